@@ -2,73 +2,90 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"go_blog/config"
+	"go_blog/internal/consumer"
 	"go_blog/internal/events"
+	"go_blog/internal/handlers"
+	"go_blog/internal/mail"
+	"go_blog/internal/realtime"
 	"go_blog/internal/repositories"
-	"go_blog/models"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
+// groupID — группа и имя в логах/DLQ. Группа обслуживает все обработчики, а не только аудит.
+// legacyGroupID — прежнее имя: на первом старте новая группа продолжает с его позиций
+// (вручную то же делает scripts/kafka-migrate-consumer-group.sh)
+const (
+	groupID       = "go_blog-consumer"
+	legacyGroupID = "audit-log-consumer"
+	eventsTopic   = "blog.events"
+)
+
 func main() {
 	config.ConnectDB()
+	config.InitRedis()
 
 	db := config.DB
 
-	auditRepo := repositories.NewAuditLogRepository(db)
+	registry := consumer.NewRegistry()
+	registry.Register(consumer.AnyEvent, handlers.NewAuditLogHandler(repositories.NewAuditLogRepository(db)))
+	registry.Register(consumer.AnyEvent, handlers.NewWebhookFanoutHandler(repositories.NewWebhookRepository(db)))
+	rt := realtime.NewPublisher(config.RDB, 0)
+	registry.Register(consumer.AnyEvent, handlers.NewRealtimeHandler(rt))
+	notify := handlers.NewUserNotifyHandler(rt)
+	registry.Register(events.CommentCreated, notify)
+	registry.Register(events.PostLiked, notify)
+	registry.Register(events.UserFollowed, notify)
+
+	inbox := handlers.NewNotificationHandler(db, repositories.NewNotificationRepository(db), repositories.NewProcessedEventRepository(db))
+	registry.Register(events.CommentCreated, inbox)
+	registry.Register(events.PostLiked, inbox)
+	registry.Register(events.UserFollowed, inbox)
+
+	composer, err := mail.NewComposer(mail.ConfigFromEnv())
+	if err != nil {
+		log.Fatalf("mail composer: %v", err)
+	}
+	email := handlers.NewEmailHandler(db, composer, repositories.NewMailOutboxRepository(db), repositories.NewNotificationRepository(db), repositories.NewProcessedEventRepository(db))
+	registry.Register(events.CommentCreated, email)
+	registry.Register(events.UserFollowed, email)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	offsets := &kafka.Client{Addr: kafka.TCP("localhost:9092"), Timeout: 10 * time.Second}
+	if err := consumer.SeedGroupOffsets(ctx, offsets, eventsTopic, legacyGroupID, groupID); err != nil {
+		log.Fatalf("seed consumer group offsets: %v", err)
+	}
 
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers: []string{"localhost:9092"},
-		Topic:   "blog.events",
-		GroupID: "audit-log-consumer",
+		Topic:   eventsTopic,
+		GroupID: groupID,
 	})
-
 	defer reader.Close()
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
+	dlq := &kafka.Writer{
+		Addr:         kafka.TCP("localhost:9092"),
+		Topic:        "blog.events.dlq",
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+	defer dlq.Close()
+
+	c := consumer.New(consumer.Config{Name: groupID}, reader, dlq, registry)
 
-	log.Println("audit consumer started")
-
-	for {
-		log.Println("waiting message...")
-		msg, err := reader.FetchMessage(ctx)
-		if err != nil {
-			log.Printf("fetch error: %v", err)
-			continue
-		}
-		log.Printf("got message offset=%d", msg.Offset)
-
-		var env events.Envelope
-		if err := json.Unmarshal(msg.Value, &env); err != nil {
-			log.Println("invalid event:", err)
-			continue
-		}
-
-		logEntry := models.AuditLog{
-			EventID:       env.EventID,
-			EventType:     env.EventType,
-			AggregateType: env.AggregateType,
-			AggregateID:   env.AggregateID,
-			ActorUserID:   env.ActorUserID,
-			Payload:       string(env.Payload),
-			OccurredAt:    env.OccurredAt,
-		}
-
-		if err := auditRepo.Create(ctx, &logEntry); err != nil {
-			log.Println("failed to save audit log:", err)
-			continue
-		}
-
-		log.Printf("processing event %s offset=%d", env.EventID, msg.Offset)
-
-		if err := reader.CommitMessages(ctx, msg); err != nil {
-			log.Println("failed to commit message:", err)
-		}
+	log.Println("event consumer started")
+
+	if err := c.Run(ctx); err != nil {
+		log.Fatalf("consumer error: %v", err)
 	}
+
+	log.Println("event consumer stopped")
 }
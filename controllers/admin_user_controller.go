@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"errors"
+	"go_blog/dto"
+	"go_blog/models"
+	"go_blog/services"
+	"go_blog/utils"
+	"go_blog/validators"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnlockUser снимает блокировку входа после серии неудачных попыток
+func UnlockUser(adminUsers *services.AdminUserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := adminUserID(c)
+		if !ok {
+			return
+		}
+		actorID, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := adminUsers.Unlock(c.Request.Context(), actorID, id); err != nil {
+			respondAdminUserError(c, err, "failed to unlock user")
+			return
+		}
+
+		utils.RespondOK(c, gin.H{"ok": true})
+	}
+}
+
+// ListRoles — роли и их права, чтобы админка не держала свою копию таблицы
+func ListRoles() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles := make([]dto.UserRoleResponse, 0, len(models.Roles))
+		for _, role := range models.Roles {
+			roles = append(roles, dto.UserRoleResponse{Role: role, Permissions: models.RolePermissions(role)})
+		}
+		utils.RespondOK(c, gin.H{"ok": true, "roles": roles})
+	}
+}
+
+func AssignRole(roleService *services.RoleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		var req dto.AssignRoleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		actorID, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		resp, err := roleService.Assign(c.Request.Context(), actorID, uint(id), req.Role)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrInvalidRole):
+				utils.RespondValidation(c, map[string]string{"Role": "неизвестная роль"})
+			case errors.Is(err, services.ErrCannotChangeOwnRole):
+				utils.RespondError(c, http.StatusConflict, "cannot change your own role")
+			case errors.Is(err, services.ErrUserNotFound):
+				utils.RespondError(c, http.StatusNotFound, "user not found")
+			default:
+				utils.RespondError(c, http.StatusInternalServerError, "failed to assign role")
+			}
+			return
+		}
+
+		utils.RespondOK(c, resp)
+	}
+}
+
+// ListUsers — ?q= по нику или адресу, ?status=active|suspended
+func ListUsers(adminUsers *services.AdminUserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit := utils.GetPage(c)
+
+		users, total, err := adminUsers.List(c.Request.Context(), c.Query("q"), c.Query("status"), page, limit)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "failed to list users")
+			return
+		}
+
+		utils.RespondOK(c, dto.AdminUserListResponse{
+			Ok:    true,
+			Page:  page,
+			Limit: limit,
+			Total: total,
+			Users: users,
+		})
+	}
+}
+
+func GetUser(adminUsers *services.AdminUserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := adminUserID(c)
+		if !ok {
+			return
+		}
+
+		resp, err := adminUsers.Get(c.Request.Context(), id)
+		if err != nil {
+			respondAdminUserError(c, err, "failed to get user")
+			return
+		}
+
+		utils.RespondOK(c, resp)
+	}
+}
+
+func SuspendUser(adminUsers *services.AdminUserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := adminUserID(c)
+		if !ok {
+			return
+		}
+
+		var req dto.SuspendUserRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		actorID, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		resp, err := adminUsers.Suspend(c.Request.Context(), actorID, id, req.Reason, req.ExpiresAt)
+		if err != nil {
+			respondAdminUserError(c, err, "failed to suspend user")
+			return
+		}
+
+		utils.RespondOK(c, resp)
+	}
+}
+
+func ReactivateUser(adminUsers *services.AdminUserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := adminUserID(c)
+		if !ok {
+			return
+		}
+		actorID, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		resp, err := adminUsers.Reactivate(c.Request.Context(), actorID, id)
+		if err != nil {
+			respondAdminUserError(c, err, "failed to reactivate user")
+			return
+		}
+
+		utils.RespondOK(c, resp)
+	}
+}
+
+// ForcePasswordReset — пароль сбрасывается сразу, пользователю уходит письмо со ссылкой
+func ForcePasswordReset(adminUsers *services.AdminUserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := adminUserID(c)
+		if !ok {
+			return
+		}
+		actorID, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := adminUsers.ForcePasswordReset(c.Request.Context(), actorID, id); err != nil {
+			respondAdminUserError(c, err, "failed to reset password")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func adminUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid id")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondAdminUserError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.RespondError(c, http.StatusNotFound, "user not found")
+	case errors.Is(err, services.ErrCannotSuspendSelf):
+		utils.RespondError(c, http.StatusConflict, "cannot suspend yourself")
+	case errors.Is(err, services.ErrUserNotSuspended):
+		utils.RespondError(c, http.StatusConflict, "user is not suspended")
+	case errors.Is(err, services.ErrInvalidSuspensionExpiry):
+		utils.RespondValidation(c, map[string]string{"ExpiresAt": "должен быть в будущем"})
+	default:
+		utils.RespondError(c, http.StatusInternalServerError, fallback)
+	}
+}
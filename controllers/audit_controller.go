@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"errors"
+	"go_blog/dto"
+	"go_blog/internal/repositories"
+	"go_blog/services"
+	"go_blog/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ListAuditLogs(auditService *services.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var q dto.AuditQuery
+		if err := c.ShouldBindQuery(&q); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid query")
+			return
+		}
+
+		resp, err := auditService.Query(c.Request.Context(), q)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAuditQuery) {
+				utils.RespondError(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to query audit log")
+			return
+		}
+
+		utils.RespondOK(c, resp)
+	}
+}
+
+func GetPostHistory(auditService *services.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var q dto.AuditQuery
+		if err := c.ShouldBindQuery(&q); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid query")
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		resp, err := auditService.PostHistory(c.Request.Context(), c.Param("slug"), uid, c.GetString("role"), q.Cursor, q.Limit)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrPostNotFound):
+				utils.RespondError(c, http.StatusNotFound, "post not found")
+			case errors.Is(err, repositories.ErrForbidden):
+				utils.RespondError(c, http.StatusForbidden, "you are not author")
+			case errors.Is(err, services.ErrInvalidAuditQuery):
+				utils.RespondError(c, http.StatusBadRequest, err.Error())
+			default:
+				utils.RespondError(c, http.StatusInternalServerError, "failed to load post history")
+			}
+			return
+		}
+
+		utils.RespondOK(c, resp)
+	}
+}
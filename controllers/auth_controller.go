@@ -1,11 +1,17 @@
 package controllers
 
 import (
+	"errors"
 	"go_blog/dto"
+	"go_blog/middleware"
 	"go_blog/services"
 	"go_blog/utils"
 	"go_blog/validators"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -51,6 +57,11 @@ func Login(auth *services.AuthService) gin.HandlerFunc {
 
 		out, err := auth.Login(c.Request.Context(), req)
 		if err != nil {
+			if wait, ok := services.IsLoginThrottled(err); ok {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				utils.RespondError(c, http.StatusTooManyRequests, "too many login attempts, try again later")
+				return
+			}
 			if respondServiceError(c, err) {
 				return
 			}
@@ -90,7 +101,8 @@ func Refresh(auth *services.AuthService) gin.HandlerFunc {
 	}
 }
 
-func Logout(auth *services.AuthService) gin.HandlerFunc {
+// Logout: refresh-токен из тела; если передан и access-токен — он попадает в denylist до exp
+func Logout(auth *services.AuthService, sessions *services.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req dto.RefreshTokenRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -99,6 +111,115 @@ func Logout(auth *services.AuthService) gin.HandlerFunc {
 		}
 
 		_ = auth.Logout(c.Request.Context(), req.RefreshToken)
+
+		if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+			if p, err := middleware.Authenticate(strings.TrimPrefix(header, "Bearer ")); err == nil {
+				if err := sessions.RevokeAccessToken(c.Request.Context(), p.TokenID, p.ExpiresAt); err != nil {
+					log.Printf("logout: revoke access token for user %d: %v", p.UserID, err)
+				}
+			}
+		}
+
 		utils.RespondOK(c, gin.H{"message": "logged out"})
 	}
 }
+
+func VerifyEmail(verification *services.EmailVerificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.VerifyEmailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		if err := verification.Verify(c.Request.Context(), req.Token); err != nil {
+			switch {
+			case errors.Is(err, services.ErrInvalidVerificationToken):
+				utils.RespondError(c, http.StatusBadRequest, "invalid or expired verification link")
+			case errors.Is(err, services.ErrEmailAlreadyVerified):
+				utils.RespondError(c, http.StatusConflict, "email already verified")
+			default:
+				utils.RespondError(c, http.StatusInternalServerError, "verification failed")
+			}
+			return
+		}
+
+		utils.RespondOK(c, gin.H{"ok": true, "email_verified": true})
+	}
+}
+
+func ResendVerificationEmail(verification *services.EmailVerificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := verification.Resend(c.Request.Context(), uid); err != nil {
+			switch {
+			case errors.Is(err, services.ErrEmailAlreadyVerified):
+				utils.RespondError(c, http.StatusConflict, "email already verified")
+			case errors.Is(err, services.ErrTooManyRequests):
+				utils.RespondError(c, http.StatusTooManyRequests, "too many requests")
+			default:
+				utils.RespondError(c, http.StatusInternalServerError, "failed to resend verification email")
+			}
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"ok": true})
+	}
+}
+
+// ForgotPassword всегда отвечает 200 — по ответу нельзя узнать, есть ли такой адрес
+func ForgotPassword(resets *services.PasswordResetService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.ForgotPasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		if err := resets.Forgot(c.Request.Context(), req.Email); err != nil {
+			log.Printf("forgot password: %v", err)
+		}
+
+		utils.RespondOK(c, gin.H{"ok": true, "message": "if the address is registered, a reset link has been sent"})
+	}
+}
+
+func ResetPassword(resets *services.PasswordResetService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.ResetPasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		if err := resets.Reset(c.Request.Context(), req.Token, req.Password); err != nil {
+			if errors.Is(err, services.ErrInvalidResetToken) {
+				utils.RespondError(c, http.StatusBadRequest, "invalid or expired reset link")
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "password reset failed")
+			return
+		}
+
+		utils.RespondOK(c, gin.H{"ok": true, "message": "password changed, please log in again"})
+	}
+}
@@ -131,3 +131,38 @@ func TestAuthController_Logout_Idempotent(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, resp.Code)
 }
+
+func TestAuthController_Logout_RevokesAccessToken(t *testing.T) {
+	app := controllers_test.SetupAuthTestApp(t)
+
+	testhelpers.DoRequest(app,
+		testhelpers.NewJSONRequest("POST", "/auth/register", dto.RegisterRequest{
+			Nickname: "test",
+			Email:    "out@test.com",
+			Password: "123456",
+		}),
+	)
+	loginResp := testhelpers.DoRequest(app,
+		testhelpers.NewJSONRequest("POST", "/auth/login", dto.LoginRequest{
+			Email:    "out@test.com",
+			Password: "123456",
+		}),
+	)
+	require.Equal(t, http.StatusOK, loginResp.Code)
+
+	var out struct {
+		Data dto.TokenPairResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(loginResp.Body).Decode(&out))
+
+	resp := testhelpers.DoRequest(app, testhelpers.NewAuthRequest("GET", "/user/me/sessions", out.Data.AccessToken))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	logout := testhelpers.NewJSONRequest("POST", "/auth/logout", dto.RefreshTokenRequest{RefreshToken: out.Data.RefreshToken})
+	logout.Header.Set("Authorization", "Bearer "+out.Data.AccessToken)
+	require.Equal(t, http.StatusOK, testhelpers.DoRequest(app, logout).Code)
+
+	// access-токен больше не принимается, хотя exp ещё не наступил
+	resp = testhelpers.DoRequest(app, testhelpers.NewAuthRequest("GET", "/user/me/sessions", out.Data.AccessToken))
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+}
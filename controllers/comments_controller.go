@@ -6,6 +6,7 @@ import (
 	"go_blog/dto"
 	"go_blog/internal/repositories"
 	"go_blog/models"
+	"go_blog/services"
 	"go_blog/utils"
 	"go_blog/validators"
 	"net/http"
@@ -15,7 +16,7 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
-func CreateComment(repo *repositories.CommentRepository) gin.HandlerFunc {
+func CreateComment(commentService *services.CommentService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var req dto.CommentCreateRequest
@@ -40,8 +41,12 @@ func CreateComment(repo *repositories.CommentRepository) gin.HandlerFunc {
 
 		slug := c.Param("slug")
 
-		comment, err := repo.Create(c.Request.Context(), slug, uid, req.Text)
+		comment, err := commentService.Create(c.Request.Context(), slug, uid, req.ParentID, req.Text)
 		if err != nil {
+			if errors.Is(err, services.ErrInvalidParentComment) {
+				utils.RespondValidation(c, map[string]string{"ParentID": "комментарий не найден в этом посте"})
+				return
+			}
 			if repositories.IsNotFound(err) {
 				utils.RespondError(c, http.StatusNotFound, "post not found")
 				return
@@ -55,7 +60,7 @@ func CreateComment(repo *repositories.CommentRepository) gin.HandlerFunc {
 	}
 }
 
-func DeleteComment(repo *repositories.CommentRepository) gin.HandlerFunc {
+func DeleteComment(commentService *services.CommentService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
 		id, err := strconv.ParseUint(idStr, 10, 64)
@@ -69,7 +74,7 @@ func DeleteComment(repo *repositories.CommentRepository) gin.HandlerFunc {
 			return
 		}
 
-		err = repo.DeleteOwnedBy(c.Request.Context(), uint(id), uid)
+		err = commentService.Delete(c.Request.Context(), uint(id), uid, c.GetString("role"))
 		if err != nil {
 			if errors.Is(err, repositories.ErrForbidden) {
 				utils.RespondError(c, http.StatusForbidden, "you are not author")
@@ -87,11 +92,11 @@ func DeleteComment(repo *repositories.CommentRepository) gin.HandlerFunc {
 	}
 }
 
-func ListCommentsForPost(repo *repositories.CommentRepository) gin.HandlerFunc {
+func ListCommentsForPost(commentService *services.CommentService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		slug := c.Param("slug")
 
-		comments, err := repo.ListByPostSlug(c.Request.Context(), slug)
+		comments, err := commentService.ListByPostSlug(c.Request.Context(), slug)
 		if err != nil {
 			if repositories.IsNotFound(err) {
 				utils.RespondError(c, http.StatusNotFound, "post not found")
@@ -116,6 +121,7 @@ func commentToResp(c models.Comment) dto.CommentResponse {
 		Text:      c.Text,
 		PostID:    c.PostID,
 		UserID:    c.UserID,
+		ParentID:  c.ParentID,
 		CreatedAt: c.CreatedAt,
 	}
 }
@@ -1,8 +1,10 @@
 package controllers_test
 
 import (
+	"go_blog/config"
 	"go_blog/controllers"
 	"go_blog/internal/repositories"
+	"go_blog/middleware"
 	"go_blog/services"
 	"go_blog/stores"
 	"go_blog/testhelpers"
@@ -18,15 +20,22 @@ func SetupAuthTestApp(t *testing.T) *gin.Engine {
 	db := testhelpers.SetupTestDB(t)
 	rdb := testhelpers.SetupTestRedis(t)
 
+	// RequireAuth проверяет отзыв access-токенов через config.RDB
+	prevRDB := config.RDB
+	config.RDB = rdb
+	t.Cleanup(func() { config.RDB = prevRDB })
+
 	userRepo := repositories.NewUserRepository(db)
 	refreshStore := stores.NewRefreshRedisStore(rdb)
-	authSvc := services.NewAuthService(userRepo, refreshStore)
+	authSvc := services.NewAuthService(userRepo, refreshStore, services.AuthOptions{})
+	sessionSvc := services.NewSessionService(refreshStore, stores.NewAccessRevocationRedisStore(rdb))
 
 	r := gin.New()
 	r.POST("/auth/register", controllers.Register(authSvc))
 	r.POST("/auth/login", controllers.Login(authSvc))
 	r.POST("/auth/refresh", controllers.Refresh(authSvc))
-	r.POST("/auth/logout", controllers.Logout(authSvc))
+	r.POST("/auth/logout", controllers.Logout(authSvc, sessionSvc))
+	r.GET("/user/me/sessions", middleware.RequireAuth(), controllers.ListSessions(sessionSvc))
 
 	return r
 }
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"errors"
+	"go_blog/internal/repositories"
+	"go_blog/services"
+	"go_blog/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func FollowUser(followService *services.FollowService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := followService.Follow(c.Request.Context(), uid, uint(id)); err != nil {
+			switch {
+			case errors.Is(err, services.ErrCannotFollowSelf):
+				utils.RespondError(c, http.StatusBadRequest, "cannot follow yourself")
+			case errors.Is(err, services.ErrUserNotFound):
+				utils.RespondError(c, http.StatusNotFound, "user not found")
+			case errors.Is(err, repositories.ErrAlreadyFollowing):
+				utils.RespondError(c, http.StatusConflict, "already following")
+			default:
+				utils.RespondError(c, http.StatusInternalServerError, "failed to follow")
+			}
+			return
+		}
+
+		utils.RespondOK(c, gin.H{"following": true})
+	}
+}
+
+func UnfollowUser(followService *services.FollowService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := followService.Unfollow(c.Request.Context(), uid, uint(id)); err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "failed to unfollow")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	"go_blog/internal/jwtkeys"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS — публичные ключи для проверки access-токенов другими сервисами (RFC 7517, без обёртки ok)
+func JWKS(keyring *jwtkeys.Keyring) gin.HandlerFunc {
+	set := keyring.JWKS()
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, set)
+	}
+}
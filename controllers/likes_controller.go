@@ -3,13 +3,14 @@ package controllers
 import (
 	"errors"
 	"go_blog/internal/repositories"
+	"go_blog/services"
 	"go_blog/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-func LikePost(repo *repositories.LikeRepository) gin.HandlerFunc {
+func LikePost(likeService *services.LikeService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		slug := c.Param("slug")
 
@@ -18,7 +19,7 @@ func LikePost(repo *repositories.LikeRepository) gin.HandlerFunc {
 			return
 		}
 
-		err := repo.Like(c.Request.Context(), slug, uid)
+		err := likeService.Like(c.Request.Context(), slug, uid)
 		if err != nil {
 			if repositories.IsNotFound(err) {
 				utils.RespondError(c, http.StatusNotFound, "post not found")
@@ -37,7 +38,7 @@ func LikePost(repo *repositories.LikeRepository) gin.HandlerFunc {
 	}
 }
 
-func UnlikePost(repo *repositories.LikeRepository) gin.HandlerFunc {
+func UnlikePost(likeService *services.LikeService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		slug := c.Param("slug")
@@ -47,7 +48,7 @@ func UnlikePost(repo *repositories.LikeRepository) gin.HandlerFunc {
 			return
 		}
 
-		err := repo.Unlike(c.Request.Context(), slug, uid)
+		err := likeService.Unlike(c.Request.Context(), slug, uid)
 		if err != nil {
 			if repositories.IsNotFound(err) {
 				utils.RespondError(c, http.StatusNotFound, "post not found")
@@ -62,11 +63,11 @@ func UnlikePost(repo *repositories.LikeRepository) gin.HandlerFunc {
 	}
 }
 
-func GetPostLikes(repo *repositories.LikeRepository) gin.HandlerFunc {
+func GetPostLikes(likeService *services.LikeService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		slug := c.Param("slug")
 
-		count, err := repo.CountByPostSlug(c.Request.Context(), slug)
+		count, err := likeService.Count(c.Request.Context(), slug)
 		if err != nil {
 			if repositories.IsNotFound(err) {
 				utils.RespondError(c, http.StatusNotFound, "post not found")
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"go_blog/dto"
+	"go_blog/services"
+	"go_blog/utils"
+	"go_blog/validators"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func respondMFAError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFAToken):
+		utils.RespondError(c, http.StatusUnauthorized, "invalid or expired mfa token")
+	case errors.Is(err, services.ErrInvalidCredentials):
+		utils.RespondError(c, http.StatusUnauthorized, "invalid credentials")
+	case errors.Is(err, services.ErrInvalidMFACode):
+		utils.RespondError(c, http.StatusUnauthorized, "invalid mfa code")
+	case errors.Is(err, services.ErrTooManyRequests):
+		utils.RespondError(c, http.StatusTooManyRequests, "too many attempts")
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		utils.RespondError(c, http.StatusConflict, "two-factor authentication already enabled")
+	case errors.Is(err, services.ErrMFANotEnabled):
+		utils.RespondError(c, http.StatusConflict, "two-factor authentication is not enabled")
+	case errors.Is(err, services.ErrWrongPassword):
+		utils.RespondError(c, http.StatusForbidden, "current password is incorrect")
+	default:
+		utils.RespondError(c, http.StatusInternalServerError, fallback)
+	}
+}
+
+// VerifyMFA — вторая ступень входа: mfa_token из /auth/login + код
+func VerifyMFA(mfa *services.MFAService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.MFAVerifyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		out, err := mfa.Verify(c.Request.Context(), req.MFAToken, req.Code)
+		if err != nil {
+			respondMFAError(c, err, "mfa verification failed")
+			return
+		}
+
+		utils.RespondOK(c, out)
+	}
+}
+
+func EnrollMFA(mfa *services.MFAService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.MFAEnrollRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		out, err := mfa.Enroll(c.Request.Context(), uid, req.Password)
+		if err != nil {
+			respondMFAError(c, err, "failed to start mfa enrollment")
+			return
+		}
+
+		utils.RespondOK(c, out)
+	}
+}
+
+// ConfirmMFA отвечает кодами восстановления — больше они не показываются
+func ConfirmMFA(mfa *services.MFAService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.MFAConfirmRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		out, err := mfa.Confirm(c.Request.Context(), uid, req.Password, req.Code)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidMFACode) {
+				utils.RespondError(c, http.StatusBadRequest, "invalid mfa code")
+				return
+			}
+			respondMFAError(c, err, "failed to enable mfa")
+			return
+		}
+
+		utils.RespondOK(c, out)
+	}
+}
+
+func DisableMFA(mfa *services.MFAService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.MFAReauthRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := mfa.Disable(c.Request.Context(), uid, req.Password, req.Code); err != nil {
+			respondMFAError(c, err, "failed to disable mfa")
+			return
+		}
+
+		utils.RespondOK(c, gin.H{"ok": true, "mfa_enabled": false})
+	}
+}
+
+func RegenerateRecoveryCodes(mfa *services.MFAService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.MFAReauthRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		out, err := mfa.RegenerateRecoveryCodes(c.Request.Context(), uid, req.Password, req.Code)
+		if err != nil {
+			respondMFAError(c, err, "failed to regenerate recovery codes")
+			return
+		}
+
+		utils.RespondOK(c, out)
+	}
+}
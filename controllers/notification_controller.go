@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"errors"
+	"go_blog/dto"
+	"go_blog/internal/mail"
+	"go_blog/services"
+	"go_blog/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ListNotifications(notificationService *services.NotificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		page, limit := utils.GetPage(c)
+		unreadOnly := c.Query("unread") == "true" || c.Query("unread") == "1"
+
+		resp, err := notificationService.List(c.Request.Context(), uid, unreadOnly, page, limit)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "failed to list notifications")
+			return
+		}
+
+		utils.RespondOK(c, resp)
+	}
+}
+
+func MarkNotificationRead(notificationService *services.NotificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := notificationService.MarkRead(c.Request.Context(), uid, uint(id)); err != nil {
+			if errors.Is(err, services.ErrNotificationNotFound) {
+				utils.RespondError(c, http.StatusNotFound, "notification not found")
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to mark notification read")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func MarkAllNotificationsRead(notificationService *services.NotificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		n, err := notificationService.MarkAllRead(c.Request.Context(), uid)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "failed to mark notifications read")
+			return
+		}
+
+		utils.RespondOK(c, gin.H{"ok": true, "updated": n})
+	}
+}
+
+func GetNotificationPreferences(notificationService *services.NotificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		prefs, err := notificationService.Preferences(c.Request.Context(), uid)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "failed to load preferences")
+			return
+		}
+
+		utils.RespondOK(c, gin.H{"ok": true, "preferences": prefs})
+	}
+}
+
+func UpdateNotificationPreferences(notificationService *services.NotificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.NotificationPreferencesRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		prefs, err := notificationService.SetPreferences(c.Request.Context(), uid, req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidNotificationType) {
+				utils.RespondError(c, http.StatusBadRequest, "unknown notification type")
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to update preferences")
+			return
+		}
+
+		utils.RespondOK(c, gin.H{"ok": true, "preferences": prefs})
+	}
+}
+
+// UnsubscribePage — GET по ссылке из письма: только страница с кнопкой подтверждения.
+// Ссылки открывают сканеры почты и предзагрузка клиентов — от GET ничего не меняется.
+func UnsubscribePage(notificationService *services.NotificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		category, err := notificationService.UnsubscribeCategory(token)
+		if err != nil {
+			renderUnsubscribePage(c, http.StatusBadRequest, unsubscribeView{Invalid: true})
+			return
+		}
+		renderUnsubscribePage(c, http.StatusOK, unsubscribeView{Token: token, Category: unsubscribeCategoryName(category)})
+	}
+}
+
+// Unsubscribe — POST с формы страницы подтверждения и one-click из почтового клиента (RFC 8058:
+// тело List-Unsubscribe=One-Click, токен в ссылке). Браузеру — страница, остальным — JSON.
+func Unsubscribe(notificationService *services.NotificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		html := c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML
+
+		token := c.Query("token")
+		if token == "" {
+			token = c.PostForm("token")
+		}
+		if token == "" {
+			if html {
+				renderUnsubscribePage(c, http.StatusBadRequest, unsubscribeView{Invalid: true})
+				return
+			}
+			utils.RespondError(c, http.StatusBadRequest, "token is required")
+			return
+		}
+
+		category, err := notificationService.Unsubscribe(c.Request.Context(), token)
+		if err != nil {
+			if errors.Is(err, mail.ErrInvalidUnsubscribeToken) {
+				if html {
+					renderUnsubscribePage(c, http.StatusBadRequest, unsubscribeView{Invalid: true})
+					return
+				}
+				utils.RespondError(c, http.StatusBadRequest, "invalid unsubscribe token")
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to unsubscribe")
+			return
+		}
+
+		if html {
+			renderUnsubscribePage(c, http.StatusOK, unsubscribeView{Done: true, Category: unsubscribeCategoryName(category)})
+			return
+		}
+		utils.RespondOK(c, gin.H{"ok": true, "unsubscribed": category})
+	}
+}
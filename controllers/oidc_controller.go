@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"errors"
+	"go_blog/dto"
+	"go_blog/services"
+	"go_blog/utils"
+	"go_blog/validators"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+func respondOIDCError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrUnknownOIDCProvider):
+		utils.RespondError(c, http.StatusNotFound, "unknown identity provider")
+	case errors.Is(err, services.ErrInvalidOIDCState), errors.Is(err, services.ErrInvalidOIDCLoginCode):
+		utils.RespondError(c, http.StatusBadRequest, "invalid or expired login attempt, please start again")
+	case errors.Is(err, services.ErrOIDCProvider):
+		utils.RespondError(c, http.StatusBadGateway, "identity provider login failed")
+	case errors.Is(err, services.ErrOIDCEmailRequired):
+		utils.RespondError(c, http.StatusBadRequest, "identity provider did not share an email address")
+	case errors.Is(err, services.ErrOIDCEmailUnverified):
+		utils.RespondError(c, http.StatusBadRequest, "identity provider has not verified your email address")
+	case errors.Is(err, services.ErrInvalidLinkToken):
+		utils.RespondError(c, http.StatusBadRequest, "invalid or expired link token")
+	case errors.Is(err, services.ErrIdentityLinked):
+		utils.RespondError(c, http.StatusConflict, "this identity is already linked")
+	default:
+		if respondServiceError(c, err) {
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, fallback)
+	}
+}
+
+func ListOIDCProviders(oidc *services.OIDCService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		utils.RespondOK(c, gin.H{"providers": oidc.Providers()})
+	}
+}
+
+// oidcStateCookie привязывает вход к браузеру, который его начал. Lax: cookie уходит
+// на top-level redirect от провайдера, но не на чужие фоновые запросы.
+const (
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/auth/oidc"
+)
+
+func setOIDCStateCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, oidcCookiePath, "", secure, true)
+}
+
+// OIDCStart — ссылка, по которой фронтенд отправляет пользователя к провайдеру
+func OIDCStart(oidc *services.OIDCService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authURL, state, err := oidc.Start(c.Request.Context(), c.Param("provider"))
+		if err != nil {
+			respondOIDCError(c, err, "failed to start login")
+			return
+		}
+
+		setOIDCStateCookie(c, state, int(services.OIDCStateTTL.Seconds()))
+		utils.RespondOK(c, dto.OIDCStartResponse{AuthorizationURL: authURL})
+	}
+}
+
+// OIDCCallback — сюда провайдер возвращает пользователя с code и state. Токены в ответ
+// на навигацию не отдаём: браузер уходит на frontendURL с одноразовым ?code=, который
+// SPA обменивает POST'ом на /auth/oidc/exchange.
+func OIDCCallback(oidc *services.OIDCService, frontendURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bound, _ := c.Cookie(oidcStateCookie)
+		setOIDCStateCookie(c, "", -1)
+
+		if c.Query("error") != "" {
+			utils.RespondError(c, http.StatusBadRequest, "identity provider denied access")
+			return
+		}
+		code, state := c.Query("code"), c.Query("state")
+		if code == "" || state == "" {
+			utils.RespondError(c, http.StatusBadRequest, "code and state are required")
+			return
+		}
+
+		loginCode, err := oidc.Callback(c.Request.Context(), c.Param("provider"), state, bound, code)
+		if err != nil {
+			respondOIDCError(c, err, "login failed")
+			return
+		}
+
+		c.Redirect(http.StatusFound, frontendURL+"?code="+url.QueryEscape(loginCode))
+	}
+}
+
+// OIDCExchange — SPA меняет одноразовый код из redirect'а на токены или link_token
+func OIDCExchange(oidc *services.OIDCService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.OIDCExchangeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		out, err := oidc.Exchange(c.Request.Context(), req.Code)
+		if err != nil {
+			respondOIDCError(c, err, "login failed")
+			return
+		}
+
+		utils.RespondOK(c, out)
+	}
+}
+
+// LinkIdentity — подтверждение привязки из уже вошедшего аккаунта
+func LinkIdentity(oidc *services.OIDCService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.OIDCLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := oidc.ConfirmLink(c.Request.Context(), uid, req.LinkToken); err != nil {
+			respondOIDCError(c, err, "failed to link identity")
+			return
+		}
+
+		utils.RespondCreated(c, gin.H{"ok": true})
+	}
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"errors"
+	"go_blog/dto"
+	"go_blog/services"
+	"go_blog/utils"
+	"go_blog/validators"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreatePersonalToken отвечает самим токеном — больше он не показывается
+func CreatePersonalToken(tokenService *services.PersonalTokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.CreatePersonalTokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		resp, err := tokenService.Create(c.Request.Context(), uid, req)
+		if err != nil {
+			if respondPersonalTokenError(c, err) {
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to create token")
+			return
+		}
+
+		utils.RespondCreated(c, resp)
+	}
+}
+
+func ListPersonalTokens(tokenService *services.PersonalTokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		resp, err := tokenService.List(c.Request.Context(), uid)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "failed to list tokens")
+			return
+		}
+
+		utils.RespondOK(c, gin.H{"ok": true, "tokens": resp})
+	}
+}
+
+func RevokePersonalToken(tokenService *services.PersonalTokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := tokenService.Revoke(c.Request.Context(), uid, uint(id)); err != nil {
+			if respondPersonalTokenError(c, err) {
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to revoke token")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func respondPersonalTokenError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrPersonalTokenNotFound):
+		utils.RespondError(c, http.StatusNotFound, "token not found")
+	case errors.Is(err, services.ErrInvalidScope):
+		utils.RespondValidation(c, map[string]string{"Scopes": err.Error()})
+	case errors.Is(err, services.ErrTooManyPersonalTokens):
+		utils.RespondError(c, http.StatusConflict, "token limit reached, revoke unused tokens first")
+	default:
+		return false
+	}
+	return true
+}
@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"go_blog/dto"
@@ -36,7 +35,7 @@ func CreatePost(postService *services.PostService) gin.HandlerFunc {
 			return
 		}
 
-		post, err := postService.Create(context.Background(), uid, req.Title, req.Text)
+		post, err := postService.Create(c.Request.Context(), uid, req.Title, req.Text)
 		if err != nil {
 			utils.RespondError(c, http.StatusInternalServerError, "failed to create post")
 			return
@@ -70,7 +69,7 @@ func UpdatePost(postService *services.PostService) gin.HandlerFunc {
 			return
 		}
 
-		post, err := postService.Update(context.Background(), slug, uid, req.Title, req.Text)
+		post, err := postService.Update(c.Request.Context(), slug, uid, c.GetString("role"), req.Title, req.Text)
 		if err != nil {
 			switch {
 			case errors.Is(err, services.ErrNoFieldsToUpdate):
@@ -96,7 +95,7 @@ func DeletePost(postService *services.PostService) gin.HandlerFunc {
 			return
 		}
 
-		err := postService.Delete(c.Request.Context(), slug, uid)
+		err := postService.Delete(c.Request.Context(), slug, uid, c.GetString("role"))
 		if err != nil {
 			if errors.Is(err, services.ErrPostNotFound) {
 				utils.RespondError(c, http.StatusNotFound, "post not found")
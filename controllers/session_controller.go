@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"errors"
+	"go_blog/services"
+	"go_blog/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ListSessions(sessionService *services.SessionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		sessions, err := sessionService.List(c.Request.Context(), uid, c.GetString("sessionID"))
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "failed to list sessions")
+			return
+		}
+
+		utils.RespondOK(c, gin.H{"ok": true, "sessions": sessions})
+	}
+}
+
+func RevokeSession(sessionService *services.SessionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := sessionService.Revoke(c.Request.Context(), uid, c.Param("id")); err != nil {
+			if errors.Is(err, services.ErrSessionNotFound) {
+				utils.RespondError(c, http.StatusNotFound, "session not found")
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to revoke session")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RevokeAllSessions — «выйти везде»
+func RevokeAllSessions(sessionService *services.SessionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := sessionService.RevokeAll(c.Request.Context(), uid); err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "failed to revoke sessions")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
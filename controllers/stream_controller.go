@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"errors"
+	"go_blog/internal/realtime"
+	"go_blog/services"
+	"go_blog/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lastEventID: заголовок ставит сам EventSource при переподключении,
+// query-параметр — для первого подключения с сохранённой позиции
+func lastEventID(c *gin.Context) string {
+	if v := c.GetHeader("Last-Event-ID"); v != "" {
+		return v
+	}
+	return c.Query("last_event_id")
+}
+
+func StreamPost(hub *realtime.Hub, postService *services.PostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		post, err := postService.Get(c.Request.Context(), c.Param("slug"))
+		if err != nil {
+			if errors.Is(err, services.ErrPostNotFound) {
+				utils.RespondError(c, http.StatusNotFound, "post not found")
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to get post")
+			return
+		}
+
+		topic := realtime.PostTopic(strconv.FormatUint(uint64(post.ID), 10))
+		realtime.ServeSSE(c.Request.Context(), c.Writer, hub, topic, lastEventID(c), realtime.DefaultHeartbeat)
+	}
+}
+
+func StreamGlobal(hub *realtime.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		realtime.ServeSSE(c.Request.Context(), c.Writer, hub, realtime.GlobalTopic, lastEventID(c), realtime.DefaultHeartbeat)
+	}
+}
@@ -0,0 +1,35 @@
+package controllers_test
+
+import (
+	"go_blog/controllers"
+	"go_blog/internal/mail"
+	"go_blog/services"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// GET ничего не меняет: у сервиса нет репозитория, запись в него упала бы паникой
+func TestUnsubscribePage_OnlyConfirms(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := mail.NewSigner("secret")
+	r := gin.New()
+	r.GET("/unsubscribe", controllers.UnsubscribePage(services.NewNotificationService(nil, signer)))
+
+	token := signer.Token(7, mail.CategoryDigest)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unsubscribe?token="+url.QueryEscape(token), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, w.Body.String(), `<form method="post" action="?token=`+url.QueryEscape(token)+`">`)
+	require.Contains(t, w.Body.String(), "the weekly digest")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unsubscribe?token=forged", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "invalid or has expired")
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"bytes"
+	"go_blog/internal/mail"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type unsubscribeView struct {
+	Token    string
+	Category string
+	Invalid  bool
+	Done     bool
+}
+
+var unsubscribeTmpl = template.Must(template.New("unsubscribe").Parse(`<!doctype html>
+<html lang="en">
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Unsubscribe</title></head>
+<body>
+{{- if .Invalid}}
+<p>This unsubscribe link is invalid or has expired. You can change email settings in your account.</p>
+{{- else if .Done}}
+<p>You have been unsubscribed from {{.Category}}.</p>
+{{- else}}
+<p>Stop receiving {{.Category}}?</p>
+<form method="post" action="?token={{.Token}}">
+<button type="submit">Unsubscribe</button>
+</form>
+{{- end}}
+</body>
+</html>
+`))
+
+func unsubscribeCategoryName(category string) string {
+	switch category {
+	case mail.CategoryActivity:
+		return "activity emails"
+	case mail.CategoryDigest:
+		return "the weekly digest"
+	}
+	return category
+}
+
+func renderUnsubscribePage(c *gin.Context, status int, v unsubscribeView) {
+	var buf bytes.Buffer
+	if err := unsubscribeTmpl.Execute(&buf, v); err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
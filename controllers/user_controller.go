@@ -3,8 +3,10 @@ package controllers
 import (
 	"errors"
 	"github.com/gin-gonic/gin"
+	"go_blog/dto"
 	"go_blog/services"
 	"go_blog/utils"
+	"go_blog/validators"
 	"gorm.io/gorm"
 	"net/http"
 )
@@ -28,3 +30,104 @@ func GetCurrentUser(userService *services.UserService) gin.HandlerFunc {
 		utils.RespondOK(c, resp)
 	}
 }
+
+func respondAccountError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrWrongPassword):
+		utils.RespondError(c, http.StatusForbidden, "current password is incorrect")
+	case errors.Is(err, services.ErrSameEmail):
+		utils.RespondError(c, http.StatusBadRequest, "new email is the same as the current one")
+	case errors.Is(err, services.ErrEmailTaken):
+		utils.RespondError(c, http.StatusConflict, "email already taken")
+	case errors.Is(err, services.ErrInvalidVerificationToken):
+		utils.RespondError(c, http.StatusBadRequest, "invalid or expired confirmation link")
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondError(c, http.StatusNotFound, "user not found")
+	default:
+		utils.RespondError(c, http.StatusInternalServerError, fallback)
+	}
+}
+
+// ChangePassword отвечает новой парой токенов: остальные сессии завершены
+func ChangePassword(userService *services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.ChangePasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		out, err := userService.ChangePassword(c.Request.Context(), uid, req.CurrentPassword, req.NewPassword)
+		if err != nil {
+			respondAccountError(c, err, "failed to change password")
+			return
+		}
+
+		utils.RespondOK(c, out)
+	}
+}
+
+func ChangeEmail(userService *services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.ChangeEmailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := userService.RequestEmailChange(c.Request.Context(), uid, req.Password, req.Email); err != nil {
+			respondAccountError(c, err, "failed to change email")
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"ok": true, "message": "confirmation link sent to the new address"})
+	}
+}
+
+func ConfirmEmailChange(userService *services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.ConfirmEmailChangeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		out, err := userService.ConfirmEmailChange(c.Request.Context(), uid, req.Token)
+		if err != nil {
+			respondAccountError(c, err, "failed to confirm email change")
+			return
+		}
+
+		utils.RespondOK(c, out)
+	}
+}
@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"errors"
+	"go_blog/dto"
+	"go_blog/models"
+	"go_blog/services"
+	"go_blog/utils"
+	"go_blog/validators"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateWebhook(webhookService *services.WebhookService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.WebhookCreateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		resp, err := webhookService.Create(c.Request.Context(), uid, req)
+		if err != nil {
+			if respondWebhookError(c, err) {
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to create webhook")
+			return
+		}
+
+		utils.RespondCreated(c, resp)
+	}
+}
+
+func ListWebhooks(webhookService *services.WebhookService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		resp, err := webhookService.List(c.Request.Context(), uid)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "failed to list webhooks")
+			return
+		}
+
+		utils.RespondOK(c, gin.H{"ok": true, "webhooks": resp})
+	}
+}
+
+func GetWebhook(webhookService *services.WebhookService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := webhookID(c)
+		if !ok {
+			return
+		}
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		resp, err := webhookService.Get(c.Request.Context(), uid, id)
+		if err != nil {
+			if respondWebhookError(c, err) {
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to get webhook")
+			return
+		}
+
+		utils.RespondOK(c, resp)
+	}
+}
+
+func UpdateWebhook(webhookService *services.WebhookService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := webhookID(c)
+		if !ok {
+			return
+		}
+
+		var req dto.WebhookUpdateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if err := validators.Validate.Struct(req); err != nil {
+			utils.RespondValidation(c, validationErrors(err))
+			return
+		}
+
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		resp, err := webhookService.Update(c.Request.Context(), uid, id, req)
+		if err != nil {
+			if respondWebhookError(c, err) {
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to update webhook")
+			return
+		}
+
+		utils.RespondOK(c, resp)
+	}
+}
+
+func DeleteWebhook(webhookService *services.WebhookService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := webhookID(c)
+		if !ok {
+			return
+		}
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := webhookService.Delete(c.Request.Context(), uid, id); err != nil {
+			if respondWebhookError(c, err) {
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to delete webhook")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func ListWebhookDeliveries(webhookService *services.WebhookService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := webhookID(c)
+		if !ok {
+			return
+		}
+		uid, ok := utils.MustUserID(c)
+		if !ok {
+			return
+		}
+
+		page, limit := utils.GetPage(c)
+
+		items, total, err := webhookService.Deliveries(c.Request.Context(), uid, id, page, limit)
+		if err != nil {
+			if respondWebhookError(c, err) {
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, "failed to list deliveries")
+			return
+		}
+
+		out := make([]dto.WebhookDeliveryResponse, 0, len(items))
+		for _, d := range items {
+			out = append(out, deliveryToResp(d))
+		}
+
+		utils.RespondOK(c, dto.WebhookDeliveryListResponse{
+			Ok:         true,
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			Deliveries: out,
+		})
+	}
+}
+
+func webhookID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid id")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondWebhookError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		utils.RespondError(c, http.StatusNotFound, "webhook not found")
+	case errors.Is(err, services.ErrInvalidWebhookURL):
+		utils.RespondValidation(c, map[string]string{"URL": "должен быть http(s) URL с публичным адресом"})
+	case errors.Is(err, services.ErrInvalidEventType):
+		utils.RespondValidation(c, map[string]string{"EventTypes": err.Error()})
+	case errors.Is(err, services.ErrNoFieldsToUpdate):
+		utils.RespondError(c, http.StatusBadRequest, "no fields to update")
+	default:
+		return false
+	}
+	return true
+}
+
+func deliveryToResp(d models.WebhookDelivery) dto.WebhookDeliveryResponse {
+	attempts := make([]dto.WebhookAttemptResponse, 0, len(d.AttemptsLog))
+	for _, a := range d.AttemptsLog {
+		attempts = append(attempts, dto.WebhookAttemptResponse{
+			AttemptNo:  a.AttemptNo,
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			DurationMs: a.DurationMs,
+			CreatedAt:  a.CreatedAt,
+		})
+	}
+
+	return dto.WebhookDeliveryResponse{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		NextAttemptAt:  d.NextAttemptAt,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+		AttemptsLog:    attempts,
+	}
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"go_blog/internal/realtime"
+	"go_blog/internal/ws"
+	"go_blog/middleware"
+	"go_blog/services"
+	"go_blog/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// авторизация по токену, а не по cookie — чужой origin без токена ничего не получит
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// bearerPrincipal — access-токен из Authorization: подпись, срок и отзыв
+func bearerPrincipal(c *gin.Context, token string) (middleware.Principal, bool) {
+	if token == "" {
+		utils.RespondError(c, http.StatusUnauthorized, "missing bearer token")
+		return middleware.Principal{}, false
+	}
+
+	p, err := middleware.Authenticate(token)
+	if err == nil {
+		err = middleware.CheckRevoked(c.Request.Context(), p)
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, err.Error())
+		return middleware.Principal{}, false
+	}
+	return p, true
+}
+
+// IssueWSTicket — POST /ws/ticket: одноразовый билет для GET /ws?ticket=
+func IssueWSTicket(tickets *services.WSTicketService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if _, ok := bearerPrincipal(c, token); !ok {
+			return
+		}
+
+		out, err := tickets.Issue(c.Request.Context(), token)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "failed to issue ticket")
+			return
+		}
+		utils.RespondCreated(c, out)
+	}
+}
+
+// Notifications — GET /ws. Браузерный WebSocket не умеет ставить Authorization,
+// поэтому вместо токена можно передать ?ticket= из POST /ws/ticket: токен в URL
+// попал бы в логи, а билет одноразовый и живёт секунды
+func Notifications(hub *realtime.Hub, tickets *services.WSTicketService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if ticket := c.Query("ticket"); token == "" && ticket != "" {
+			var err error
+			token, err = tickets.Redeem(c.Request.Context(), ticket)
+			if err != nil {
+				if errors.Is(err, services.ErrInvalidWSTicket) {
+					utils.RespondError(c, http.StatusUnauthorized, err.Error())
+				} else {
+					utils.RespondError(c, http.StatusServiceUnavailable, "auth temporarily unavailable")
+				}
+				return
+			}
+		}
+
+		p, ok := bearerPrincipal(c, token)
+		if !ok {
+			return
+		}
+
+		// подписываемся до Upgrade, чтобы не потерять уведомления сразу после подключения
+		sub := hub.Subscribe(realtime.UserTopic(strconv.FormatUint(uint64(p.UserID), 10)))
+
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// Upgrade сам ответил клиенту
+			sub.Close()
+			return
+		}
+
+		// CheckRevoked перепроверяется по ходу: logout, «выйти везде», блокировка и
+		// принудительный сброс пароля закрывают и уже открытый сокет
+		ws.Serve(conn, sub, ws.Session{
+			ExpiresAt: p.ExpiresAt,
+			Check: func(ctx context.Context) error {
+				return middleware.CheckRevoked(ctx, p)
+			},
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"go_blog/controllers"
+	"go_blog/dto"
+	"go_blog/internal/realtime"
+	"go_blog/internal/ws"
+	"go_blog/services"
+	"go_blog/stores"
+	"go_blog/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+type memWSTickets struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func (s *memWSTickets) Save(ctx context.Context, hash, accessToken string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[hash] = accessToken
+	return nil
+}
+
+func (s *memWSTickets) Consume(ctx context.Context, hash string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.m[hash]
+	if !ok {
+		return "", stores.ErrInvalidWSTicket
+	}
+	delete(s.m, hash)
+	return token, nil
+}
+
+func setupWSApp(t *testing.T) (*httptest.Server, *realtime.Hub) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	hub := realtime.NewHub(nil, 16)
+	tickets := services.NewWSTicketService(&memWSTickets{m: map[string]string{}})
+	r := gin.New()
+	r.POST("/ws/ticket", controllers.IssueWSTicket(tickets))
+	r.GET("/ws", controllers.Notifications(hub, tickets))
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, hub
+}
+
+func issueTicket(t *testing.T, srv *httptest.Server, uid uint) string {
+	t.Helper()
+	token, err := utils.GenerateAccessJWT(uid, "user")
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/ws/ticket", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var out struct {
+		Data dto.WSTicketResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	require.NotEmpty(t, out.Data.Ticket)
+	require.Equal(t, 30, out.Data.ExpiresIn)
+	return out.Data.Ticket
+}
+
+func wsURL(srv *httptest.Server, ticket string) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?ticket=" + ticket
+}
+
+func dialWS(t *testing.T, srv *httptest.Server, uid uint) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(srv, issueTicket(t, srv, uid)), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) ws.ServerMessage {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var m ws.ServerMessage
+	require.NoError(t, conn.ReadJSON(&m))
+	return m
+}
+
+func notification(event string) realtime.Message {
+	return realtime.Message{Event: event, Data: json.RawMessage(`{"post_id":"1"}`)}
+}
+
+func TestWS_RequiresToken(t *testing.T) {
+	srv, _ := setupWSApp(t)
+
+	resp, err := http.Get(srv.URL + "/ws")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp2, err := http.Get(srv.URL + "/ws?ticket=not-a-ticket")
+	require.NoError(t, err)
+	defer resp2.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp2.StatusCode)
+
+	// JWT в URL больше не принимается
+	token, err := utils.GenerateAccessJWT(1, "user")
+	require.NoError(t, err)
+	resp3, err := http.Get(srv.URL + "/ws?access_token=" + token)
+	require.NoError(t, err)
+	defer resp3.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp3.StatusCode)
+
+	// билет выдаётся только по access-токену
+	resp4, err := http.Post(srv.URL+"/ws/ticket", "application/json", nil)
+	require.NoError(t, err)
+	defer resp4.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp4.StatusCode)
+}
+
+func TestWS_TicketIsSingleUse(t *testing.T) {
+	srv, _ := setupWSApp(t)
+	ticket := issueTicket(t, srv, 1)
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(srv, ticket), nil)
+	require.NoError(t, err)
+	conn.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL(srv, ticket), nil)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestWS_DeliversOnlyOwnNotifications(t *testing.T) {
+	srv, hub := setupWSApp(t)
+	conn := dialWS(t, srv, 1)
+
+	hub.Deliver(realtime.UserTopic("2"), notification(realtime.NotifyPostLiked))
+	hub.Deliver(realtime.UserTopic("1"), notification(realtime.NotifyCommentCreated))
+
+	m := readWS(t, conn)
+	require.Equal(t, "notification", m.Op)
+	require.Equal(t, realtime.NotifyCommentCreated, m.Type)
+	require.JSONEq(t, `{"post_id":"1"}`, string(m.Data))
+}
+
+func TestWS_SubscriptionManagement(t *testing.T) {
+	srv, hub := setupWSApp(t)
+	conn := dialWS(t, srv, 1)
+
+	require.NoError(t, conn.WriteJSON(ws.ClientMessage{Op: "unsubscribe", Types: []string{realtime.NotifyPostLiked}}))
+	m := readWS(t, conn)
+	require.Equal(t, "subscribed", m.Op)
+	require.NotContains(t, m.Types, realtime.NotifyPostLiked)
+	require.Contains(t, m.Types, realtime.NotifyCommentReply)
+
+	hub.Deliver(realtime.UserTopic("1"), notification(realtime.NotifyPostLiked))
+	hub.Deliver(realtime.UserTopic("1"), notification(realtime.NotifyCommentReply))
+
+	m = readWS(t, conn)
+	require.Equal(t, realtime.NotifyCommentReply, m.Type)
+
+	require.NoError(t, conn.WriteJSON(ws.ClientMessage{Op: "subscribe", Types: []string{"nope"}}))
+	m = readWS(t, conn)
+	require.Equal(t, "error", m.Op)
+
+	require.NoError(t, conn.WriteJSON(ws.ClientMessage{Op: "ping"}))
+	require.Equal(t, "pong", readWS(t, conn).Op)
+}
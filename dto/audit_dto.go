@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditQuery — query-параметры GET /admin/audit
+type AuditQuery struct {
+	EventType     string `form:"event_type"` // через запятую
+	AggregateType string `form:"aggregate_type"`
+	AggregateID   string `form:"aggregate_id"`
+	Actor         string `form:"actor"`
+	From          string `form:"from"` // RFC3339
+	To            string `form:"to"`
+	Payload       string `form:"payload"` // JSON-объект, например {"slug":"hello"}
+	Cursor        string `form:"cursor"`
+	Limit         int    `form:"limit"`
+}
+
+type AuditLogResponse struct {
+	ID            uint            `json:"id"`
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	ActorUserID   string          `json:"actor_user_id,omitempty"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+type AuditLogListResponse struct {
+	Ok         bool               `json:"ok"`
+	Items      []AuditLogResponse `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
@@ -16,12 +16,85 @@ type RefreshTokenRequest struct {
 }
 
 type RegisterResponse struct {
-	ID       uint   `json:"id"`
-	Nickname string `json:"nickname"`
-	Email    string `json:"email"`
+	ID            uint   `json:"id"`
+	Nickname      string `json:"nickname"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
 type TokenPairResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+// LoginResponse: при включённой 2FA токенов ещё нет — mfa_token меняется на пару в /auth/mfa/verify
+type LoginResponse struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
+
+// MFAVerifyRequest: code — 6 цифр из приложения или код восстановления
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required,max=20"`
+}
+
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// MFAEnrollRequest — подключение 2FA, как и смена пароля, требует текущий пароль
+type MFAEnrollRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type MFAConfirmRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// MFAReauthRequest — выключение 2FA и перевыпуск кодов требуют пароль и действующий код
+type MFAReauthRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,max=20"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type OIDCStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// OIDCCallbackResponse: link_required — адрес уже занят аккаунтом; нужно войти в него
+// и подтвердить привязку link_token'ом в /user/me/identities
+type OIDCCallbackResponse struct {
+	LoginResponse
+	LinkRequired bool   `json:"link_required,omitempty"`
+	LinkToken    string `json:"link_token,omitempty"`
+}
+
+type OIDCExchangeRequest struct {
+	Code string `json:"code" validate:"required,max=100"`
+}
+
+type OIDCLinkRequest struct {
+	LinkToken string `json:"link_token" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
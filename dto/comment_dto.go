@@ -3,7 +3,8 @@ package dto
 import "time"
 
 type CommentCreateRequest struct {
-	Text string `json:"text" validate:"required"`
+	Text     string `json:"text" validate:"required"`
+	ParentID *uint  `json:"parent_id" validate:"omitempty,gt=0"`
 }
 
 type CommentResponse struct {
@@ -11,5 +12,6 @@ type CommentResponse struct {
 	Text      string    `json:"text"`
 	PostID    uint      `json:"post_id"`
 	UserID    uint      `json:"user_id"`
+	ParentID  *uint     `json:"parent_id,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type NotificationResponse struct {
+	ID          uint            `json:"id"`
+	Type        string          `json:"type"`
+	ActorUserID uint            `json:"actor_user_id"`
+	ActorCount  int             `json:"actor_count"`
+	PostID      *uint           `json:"post_id,omitempty"`
+	CommentID   *uint           `json:"comment_id,omitempty"`
+	Data        json.RawMessage `json:"data"`
+	Read        bool            `json:"read"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type NotificationListResponse struct {
+	Ok            bool                   `json:"ok"`
+	Page          int                    `json:"page"`
+	Limit         int                    `json:"limit"`
+	Total         int64                  `json:"total"`
+	UnreadCount   int64                  `json:"unread_count"`
+	Notifications []NotificationResponse `json:"notifications"`
+}
+
+// NotificationPreferencesRequest: {"post.liked": false}
+type NotificationPreferencesRequest map[string]bool
+
+// WSTicketResponse — билет для GET /ws?ticket=; одноразовый, живёт expires_in секунд
+type WSTicketResponse struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expires_in"`
+}
@@ -1,6 +1,9 @@
 package dto
 
-import "go_blog/models"
+import (
+	"go_blog/models"
+	"time"
+)
 
 type UserResponse struct {
 	ID       uint          `json:"id"`
@@ -10,7 +13,100 @@ type UserResponse struct {
 }
 
 type UserMeResponse struct {
-	ID       uint   `json:"id"`
-	Nickname string `json:"nickname"`
-	Email    string `json:"email"`
+	ID            uint   `json:"id"`
+	Nickname      string `json:"nickname"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+type CreatePersonalTokenRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,required"`
+	// без срока — токен действует до отзыва
+	ExpiresInDays *int `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+}
+
+type PersonalTokenResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// сам токен показываем только при создании
+	Token string `json:"token,omitempty"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+type UserRoleResponse struct {
+	UserID      uint     `json:"user_id"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+	// без срока — до ручного снятия
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type SuspensionResponse struct {
+	At     time.Time  `json:"at"`
+	Until  *time.Time `json:"until,omitempty"`
+	Reason string     `json:"reason"`
+}
+
+type AdminUserResponse struct {
+	ID            uint                `json:"id"`
+	Nickname      string              `json:"nickname"`
+	Email         string              `json:"email"`
+	Role          string              `json:"role"`
+	IsActive      bool                `json:"is_active"`
+	EmailVerified bool                `json:"email_verified"`
+	CreatedAt     time.Time           `json:"created_at"`
+	Suspension    *SuspensionResponse `json:"suspension,omitempty"`
+}
+
+type AdminUserDetailResponse struct {
+	AdminUserResponse
+	Permissions []string `json:"permissions"`
+	MFAEnabled  bool     `json:"mfa_enabled"`
+	// провайдеры привязанного входа (OIDC)
+	Identities []string `json:"identities"`
+}
+
+type AdminUserListResponse struct {
+	Ok    bool                `json:"ok"`
+	Page  int                 `json:"page"`
+	Limit int                 `json:"limit"`
+	Total int64               `json:"total"`
+	Users []AdminUserResponse `json:"users"`
 }
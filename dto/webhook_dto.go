@@ -0,0 +1,56 @@
+package dto
+
+import "time"
+
+type WebhookCreateRequest struct {
+	URL        string   `json:"url" validate:"required,url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,required"`
+}
+
+type WebhookUpdateRequest struct {
+	URL        *string  `json:"url" validate:"omitempty,url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"omitempty,min=1,dive,required"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+type WebhookResponse struct {
+	ID             uint       `json:"id"`
+	URL            string     `json:"url"`
+	EventTypes     []string   `json:"event_types"`
+	IsActive       bool       `json:"is_active"`
+	FailureCount   int        `json:"failure_count"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	// секрет показываем только при создании
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookAttemptResponse struct {
+	AttemptNo  int       `json:"attempt_no"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint                     `json:"id"`
+	EventID        string                   `json:"event_id"`
+	EventType      string                   `json:"event_type"`
+	Status         string                   `json:"status"`
+	Attempts       int                      `json:"attempts"`
+	LastStatusCode int                      `json:"last_status_code"`
+	NextAttemptAt  time.Time                `json:"next_attempt_at"`
+	DeliveredAt    *time.Time               `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+	AttemptsLog    []WebhookAttemptResponse `json:"attempts_log"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Ok         bool                      `json:"ok"`
+	Page       int                       `json:"page"`
+	Limit      int                       `json:"limit"`
+	Total      int64                     `json:"total"`
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+}
@@ -0,0 +1,209 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_blog/internal/events"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Format — как Envelope кладётся в kafka.Message
+type Format string
+
+const (
+	// FormatEnvelope — наш events.Envelope JSON в value
+	FormatEnvelope Format = "envelope"
+	// FormatStructured — CloudEvents JSON целиком в value
+	FormatStructured Format = "cloudevents-structured"
+	// FormatBinary — атрибуты CloudEvents в заголовках ce_*, в value только payload
+	FormatBinary Format = "cloudevents-binary"
+)
+
+const (
+	headerContentType = "content-type"
+	headerPrefix      = "ce_"
+	// дублируем correlation id отдельным заголовком во всех форматах,
+	// чтобы его видели и те, кто не разбирает value
+	headerRequestID = "x-request-id"
+)
+
+var ErrUnknownFormat = errors.New("unknown event format")
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "", FormatEnvelope:
+		return FormatEnvelope, nil
+	case FormatStructured, FormatBinary:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownFormat, s)
+	}
+}
+
+func Encode(e events.Envelope, f Format) (kafka.Message, error) {
+	msg := kafka.Message{
+		Key:  []byte(e.AggregateID),
+		Time: time.Now(),
+	}
+
+	switch f {
+	case "", FormatEnvelope:
+		value, err := json.Marshal(e)
+		if err != nil {
+			return kafka.Message{}, err
+		}
+		msg.Value = value
+
+	case FormatStructured:
+		value, err := json.Marshal(events.ToCloudEvent(e))
+		if err != nil {
+			return kafka.Message{}, err
+		}
+		msg.Value = value
+		msg.Headers = []kafka.Header{{Key: headerContentType, Value: []byte(events.CloudEventsJSON)}}
+
+	case FormatBinary:
+		msg.Value = e.Payload
+		msg.Headers = binaryHeaders(events.ToCloudEvent(e))
+
+	default:
+		return kafka.Message{}, fmt.Errorf("%w: %q", ErrUnknownFormat, f)
+	}
+
+	if e.CorrelationID != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: headerRequestID, Value: []byte(e.CorrelationID)})
+	}
+
+	return msg, nil
+}
+
+// Decode определяет формат сам: ce_specversion в заголовках → binary,
+// content-type cloudevents или поле specversion в JSON → structured, иначе Envelope
+func Decode(msg kafka.Message) (events.Envelope, error) {
+	env, err := decode(msg)
+	if err != nil {
+		return events.Envelope{}, err
+	}
+	if env.CorrelationID == "" {
+		env.CorrelationID, _ = header(msg, headerRequestID)
+	}
+	return env, nil
+}
+
+func decode(msg kafka.Message) (events.Envelope, error) {
+	if _, ok := header(msg, headerPrefix+"specversion"); ok {
+		return decodeBinary(msg)
+	}
+
+	if ct, ok := header(msg, headerContentType); ok && strings.HasPrefix(ct, events.CloudEventsJSON) {
+		return decodeStructured(msg.Value)
+	}
+
+	var probe struct {
+		SpecVersion string `json:"specversion"`
+	}
+	if err := json.Unmarshal(msg.Value, &probe); err != nil {
+		return events.Envelope{}, err
+	}
+	if probe.SpecVersion != "" {
+		return decodeStructured(msg.Value)
+	}
+
+	var env events.Envelope
+	if err := json.Unmarshal(msg.Value, &env); err != nil {
+		return events.Envelope{}, err
+	}
+	if env.EventID == "" || env.EventType == "" {
+		return events.Envelope{}, errors.New("envelope without event_id/event_type")
+	}
+	return env, nil
+}
+
+func decodeStructured(value []byte) (events.Envelope, error) {
+	var ce events.CloudEvent
+	if err := json.Unmarshal(value, &ce); err != nil {
+		return events.Envelope{}, err
+	}
+	return events.FromCloudEvent(ce)
+}
+
+func decodeBinary(msg kafka.Message) (events.Envelope, error) {
+	get := func(attr string) string {
+		v, _ := header(msg, headerPrefix+attr)
+		return v
+	}
+
+	ce := events.CloudEvent{
+		SpecVersion:   get("specversion"),
+		ID:            get("id"),
+		Source:        get("source"),
+		Type:          get("type"),
+		Subject:       get("subject"),
+		AggregateType: get("aggregatetype"),
+		AggregateID:   get("aggregateid"),
+		ActorUserID:   get("actoruserid"),
+		CorrelationID: get("correlationid"),
+		Data:          json.RawMessage(bytes.Clone(msg.Value)),
+	}
+	ce.DataContentType, _ = header(msg, headerContentType)
+
+	if t := get("time"); t != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return events.Envelope{}, fmt.Errorf("%w: bad time: %v", events.ErrInvalidCloudEvent, err)
+		}
+		ce.Time = parsed
+	}
+	if v := get("schemaversion"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return events.Envelope{}, fmt.Errorf("%w: bad schemaversion: %v", events.ErrInvalidCloudEvent, err)
+		}
+		ce.SchemaVersion = n
+	}
+
+	return events.FromCloudEvent(ce)
+}
+
+func binaryHeaders(ce events.CloudEvent) []kafka.Header {
+	headers := []kafka.Header{
+		{Key: headerContentType, Value: []byte(ce.DataContentType)},
+		{Key: headerPrefix + "specversion", Value: []byte(ce.SpecVersion)},
+		{Key: headerPrefix + "id", Value: []byte(ce.ID)},
+		{Key: headerPrefix + "source", Value: []byte(ce.Source)},
+		{Key: headerPrefix + "type", Value: []byte(ce.Type)},
+		{Key: headerPrefix + "time", Value: []byte(ce.Time.Format(time.RFC3339Nano))},
+	}
+
+	optional := []struct{ attr, value string }{
+		{"subject", ce.Subject},
+		{"aggregatetype", ce.AggregateType},
+		{"aggregateid", ce.AggregateID},
+		{"actoruserid", ce.ActorUserID},
+		{"correlationid", ce.CorrelationID},
+	}
+	if ce.SchemaVersion != 0 {
+		optional = append(optional, struct{ attr, value string }{"schemaversion", strconv.Itoa(ce.SchemaVersion)})
+	}
+	for _, o := range optional {
+		if o.value != "" {
+			headers = append(headers, kafka.Header{Key: headerPrefix + o.attr, Value: []byte(o.value)})
+		}
+	}
+	return headers
+}
+
+func header(msg kafka.Message, key string) (string, bool) {
+	for _, h := range msg.Headers {
+		if strings.EqualFold(h.Key, key) {
+			return string(h.Value), true
+		}
+	}
+	return "", false
+}
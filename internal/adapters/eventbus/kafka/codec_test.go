@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"encoding/json"
+	"go_blog/internal/events"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleEnvelope() events.Envelope {
+	return events.Envelope{
+		EventID:       "4b1c1a5e-0000-4000-8000-000000000001",
+		EventType:     events.PostCreated,
+		OccurredAt:    time.Date(2025, 1, 2, 3, 4, 5, 6000, time.UTC),
+		AggregateType: "post",
+		AggregateID:   "42",
+		ActorUserID:   "7",
+		CorrelationID: "req-1",
+		Version:       1,
+		Payload:       json.RawMessage(`{"post_id":"42","title":"Hi","slug":"hi"}`),
+	}
+}
+
+func TestCodec_RoundTrip_AllFormats(t *testing.T) {
+	for _, f := range []Format{FormatEnvelope, FormatStructured, FormatBinary} {
+		t.Run(string(f), func(t *testing.T) {
+			env := sampleEnvelope()
+
+			msg, err := Encode(env, f)
+			require.NoError(t, err)
+			require.Equal(t, []byte("42"), msg.Key)
+
+			got, err := Decode(msg)
+			require.NoError(t, err)
+			require.True(t, env.OccurredAt.Equal(got.OccurredAt))
+			got.OccurredAt = env.OccurredAt
+			require.JSONEq(t, string(env.Payload), string(got.Payload))
+			got.Payload = env.Payload
+			require.Equal(t, env, got)
+		})
+	}
+}
+
+func TestCodec_Binary_PayloadOnlyInValue(t *testing.T) {
+	msg, err := Encode(sampleEnvelope(), FormatBinary)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `{"post_id":"42","title":"Hi","slug":"hi"}`, string(msg.Value))
+
+	v, ok := header(msg, "ce_specversion")
+	require.True(t, ok)
+	require.Equal(t, "1.0", v)
+	v, _ = header(msg, "ce_type")
+	require.Equal(t, events.PostCreated, v)
+	v, _ = header(msg, "ce_subject")
+	require.Equal(t, "post/42", v)
+	v, _ = header(msg, "content-type")
+	require.Equal(t, "application/json", v)
+}
+
+func TestCodec_Structured_IsCloudEventsJSON(t *testing.T) {
+	msg, err := Encode(sampleEnvelope(), FormatStructured)
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(msg.Value, &raw))
+	require.Equal(t, "1.0", raw["specversion"])
+	require.Equal(t, "go_blog", raw["source"])
+	require.Equal(t, events.PostCreated, raw["type"])
+	require.Equal(t, "2025-01-02T03:04:05.000006Z", raw["time"])
+}
+
+func TestCodec_Decode_ForeignStructuredWithoutHeaders(t *testing.T) {
+	// другой сервис прислал CloudEvent без content-type заголовка
+	msg := kafka.Message{Value: []byte(`{
+		"specversion":"1.0","id":"x1","source":"other-service","type":"PostCreated",
+		"time":"2025-01-02T03:04:05Z","data":{"post_id":"1","title":"t","slug":"s"}
+	}`)}
+
+	got, err := Decode(msg)
+	require.NoError(t, err)
+	require.Equal(t, "x1", got.EventID)
+	require.Equal(t, 1, got.Version)
+	require.JSONEq(t, `{"post_id":"1","title":"t","slug":"s"}`, string(got.Payload))
+}
+
+func TestCodec_Decode_Invalid(t *testing.T) {
+	_, err := Decode(kafka.Message{Value: []byte("not json")})
+	require.Error(t, err)
+
+	_, err = Decode(kafka.Message{Value: []byte(`{"specversion":"0.3","id":"1","source":"s","type":"t"}`)})
+	require.ErrorIs(t, err, events.ErrInvalidCloudEvent)
+
+	_, err = Decode(kafka.Message{Headers: []kafka.Header{{Key: "ce_specversion", Value: []byte("1.0")}}})
+	require.ErrorIs(t, err, events.ErrInvalidCloudEvent)
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("")
+	require.NoError(t, err)
+	require.Equal(t, FormatEnvelope, f)
+
+	f, err = ParseFormat("CloudEvents-Binary")
+	require.NoError(t, err)
+	require.Equal(t, FormatBinary, f)
+
+	_, err = ParseFormat("avro")
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestCodec_CorrelationID_ForwardedAsHeader(t *testing.T) {
+	for _, f := range []Format{FormatEnvelope, FormatStructured, FormatBinary} {
+		msg, err := Encode(sampleEnvelope(), f)
+		require.NoError(t, err)
+
+		v, ok := header(msg, "X-Request-ID")
+		require.True(t, ok, f)
+		require.Equal(t, "req-1", v)
+	}
+}
+
+func TestCodec_Decode_CorrelationIDFromHeader(t *testing.T) {
+	env := sampleEnvelope()
+	env.CorrelationID = ""
+	value, err := json.Marshal(env)
+	require.NoError(t, err)
+
+	got, err := Decode(kafka.Message{
+		Value:   value,
+		Headers: []kafka.Header{{Key: "x-request-id", Value: []byte("from-header")}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "from-header", got.CorrelationID)
+}
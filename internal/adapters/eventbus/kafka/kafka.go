@@ -2,9 +2,7 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 	"go_blog/internal/events"
-	"time"
 
 	"github.com/segmentio/kafka-go"
 )
@@ -12,11 +10,21 @@ import (
 type EventBus struct {
 	writer *kafka.Writer
 	topic  string
+	format Format
 }
 
-func New(brokers []string, topic string) *EventBus {
-	return &EventBus{
-		topic: topic,
+type Option func(*EventBus)
+
+func WithFormat(f Format) Option {
+	return func(b *EventBus) {
+		b.format = f
+	}
+}
+
+func New(brokers []string, topic string, opts ...Option) *EventBus {
+	b := &EventBus{
+		topic:  topic,
+		format: FormatEnvelope,
 		writer: &kafka.Writer{
 			Addr:         kafka.TCP(brokers...),
 			Topic:        topic,
@@ -25,18 +33,20 @@ func New(brokers []string, topic string) *EventBus {
 			Async:        false,
 		},
 	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
 }
 
 func (b *EventBus) Publish(ctx context.Context, e events.Envelope) error {
-	value, err := json.Marshal(e)
-	if err != nil {
+	if err := events.Schemas.Validate(e); err != nil {
 		return err
 	}
 
-	msg := kafka.Message{
-		Key:   []byte(e.AggregateID),
-		Value: value,
-		Time:  time.Now(),
+	msg, err := Encode(e, b.format)
+	if err != nil {
+		return err
 	}
 
 	return b.writer.WriteMessages(ctx, msg)
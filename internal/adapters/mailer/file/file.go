@@ -0,0 +1,36 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"go_blog/internal/adapters/mailer/smtp"
+	"go_blog/internal/ports"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Mailer пишет каждое письмо в .eml файл — для локальной разработки
+type Mailer struct {
+	dir  string
+	from string
+}
+
+func New(dir, from string) (*Mailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Mailer{dir: dir, from: from}, nil
+}
+
+func (m *Mailer) Send(ctx context.Context, mail ports.Mail) error {
+	now := time.Now()
+	raw, err := smtp.Build(m.from, mail, now)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405"), uuid.NewString()[:8])
+	return os.WriteFile(filepath.Join(m.dir, name), raw, 0o644)
+}
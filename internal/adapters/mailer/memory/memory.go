@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"go_blog/internal/ports"
+	"sync"
+)
+
+// Mailer складывает письма в память — для тестов
+type Mailer struct {
+	mu   sync.Mutex
+	Sent []ports.Mail
+	// Err, если задан, возвращается из Send (проверка ретраев)
+	Err error
+}
+
+func New() *Mailer {
+	return &Mailer{}
+}
+
+func (m *Mailer) Send(ctx context.Context, mail ports.Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	m.Sent = append(m.Sent, mail)
+	return nil
+}
+
+func (m *Mailer) Messages() []ports.Mail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ports.Mail(nil), m.Sent...)
+}
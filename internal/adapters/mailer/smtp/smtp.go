@@ -0,0 +1,58 @@
+package smtp
+
+import (
+	"context"
+	"go_blog/internal/ports"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type Mailer struct {
+	cfg Config
+}
+
+func New(cfg Config) *Mailer {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &Mailer{cfg: cfg}
+}
+
+// Send: net/smtp сам делает STARTTLS, если сервер его предлагает; PLAIN auth —
+// только поверх TLS или на localhost
+func (m *Mailer) Send(ctx context.Context, mail ports.Mail) error {
+	raw, err := Build(m.cfg.From, mail, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+
+	// net/smtp не принимает context — ограничиваем отправку в отдельной горутине
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, m.cfg.From, []string{mail.To}, raw)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
@@ -0,0 +1,35 @@
+package clientinfo
+
+import (
+	"context"
+	"unicode/utf8"
+)
+
+const maxUserAgent = 255
+
+// Info — откуда пришёл запрос; сохраняется в метаданных сессии
+type Info struct {
+	UserAgent string
+	IP        string
+}
+
+type ctxKey struct{}
+
+func New(userAgent, ip string) Info {
+	if len(userAgent) > maxUserAgent {
+		userAgent = userAgent[:maxUserAgent]
+		for !utf8.ValidString(userAgent) {
+			userAgent = userAgent[:len(userAgent)-1]
+		}
+	}
+	return Info{UserAgent: userAgent, IP: ip}
+}
+
+func WithContext(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(ctxKey{}).(Info)
+	return info
+}
@@ -0,0 +1,272 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	kafkabus "go_blog/internal/adapters/eventbus/kafka"
+	"go_blog/internal/events"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Reader/Writer — то, что нам нужно от kafka-go (удобно подменять в тестах)
+type Reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type Decoder func(msg kafka.Message) (events.Envelope, error)
+
+type Config struct {
+	Name           string
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// размер очереди на одну партицию
+	PartitionBuffer int
+	Decode          Decoder
+}
+
+type Consumer struct {
+	cfg      Config
+	reader   Reader
+	dlq      Writer
+	registry *Registry
+}
+
+// permanentError — ретраить бесполезно, сразу в DLQ
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var pe permanentError
+	return errors.As(err, &pe)
+}
+
+func New(cfg Config, reader Reader, dlq Writer, registry *Registry) *Consumer {
+	if cfg.Name == "" {
+		cfg.Name = "consumer"
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 200 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 10 * time.Second
+	}
+	if cfg.PartitionBuffer <= 0 {
+		cfg.PartitionBuffer = 64
+	}
+	if cfg.Decode == nil {
+		cfg.Decode = Decode
+	}
+	return &Consumer{cfg: cfg, reader: reader, dlq: dlq, registry: registry}
+}
+
+// Decode принимает и Envelope, и CloudEvents (structured/binary)
+// и доводит payload до последней версии схемы
+func Decode(msg kafka.Message) (events.Envelope, error) {
+	env, err := kafkabus.Decode(msg)
+	if err != nil {
+		return events.Envelope{}, err
+	}
+	return events.Schemas.Upcast(env)
+}
+
+// Run читает сообщения, пока ctx не отменён.
+// Каждая партиция обрабатывается своим воркером последовательно,
+// коммит offset'а — только после успешной обработки (или отправки в DLQ).
+func (c *Consumer) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	workers := make(map[int]chan kafka.Message)
+
+	defer func() {
+		for _, ch := range workers {
+			close(ch)
+		}
+		wg.Wait()
+	}()
+
+	fetchBackoff := c.cfg.InitialBackoff
+
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Printf("[%s] fetch error: %v", c.cfg.Name, err)
+			if !sleep(ctx, fetchBackoff) {
+				return nil
+			}
+			fetchBackoff = c.nextBackoff(fetchBackoff)
+			continue
+		}
+		fetchBackoff = c.cfg.InitialBackoff
+
+		ch, ok := workers[msg.Partition]
+		if !ok {
+			ch = make(chan kafka.Message, c.cfg.PartitionBuffer)
+			workers[msg.Partition] = ch
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.partitionWorker(ctx, ch)
+			}()
+		}
+
+		select {
+		case ch <- msg:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (c *Consumer) partitionWorker(ctx context.Context, ch <-chan kafka.Message) {
+	for msg := range ch {
+		if ctx.Err() != nil {
+			// shutdown: остаток не коммитим, его перечитают после рестарта
+			continue
+		}
+		c.process(ctx, msg)
+	}
+}
+
+// process выходит без коммита только при shutdown — после этого воркер
+// пропускает остаток очереди, так что коммит не перепрыгнет сообщение
+func (c *Consumer) process(ctx context.Context, msg kafka.Message) {
+	// начатую обработку доводим до конца даже при shutdown
+	workCtx := context.WithoutCancel(ctx)
+
+	env, err := c.cfg.Decode(msg)
+	if err != nil {
+		log.Printf("[%s] invalid message partition=%d offset=%d: %v", c.cfg.Name, msg.Partition, msg.Offset, err)
+		if c.deadLetter(ctx, msg, err) {
+			c.commit(workCtx, msg)
+		}
+		return
+	}
+
+	pending := c.registry.Handlers(env.EventType)
+	backoff := c.cfg.InitialBackoff
+
+	for attempt := 1; len(pending) > 0; attempt++ {
+		var failed []Handler
+		var lastErr error
+		for _, h := range pending {
+			if err := h.Handle(workCtx, env); err != nil {
+				failed = append(failed, h)
+				lastErr = err
+			}
+		}
+		pending = failed
+		if len(pending) == 0 {
+			break
+		}
+
+		log.Printf("[%s] handle %s (%s) attempt %d/%d: %v", c.cfg.Name, env.EventID, env.EventType, attempt, c.cfg.MaxAttempts, lastErr)
+
+		if IsPermanent(lastErr) || attempt >= c.cfg.MaxAttempts {
+			if !c.deadLetter(ctx, msg, lastErr) {
+				return
+			}
+			break
+		}
+
+		if !sleep(ctx, backoff) {
+			return
+		}
+		backoff = c.nextBackoff(backoff)
+	}
+
+	c.commit(workCtx, msg)
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, msg kafka.Message, cause error) bool {
+	if c.dlq == nil {
+		log.Printf("[%s] no DLQ configured, dropping partition=%d offset=%d", c.cfg.Name, msg.Partition, msg.Offset)
+		return true
+	}
+
+	headers := make([]kafka.Header, 0, len(msg.Headers)+5)
+	headers = append(headers, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: "x-dlq-consumer", Value: []byte(c.cfg.Name)},
+		kafka.Header{Key: "x-dlq-error", Value: []byte(cause.Error())},
+		kafka.Header{Key: "x-dlq-original-topic", Value: []byte(msg.Topic)},
+		kafka.Header{Key: "x-dlq-original-partition", Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: "x-dlq-original-offset", Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+
+	dead := kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+		Time:    time.Now(),
+	}
+
+	// DLQ недоступен — ждём, терять сообщение нельзя
+	backoff := c.cfg.InitialBackoff
+	for {
+		err := c.dlq.WriteMessages(context.WithoutCancel(ctx), dead)
+		if err == nil {
+			log.Printf("[%s] sent to DLQ partition=%d offset=%d: %v", c.cfg.Name, msg.Partition, msg.Offset, cause)
+			return true
+		}
+		log.Printf("[%s] DLQ write error: %v", c.cfg.Name, err)
+		if !sleep(ctx, backoff) {
+			return false
+		}
+		backoff = c.nextBackoff(backoff)
+	}
+}
+
+// ошибка коммита не страшна: следующий коммит по партиции его перекроет,
+// в худшем случае сообщение придёт повторно
+func (c *Consumer) commit(ctx context.Context, msg kafka.Message) {
+	if err := c.reader.CommitMessages(ctx, msg); err != nil {
+		log.Printf("[%s] failed to commit partition=%d offset=%d: %v", c.cfg.Name, msg.Partition, msg.Offset, err)
+	}
+}
+
+func (c *Consumer) nextBackoff(d time.Duration) time.Duration {
+	d *= 2
+	if d > c.cfg.MaxBackoff {
+		return c.cfg.MaxBackoff
+	}
+	return d
+}
+
+// sleep возвращает false, если ctx отменили раньше
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
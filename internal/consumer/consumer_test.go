@@ -0,0 +1,339 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	kafkabus "go_blog/internal/adapters/eventbus/kafka"
+	"go_blog/internal/events"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+// ---- fake kafka ----
+
+type fakeReader struct {
+	mu        sync.Mutex
+	msgs      []kafka.Message
+	committed []kafka.Message
+	fetchErr  error
+	fetches   int
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	r.fetches++
+	if r.fetchErr != nil {
+		err := r.fetchErr
+		r.mu.Unlock()
+		return kafka.Message{}, err
+	}
+	if len(r.msgs) > 0 {
+		m := r.msgs[0]
+		r.msgs = r.msgs[1:]
+		r.mu.Unlock()
+		return m, nil
+	}
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeReader) committedOffsets() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]int64, 0, len(r.committed))
+	for _, m := range r.committed {
+		out = append(out, m.Offset)
+	}
+	return out
+}
+
+type fakeWriter struct {
+	mu   sync.Mutex
+	msgs []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) written() []kafka.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]kafka.Message(nil), w.msgs...)
+}
+
+func envelopeMsg(t *testing.T, partition int, offset int64, eventType string) kafka.Message {
+	t.Helper()
+	b, err := json.Marshal(events.Envelope{
+		EventID:   "evt-" + eventType,
+		EventType: eventType,
+		Version:   1,
+		Payload:   json.RawMessage(`{}`),
+	})
+	require.NoError(t, err)
+	return kafka.Message{Topic: "blog.events", Partition: partition, Offset: offset, Value: b}
+}
+
+func testConfig() Config {
+	return Config{
+		Name:           "test",
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	}
+}
+
+// runUntil крутит consumer, пока не выполнится cond
+func runUntil(t *testing.T, c *Consumer, cond func() bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	require.Eventually(t, cond, 2*time.Second, 5*time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("consumer did not stop after cancel")
+	}
+}
+
+// ---- tests ----
+
+func TestConsumer_DispatchesByTypeAndCommits(t *testing.T) {
+	reader := &fakeReader{msgs: []kafka.Message{
+		envelopeMsg(t, 0, 1, "PostCreated"),
+		envelopeMsg(t, 0, 2, "PostDeleted"),
+	}}
+
+	var mu sync.Mutex
+	var created, all []string
+
+	registry := NewRegistry()
+	registry.Register("PostCreated", HandlerFunc(func(ctx context.Context, e events.Envelope) error {
+		mu.Lock()
+		defer mu.Unlock()
+		created = append(created, e.EventType)
+		return nil
+	}))
+	registry.Register(AnyEvent, HandlerFunc(func(ctx context.Context, e events.Envelope) error {
+		mu.Lock()
+		defer mu.Unlock()
+		all = append(all, e.EventType)
+		return nil
+	}))
+
+	c := New(testConfig(), reader, &fakeWriter{}, registry)
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 2 })
+
+	require.Equal(t, []int64{1, 2}, reader.committedOffsets())
+	require.Equal(t, []string{"PostCreated"}, created)
+	require.Equal(t, []string{"PostCreated", "PostDeleted"}, all)
+}
+
+func TestConsumer_RetriesOnlyFailedHandler(t *testing.T) {
+	reader := &fakeReader{msgs: []kafka.Message{envelopeMsg(t, 0, 1, "PostCreated")}}
+
+	var mu sync.Mutex
+	okCalls, flakyCalls := 0, 0
+
+	registry := NewRegistry()
+	registry.Register("PostCreated", HandlerFunc(func(ctx context.Context, e events.Envelope) error {
+		mu.Lock()
+		defer mu.Unlock()
+		okCalls++
+		return nil
+	}))
+	registry.Register("PostCreated", HandlerFunc(func(ctx context.Context, e events.Envelope) error {
+		mu.Lock()
+		defer mu.Unlock()
+		flakyCalls++
+		if flakyCalls < 3 {
+			return errors.New("db down")
+		}
+		return nil
+	}))
+
+	dlq := &fakeWriter{}
+	c := New(testConfig(), reader, dlq, registry)
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 1 })
+
+	require.Equal(t, 1, okCalls)
+	require.Equal(t, 3, flakyCalls)
+	require.Empty(t, dlq.written())
+}
+
+func TestConsumer_ExhaustedRetries_GoToDLQ(t *testing.T) {
+	reader := &fakeReader{msgs: []kafka.Message{envelopeMsg(t, 2, 7, "PostCreated")}}
+
+	calls := 0
+	registry := NewRegistry()
+	registry.Register("PostCreated", HandlerFunc(func(ctx context.Context, e events.Envelope) error {
+		calls++
+		return errors.New("always fails")
+	}))
+
+	dlq := &fakeWriter{}
+	c := New(testConfig(), reader, dlq, registry)
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 1 })
+
+	require.Equal(t, 3, calls)
+	dead := dlq.written()
+	require.Len(t, dead, 1)
+	require.Equal(t, "7", headerValue(dead[0], "x-dlq-original-offset"))
+	require.Equal(t, "2", headerValue(dead[0], "x-dlq-original-partition"))
+	require.Equal(t, "always fails", headerValue(dead[0], "x-dlq-error"))
+}
+
+func TestConsumer_PermanentError_NoRetry(t *testing.T) {
+	reader := &fakeReader{msgs: []kafka.Message{envelopeMsg(t, 0, 1, "PostCreated")}}
+
+	calls := 0
+	registry := NewRegistry()
+	registry.Register("PostCreated", HandlerFunc(func(ctx context.Context, e events.Envelope) error {
+		calls++
+		return Permanent(errors.New("bad payload"))
+	}))
+
+	dlq := &fakeWriter{}
+	c := New(testConfig(), reader, dlq, registry)
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 1 })
+
+	require.Equal(t, 1, calls)
+	require.Len(t, dlq.written(), 1)
+}
+
+func TestConsumer_PoisonMessage_GoesToDLQ(t *testing.T) {
+	reader := &fakeReader{msgs: []kafka.Message{
+		{Partition: 0, Offset: 1, Value: []byte("not json")},
+		envelopeMsg(t, 0, 2, "PostCreated"),
+	}}
+
+	registry := NewRegistry()
+	handled := make(chan struct{}, 1)
+	registry.Register("PostCreated", HandlerFunc(func(ctx context.Context, e events.Envelope) error {
+		handled <- struct{}{}
+		return nil
+	}))
+
+	dlq := &fakeWriter{}
+	c := New(testConfig(), reader, dlq, registry)
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 2 })
+
+	require.Len(t, dlq.written(), 1)
+	require.Equal(t, []byte("not json"), dlq.written()[0].Value)
+	require.Len(t, handled, 1)
+}
+
+func TestConsumer_AcceptsCloudEventsBinary(t *testing.T) {
+	msg, err := kafkabus.Encode(events.Envelope{
+		EventID:   "ce-1",
+		EventType: "PostCreated",
+		Version:   1,
+		Payload:   json.RawMessage(`{"post_id":"1","title":"t","slug":"s"}`),
+	}, kafkabus.FormatBinary)
+	require.NoError(t, err)
+	msg.Partition, msg.Offset = 0, 1
+
+	reader := &fakeReader{msgs: []kafka.Message{msg}}
+
+	got := make(chan events.Envelope, 1)
+	registry := NewRegistry()
+	registry.Register("PostCreated", HandlerFunc(func(ctx context.Context, e events.Envelope) error {
+		got <- e
+		return nil
+	}))
+
+	c := New(testConfig(), reader, &fakeWriter{}, registry)
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 1 })
+
+	env := <-got
+	require.Equal(t, "ce-1", env.EventID)
+	require.JSONEq(t, `{"post_id":"1","title":"t","slug":"s"}`, string(env.Payload))
+}
+
+func TestConsumer_UnknownType_IsCommitted(t *testing.T) {
+	reader := &fakeReader{msgs: []kafka.Message{envelopeMsg(t, 0, 1, "Unknown")}}
+
+	c := New(testConfig(), reader, &fakeWriter{}, NewRegistry())
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 1 })
+}
+
+func TestConsumer_PartitionsProcessedConcurrently(t *testing.T) {
+	reader := &fakeReader{msgs: []kafka.Message{
+		envelopeMsg(t, 0, 1, "Slow"),
+		envelopeMsg(t, 1, 1, "Fast"),
+	}}
+
+	release := make(chan struct{})
+	registry := NewRegistry()
+	registry.Register("Slow", HandlerFunc(func(ctx context.Context, e events.Envelope) error {
+		<-release
+		return nil
+	}))
+	registry.Register("Fast", HandlerFunc(func(ctx context.Context, e events.Envelope) error {
+		return nil
+	}))
+
+	c := New(testConfig(), reader, &fakeWriter{}, registry)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	// партиция 1 не ждёт медленную партицию 0
+	require.Eventually(t, func() bool { return len(reader.committedOffsets()) == 1 }, time.Second, 5*time.Millisecond)
+
+	close(release)
+	require.Eventually(t, func() bool { return len(reader.committedOffsets()) == 2 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestConsumer_StopsOnCancel_WithoutHotLoop(t *testing.T) {
+	reader := &fakeReader{fetchErr: errors.New("broker unavailable")}
+
+	cfg := testConfig()
+	cfg.InitialBackoff = 20 * time.Millisecond
+	cfg.MaxBackoff = 20 * time.Millisecond
+	c := New(cfg, reader, &fakeWriter{}, NewRegistry())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, c.Run(ctx))
+
+	reader.mu.Lock()
+	defer reader.mu.Unlock()
+	require.Less(t, reader.fetches, 10)
+}
+
+func headerValue(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// OffsetClient — то, что нужно от kafka.Client для переноса позиций группы
+type OffsetClient interface {
+	OffsetFetch(ctx context.Context, req *kafka.OffsetFetchRequest) (*kafka.OffsetFetchResponse, error)
+	OffsetCommit(ctx context.Context, req *kafka.OffsetCommitRequest) (*kafka.OffsetCommitResponse, error)
+}
+
+// SeedGroupOffsets — первый запуск под новым именем группы: если у to нет ни одного коммита по topic,
+// ей коммитятся позиции from. Иначе новая группа начала бы с начала топика, и неидемпотентные
+// обработчики (realtime, вебхуки) повторили бы всю историю. Вызывать до старта Reader:
+// коммит вне поколения (generation -1) брокер принимает только у пустой группы.
+func SeedGroupOffsets(ctx context.Context, client OffsetClient, topic, from, to string) error {
+	current, err := fetchGroupOffsets(ctx, client, to, topic)
+	if err != nil {
+		return err
+	}
+	previous, err := fetchGroupOffsets(ctx, client, from, topic)
+	if err != nil {
+		return err
+	}
+
+	commits := seedCommits(current, previous)
+	if len(commits) == 0 {
+		return nil
+	}
+
+	resp, err := client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      to,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return fmt.Errorf("commit offsets of %s to %s: %w", from, to, err)
+	}
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return fmt.Errorf("commit offsets of %s to %s, partition %d: %w", from, to, p.Partition, p.Error)
+		}
+	}
+	log.Printf("consumer group %s: seeded %d partitions of %s from %s", to, len(commits), topic, from)
+	return nil
+}
+
+func fetchGroupOffsets(ctx context.Context, client OffsetClient, group, topic string) ([]kafka.OffsetFetchPartition, error) {
+	resp, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: group})
+	if err != nil {
+		return nil, fmt.Errorf("fetch offsets of %s: %w", group, err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("fetch offsets of %s: %w", group, resp.Error)
+	}
+	return resp.Topics[topic], nil
+}
+
+// seedCommits: у новой группы уже есть хоть одна позиция — ничего не переносим
+func seedCommits(current, previous []kafka.OffsetFetchPartition) []kafka.OffsetCommit {
+	for _, p := range current {
+		if p.Error == nil && p.CommittedOffset >= 0 {
+			return nil
+		}
+	}
+	var out []kafka.OffsetCommit
+	for _, p := range previous {
+		if p.Error == nil && p.CommittedOffset >= 0 {
+			out = append(out, kafka.OffsetCommit{Partition: p.Partition, Offset: p.CommittedOffset})
+		}
+	}
+	return out
+}
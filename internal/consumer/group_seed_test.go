@@ -0,0 +1,61 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOffsetClient struct {
+	groups    map[string][]kafka.OffsetFetchPartition
+	committed []*kafka.OffsetCommitRequest
+}
+
+func (f *fakeOffsetClient) OffsetFetch(ctx context.Context, req *kafka.OffsetFetchRequest) (*kafka.OffsetFetchResponse, error) {
+	return &kafka.OffsetFetchResponse{Topics: map[string][]kafka.OffsetFetchPartition{"blog.events": f.groups[req.GroupID]}}, nil
+}
+
+func (f *fakeOffsetClient) OffsetCommit(ctx context.Context, req *kafka.OffsetCommitRequest) (*kafka.OffsetCommitResponse, error) {
+	f.committed = append(f.committed, req)
+	return &kafka.OffsetCommitResponse{}, nil
+}
+
+func TestSeedGroupOffsets(t *testing.T) {
+	old := []kafka.OffsetFetchPartition{{Partition: 0, CommittedOffset: 42}, {Partition: 1, CommittedOffset: -1}, {Partition: 2, CommittedOffset: 7}}
+
+	for _, tc := range []struct {
+		name   string
+		groups map[string][]kafka.OffsetFetchPartition
+		want   []kafka.OffsetCommit
+	}{
+		{
+			name:   "first start takes positions of the old group",
+			groups: map[string][]kafka.OffsetFetchPartition{"old": old, "new": {{Partition: 0, CommittedOffset: -1}}},
+			want:   []kafka.OffsetCommit{{Partition: 0, Offset: 42}, {Partition: 2, Offset: 7}},
+		},
+		{
+			name:   "new group already has positions",
+			groups: map[string][]kafka.OffsetFetchPartition{"old": old, "new": {{Partition: 1, CommittedOffset: 3}}},
+		},
+		{
+			name:   "no old group",
+			groups: map[string][]kafka.OffsetFetchPartition{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &fakeOffsetClient{groups: tc.groups}
+			require.NoError(t, SeedGroupOffsets(context.Background(), client, "blog.events", "old", "new"))
+
+			if tc.want == nil {
+				require.Empty(t, client.committed)
+				return
+			}
+			require.Len(t, client.committed, 1)
+			require.Equal(t, "new", client.committed[0].GroupID)
+			require.Equal(t, -1, client.committed[0].GenerationID)
+			require.Equal(t, tc.want, client.committed[0].Topics["blog.events"])
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package consumer
+
+import (
+	"context"
+	"go_blog/internal/events"
+
+	"gorm.io/gorm"
+)
+
+type ProcessedStore interface {
+	MarkProcessedTx(ctx context.Context, tx *gorm.DB, consumerName, eventID string) (bool, error)
+}
+
+// TxHandlerFunc — обработчик, который делает побочный эффект через tx
+type TxHandlerFunc func(ctx context.Context, tx *gorm.DB, e events.Envelope) error
+
+// Idempotent оборачивает обработчик: отметка (consumerName, EventID) и побочный эффект
+// коммитятся одной транзакцией, повторная доставка того же события пропускается.
+// Replay тоже пропускает уже применённое; перестройка проекции с нуля сначала снимает
+// отметки (handlers.Resets).
+func Idempotent(db *gorm.DB, store ProcessedStore, consumerName string, fn TxHandlerFunc) Handler {
+	return HandlerFunc(func(ctx context.Context, e events.Envelope) error {
+		return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			first, err := store.MarkProcessedTx(ctx, tx, consumerName, e.EventID)
+			if err != nil {
+				return err
+			}
+			if !first {
+				return nil
+			}
+			return fn(ctx, tx, e)
+		})
+	})
+}
@@ -0,0 +1,120 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func seedPost(t *testing.T, tx *gorm.DB) *models.Post {
+	t.Helper()
+	user := &models.User{Nickname: "u", Email: "idem@test.com", Password: "123", IsActive: true}
+	require.NoError(t, tx.Create(user).Error)
+	post := &models.Post{Title: "Post", Slug: "idem-post", UserID: user.ID, IsActive: true}
+	require.NoError(t, tx.Create(post).Error)
+	return post
+}
+
+func commentOnEvent(postID uint) TxHandlerFunc {
+	return func(ctx context.Context, tx *gorm.DB, e events.Envelope) error {
+		return tx.WithContext(ctx).Create(&models.Comment{PostID: postID, UserID: 1, Text: e.EventID}).Error
+	}
+}
+
+func TestIdempotent_SameKafkaMessageTwice_AppliedOnce(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	post := seedPost(t, tx)
+
+	msg := envelopeMsg(t, 0, 1, "PostCreated")
+	redelivered := msg
+	redelivered.Offset = 2
+
+	reader := &fakeReader{msgs: []kafka.Message{msg, redelivered}}
+
+	registry := NewRegistry()
+	registry.Register("PostCreated", Idempotent(tx, repositories.NewProcessedEventRepository(tx), "comments-projection", commentOnEvent(post.ID)))
+
+	c := New(testConfig(), reader, &fakeWriter{}, registry)
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 2 })
+
+	var count int64
+	require.NoError(t, tx.Model(&models.Comment{}).Where("post_id = ?", post.ID).Count(&count).Error)
+	require.Equal(t, int64(1), count)
+}
+
+func TestIdempotent_DifferentConsumers_BothApply(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	post := seedPost(t, tx)
+	store := repositories.NewProcessedEventRepository(tx)
+
+	env := events.Envelope{EventID: "evt-1", EventType: "PostCreated"}
+
+	require.NoError(t, Idempotent(tx, store, "a", commentOnEvent(post.ID)).Handle(context.Background(), env))
+	require.NoError(t, Idempotent(tx, store, "b", commentOnEvent(post.ID)).Handle(context.Background(), env))
+	require.NoError(t, Idempotent(tx, store, "a", commentOnEvent(post.ID)).Handle(context.Background(), env))
+
+	var count int64
+	require.NoError(t, tx.Model(&models.Comment{}).Where("post_id = ?", post.ID).Count(&count).Error)
+	require.Equal(t, int64(2), count)
+}
+
+func TestIdempotent_FailedHandler_RollsBackMarker(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	post := seedPost(t, tx)
+	store := repositories.NewProcessedEventRepository(tx)
+
+	env := events.Envelope{EventID: "evt-2", EventType: "PostCreated"}
+
+	failing := Idempotent(tx, store, "projection", func(ctx context.Context, tx *gorm.DB, e events.Envelope) error {
+		if err := commentOnEvent(post.ID)(ctx, tx, e); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	})
+	require.Error(t, failing.Handle(context.Background(), env))
+
+	processed, err := store.IsProcessed(context.Background(), "projection", env.EventID)
+	require.NoError(t, err)
+	require.False(t, processed)
+
+	// повторная доставка после ошибки применяется
+	require.NoError(t, Idempotent(tx, store, "projection", commentOnEvent(post.ID)).Handle(context.Background(), env))
+
+	var count int64
+	require.NoError(t, tx.Model(&models.Comment{}).Where("post_id = ?", post.ID).Count(&count).Error)
+	require.Equal(t, int64(1), count)
+}
+
+func TestIdempotent_ClearedMarks_ApplyAgain(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	post := seedPost(t, tx)
+	store := repositories.NewProcessedEventRepository(tx)
+	ctx := context.Background()
+
+	env := events.Envelope{EventID: "evt-3", EventType: "PostCreated"}
+	h := Idempotent(tx, store, "projection", commentOnEvent(post.ID))
+
+	// replay без перестройки уже применённое пропускает
+	require.NoError(t, h.Handle(ctx, env))
+	require.NoError(t, h.Handle(ctx, env))
+
+	// перестройка снимает отметки — событие применяется заново
+	require.NoError(t, store.DeleteByConsumerTx(ctx, tx, "projection"))
+	require.NoError(t, h.Handle(ctx, env))
+
+	var count int64
+	require.NoError(t, tx.Model(&models.Comment{}).Where("post_id = ?", post.ID).Count(&count).Error)
+	require.Equal(t, int64(2), count)
+}
@@ -0,0 +1,48 @@
+package consumer
+
+import (
+	"context"
+	"go_blog/internal/events"
+	"sync"
+)
+
+// AnyEvent — подписка на все типы событий (например, audit log)
+const AnyEvent = "*"
+
+type Handler interface {
+	Handle(ctx context.Context, e events.Envelope) error
+}
+
+type HandlerFunc func(ctx context.Context, e events.Envelope) error
+
+func (f HandlerFunc) Handle(ctx context.Context, e events.Envelope) error {
+	return f(ctx, e)
+}
+
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string][]Handler)}
+}
+
+func (r *Registry) Register(eventType string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[eventType] = append(r.handlers[eventType], h)
+}
+
+// Handlers возвращает обработчики конкретного типа + подписанные на AnyEvent
+func (r *Registry) Handlers(eventType string) []Handler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Handler, 0, len(r.handlers[eventType])+len(r.handlers[AnyEvent]))
+	out = append(out, r.handlers[eventType]...)
+	if eventType != AnyEvent {
+		out = append(out, r.handlers[AnyEvent]...)
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	CloudEventsSpecVersion = "1.0"
+	CloudEventsSource      = "go_blog"
+	CloudEventsJSON        = "application/cloudevents+json"
+	PayloadContentType     = "application/json"
+)
+
+var ErrInvalidCloudEvent = errors.New("invalid cloudevent")
+
+// CloudEvent — CloudEvents 1.0 в structured JSON виде.
+// Поля Envelope, которых нет в спецификации, идут extension-атрибутами.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+
+	// extensions (по спеке — только [a-z0-9])
+	AggregateType string `json:"aggregatetype,omitempty"`
+	AggregateID   string `json:"aggregateid,omitempty"`
+	ActorUserID   string `json:"actoruserid,omitempty"`
+	CorrelationID string `json:"correlationid,omitempty"`
+	SchemaVersion int    `json:"schemaversion,omitempty"`
+}
+
+func ToCloudEvent(e Envelope) CloudEvent {
+	ce := CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              e.EventID,
+		Source:          CloudEventsSource,
+		Type:            e.EventType,
+		Time:            e.OccurredAt,
+		DataContentType: PayloadContentType,
+		Data:            e.Payload,
+		AggregateType:   e.AggregateType,
+		AggregateID:     e.AggregateID,
+		ActorUserID:     e.ActorUserID,
+		CorrelationID:   e.CorrelationID,
+		SchemaVersion:   e.Version,
+	}
+	if e.AggregateType != "" && e.AggregateID != "" {
+		ce.Subject = e.AggregateType + "/" + e.AggregateID
+	}
+	return ce
+}
+
+func FromCloudEvent(ce CloudEvent) (Envelope, error) {
+	if ce.SpecVersion != CloudEventsSpecVersion {
+		return Envelope{}, fmt.Errorf("%w: unsupported specversion %q", ErrInvalidCloudEvent, ce.SpecVersion)
+	}
+	if ce.ID == "" || ce.Source == "" || ce.Type == "" {
+		return Envelope{}, fmt.Errorf("%w: id, source and type are required", ErrInvalidCloudEvent)
+	}
+
+	version := ce.SchemaVersion
+	if version == 0 {
+		version = 1
+	}
+
+	return Envelope{
+		EventID:       ce.ID,
+		EventType:     ce.Type,
+		OccurredAt:    ce.Time,
+		AggregateType: ce.AggregateType,
+		AggregateID:   ce.AggregateID,
+		ActorUserID:   ce.ActorUserID,
+		CorrelationID: ce.CorrelationID,
+		Version:       version,
+		Payload:       ce.Data,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package events
+
+import "encoding/json"
+
+const (
+	CommentCreated = "CommentCreated"
+)
+
+type CommentCreatedPayloadV1 struct {
+	CommentID    string `json:"comment_id" validate:"required"`
+	PostID       string `json:"post_id" validate:"required"`
+	PostSlug     string `json:"post_slug" validate:"required"`
+	PostAuthorID string `json:"post_author_id" validate:"required"`
+	Text         string `json:"text"`
+}
+
+// CommentCreatedPayload — v2: ответы на комментарии
+type CommentCreatedPayload struct {
+	CommentID      string `json:"comment_id" validate:"required"`
+	PostID         string `json:"post_id" validate:"required"`
+	PostSlug       string `json:"post_slug" validate:"required"`
+	PostAuthorID   string `json:"post_author_id" validate:"required"`
+	Text           string `json:"text"`
+	ParentID       string `json:"parent_id,omitempty"`
+	ParentAuthorID string `json:"parent_author_id,omitempty" validate:"required_with=ParentID"`
+}
+
+func init() {
+	Schemas.Register(CommentCreated, 1, CommentCreatedPayloadV1{})
+	Schemas.Register(CommentCreated, 2, CommentCreatedPayload{})
+
+	Schemas.RegisterUpcaster(CommentCreated, 1, func(p json.RawMessage) (json.RawMessage, error) {
+		var v1 CommentCreatedPayloadV1
+		if err := json.Unmarshal(p, &v1); err != nil {
+			return nil, err
+		}
+		// v1 — только комментарии верхнего уровня
+		return json.Marshal(CommentCreatedPayload{
+			CommentID:    v1.CommentID,
+			PostID:       v1.PostID,
+			PostSlug:     v1.PostSlug,
+			PostAuthorID: v1.PostAuthorID,
+			Text:         v1.Text,
+		})
+	})
+}
@@ -0,0 +1,20 @@
+package events
+
+const (
+	PostLiked   = "PostLiked"
+	PostUnliked = "PostUnliked"
+)
+
+// PostLikePayload — общий для лайка и снятия лайка; LikesCount — счётчик после изменения
+type PostLikePayload struct {
+	PostID       string `json:"post_id" validate:"required"`
+	PostSlug     string `json:"post_slug" validate:"required"`
+	PostAuthorID string `json:"post_author_id" validate:"required"`
+	UserID       string `json:"user_id" validate:"required"`
+	LikesCount   int64  `json:"likes_count" validate:"gte=0"`
+}
+
+func init() {
+	Schemas.Register(PostLiked, 1, PostLikePayload{})
+	Schemas.Register(PostUnliked, 1, PostLikePayload{})
+}
@@ -1,17 +1,29 @@
 package events
 
+const (
+	PostCreated = "PostCreated"
+	PostUpdated = "PostUpdated"
+	PostDeleted = "PostDeleted"
+)
+
 type PostCreatedPayload struct {
-	PostID string `json:"post_id"`
-	Title  string `json:"title"`
-	Slug   string `json:"slug"`
+	PostID string `json:"post_id" validate:"required"`
+	Title  string `json:"title" validate:"required"`
+	Slug   string `json:"slug" validate:"required"`
 }
 
 type PostUpdatedPayload struct {
-	PostID string `json:"post_id"`
-	Title  string `json:"title"`
-	Slug   string `json:"slug"`
+	PostID string `json:"post_id" validate:"required"`
+	Title  string `json:"title" validate:"required"`
+	Slug   string `json:"slug" validate:"required"`
 }
 
 type PostDeletedPayload struct {
-	PostID string `json:"post_id"`
+	PostID string `json:"post_id" validate:"required"`
+}
+
+func init() {
+	Schemas.Register(PostCreated, 1, PostCreatedPayload{})
+	Schemas.Register(PostUpdated, 1, PostUpdatedPayload{})
+	Schemas.Register(PostDeleted, 1, PostDeletedPayload{})
 }
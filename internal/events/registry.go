@@ -0,0 +1,227 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_blog/validators"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownSchema   = errors.New("unknown event schema")
+	ErrInvalidPayload  = errors.New("invalid event payload")
+	ErrMissingUpcaster = errors.New("missing upcaster")
+)
+
+// Upcaster переводит payload из версии N в версию N+1
+type Upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+// SchemaRegistry: (event type, version) -> Go-тип payload'а + цепочка апкастеров.
+// Продюсеры пишут только последнюю версию, consumer'ы через Upcast тоже видят только её.
+type SchemaRegistry struct {
+	mu        sync.RWMutex
+	types     map[string]map[int]reflect.Type
+	latest    map[string]int
+	upcasters map[string]map[int]Upcaster
+	internal  map[string]bool
+}
+
+// Schemas — реестр, в котором регистрируются все события приложения
+var Schemas = NewSchemaRegistry()
+
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		types:     make(map[string]map[int]reflect.Type),
+		latest:    make(map[string]int),
+		upcasters: make(map[string]map[int]Upcaster),
+		internal:  make(map[string]bool),
+	}
+}
+
+// Register: payload — пустое значение структуры, например PostCreatedPayload{}
+func (r *SchemaRegistry) Register(eventType string, version int, payload any) {
+	if version < 1 {
+		panic(fmt.Sprintf("events: %s: version must be >= 1", eventType))
+	}
+	t := reflect.TypeOf(payload)
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("events: %s v%d: payload must be a struct", eventType, version))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.types[eventType] == nil {
+		r.types[eventType] = make(map[int]reflect.Type)
+	}
+	r.types[eventType][version] = t
+	if version > r.latest[eventType] {
+		r.latest[eventType] = version
+	}
+}
+
+func (r *SchemaRegistry) RegisterUpcaster(eventType string, fromVersion int, fn Upcaster) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.upcasters[eventType] == nil {
+		r.upcasters[eventType] = make(map[int]Upcaster)
+	}
+	r.upcasters[eventType][fromVersion] = fn
+}
+
+func (r *SchemaRegistry) Latest(eventType string) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	v, ok := r.latest[eventType]
+	return v, ok
+}
+
+func (r *SchemaRegistry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.latest))
+	for t := range r.latest {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// MarkInternal — событие только для аудита и внутренних consumer'ов
+// (данные аккаунта): наружу через вебхуки не уходит
+func (r *SchemaRegistry) MarkInternal(eventType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.internal[eventType] = true
+}
+
+func (r *SchemaRegistry) IsInternal(eventType string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.internal[eventType]
+}
+
+// PublicTypes — Types без внутренних
+func (r *SchemaRegistry) PublicTypes() []string {
+	out := r.Types()
+	n := 0
+	for _, t := range out {
+		if !r.IsInternal(t) {
+			out[n] = t
+			n++
+		}
+	}
+	return out[:n]
+}
+
+func (r *SchemaRegistry) payloadType(eventType string, version int) (reflect.Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.types[eventType][version]
+	return t, ok
+}
+
+// NewEnvelope собирает событие последней версии схемы и проверяет payload
+func (r *SchemaRegistry) NewEnvelope(eventType, aggregateType, aggregateID, actorUserID string, payload any) (Envelope, error) {
+	version, ok := r.Latest(eventType)
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %s", ErrUnknownSchema, eventType)
+	}
+
+	t, _ := r.payloadType(eventType, version)
+	if pt := reflect.TypeOf(payload); pt != t && pt != reflect.PointerTo(t) {
+		return Envelope{}, fmt.Errorf("%w: %s v%d expects %s, got %v", ErrInvalidPayload, eventType, version, t, pt)
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	env := Envelope{
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		OccurredAt:    time.Now().UTC(),
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		ActorUserID:   actorUserID,
+		Version:       version,
+		Payload:       b,
+	}
+
+	if err := r.Validate(env); err != nil {
+		return Envelope{}, err
+	}
+	return env, nil
+}
+
+// Validate: схема (type, version) известна, payload строго ложится в её Go-тип
+// и проходит validate-теги
+func (r *SchemaRegistry) Validate(e Envelope) error {
+	t, ok := r.payloadType(e.EventType, e.Version)
+	if !ok {
+		return fmt.Errorf("%w: %s v%d", ErrUnknownSchema, e.EventType, e.Version)
+	}
+
+	v := reflect.New(t)
+	dec := json.NewDecoder(bytes.NewReader(e.Payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v.Interface()); err != nil {
+		return fmt.Errorf("%w: %s v%d: %v", ErrInvalidPayload, e.EventType, e.Version, err)
+	}
+	if err := validators.Validate.Struct(v.Interface()); err != nil {
+		return fmt.Errorf("%w: %s v%d: %v", ErrInvalidPayload, e.EventType, e.Version, err)
+	}
+	return nil
+}
+
+// Upcast доводит payload до последней версии схемы.
+// Неизвестные типы событий возвращаются как есть — не всем consumer'ам нужны все схемы.
+func (r *SchemaRegistry) Upcast(e Envelope) (Envelope, error) {
+	latest, ok := r.Latest(e.EventType)
+	if !ok {
+		return e, nil
+	}
+	if e.Version == 0 {
+		// события до появления версий
+		e.Version = 1
+	}
+	if e.Version > latest {
+		return Envelope{}, fmt.Errorf("%w: %s v%d is newer than v%d", ErrUnknownSchema, e.EventType, e.Version, latest)
+	}
+
+	for e.Version < latest {
+		r.mu.RLock()
+		fn, ok := r.upcasters[e.EventType][e.Version]
+		r.mu.RUnlock()
+		if !ok {
+			return Envelope{}, fmt.Errorf("%w: %s v%d -> v%d", ErrMissingUpcaster, e.EventType, e.Version, e.Version+1)
+		}
+
+		payload, err := fn(e.Payload)
+		if err != nil {
+			return Envelope{}, fmt.Errorf("upcast %s v%d: %w", e.EventType, e.Version, err)
+		}
+		e.Payload = payload
+		e.Version++
+	}
+
+	return e, nil
+}
+
+// DecodePayload — для обработчиков: payload уже после Upcast
+func DecodePayload[T any](e Envelope) (T, error) {
+	var out T
+	if err := json.Unmarshal(e.Payload, &out); err != nil {
+		return out, fmt.Errorf("%w: %s v%d: %v", ErrInvalidPayload, e.EventType, e.Version, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,179 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type greetV1 struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type greetV2 struct {
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name"`
+}
+
+type greetV3 struct {
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name"`
+	Lang      string `json:"lang" validate:"required"`
+}
+
+func newGreetRegistry() *SchemaRegistry {
+	r := NewSchemaRegistry()
+	r.Register("Greeted", 1, greetV1{})
+	r.Register("Greeted", 2, greetV2{})
+	r.Register("Greeted", 3, greetV3{})
+
+	r.RegisterUpcaster("Greeted", 1, func(p json.RawMessage) (json.RawMessage, error) {
+		var v1 greetV1
+		if err := json.Unmarshal(p, &v1); err != nil {
+			return nil, err
+		}
+		return json.Marshal(greetV2{FirstName: v1.Name})
+	})
+	r.RegisterUpcaster("Greeted", 2, func(p json.RawMessage) (json.RawMessage, error) {
+		var v2 greetV2
+		if err := json.Unmarshal(p, &v2); err != nil {
+			return nil, err
+		}
+		return json.Marshal(greetV3{FirstName: v2.FirstName, LastName: v2.LastName, Lang: "en"})
+	})
+	return r
+}
+
+func TestSchemaRegistry_NewEnvelope_UsesLatestVersion(t *testing.T) {
+	r := newGreetRegistry()
+
+	env, err := r.NewEnvelope("Greeted", "user", "1", "1", greetV3{FirstName: "Ann", Lang: "ru"})
+	require.NoError(t, err)
+	require.Equal(t, 3, env.Version)
+	require.NotEmpty(t, env.EventID)
+	require.False(t, env.OccurredAt.IsZero())
+}
+
+func TestSchemaRegistry_NewEnvelope_RejectsWrongType(t *testing.T) {
+	r := newGreetRegistry()
+
+	_, err := r.NewEnvelope("Greeted", "user", "1", "1", greetV1{Name: "Ann"})
+	require.ErrorIs(t, err, ErrInvalidPayload)
+
+	_, err = r.NewEnvelope("Unknown", "user", "1", "1", greetV1{Name: "Ann"})
+	require.ErrorIs(t, err, ErrUnknownSchema)
+}
+
+func TestSchemaRegistry_NewEnvelope_RunsValidateTags(t *testing.T) {
+	r := newGreetRegistry()
+
+	_, err := r.NewEnvelope("Greeted", "user", "1", "1", greetV3{FirstName: "Ann"})
+	require.ErrorIs(t, err, ErrInvalidPayload)
+}
+
+func TestSchemaRegistry_Validate(t *testing.T) {
+	r := newGreetRegistry()
+
+	require.NoError(t, r.Validate(Envelope{EventType: "Greeted", Version: 1, Payload: json.RawMessage(`{"name":"Ann"}`)}))
+
+	err := r.Validate(Envelope{EventType: "Greeted", Version: 1, Payload: json.RawMessage(`{"name":"Ann","extra":1}`)})
+	require.ErrorIs(t, err, ErrInvalidPayload)
+
+	err = r.Validate(Envelope{EventType: "Greeted", Version: 9, Payload: json.RawMessage(`{}`)})
+	require.ErrorIs(t, err, ErrUnknownSchema)
+}
+
+func TestSchemaRegistry_Upcast_OldEventToLatest(t *testing.T) {
+	r := newGreetRegistry()
+
+	old := Envelope{EventID: "e1", EventType: "Greeted", Version: 1, Payload: json.RawMessage(`{"name":"Ann"}`)}
+
+	got, err := r.Upcast(old)
+	require.NoError(t, err)
+	require.Equal(t, 3, got.Version)
+	require.NoError(t, r.Validate(got))
+
+	p, err := DecodePayload[greetV3](got)
+	require.NoError(t, err)
+	require.Equal(t, greetV3{FirstName: "Ann", Lang: "en"}, p)
+}
+
+func TestSchemaRegistry_Upcast_ZeroVersionTreatedAsV1(t *testing.T) {
+	r := newGreetRegistry()
+
+	got, err := r.Upcast(Envelope{EventType: "Greeted", Payload: json.RawMessage(`{"name":"Ann"}`)})
+	require.NoError(t, err)
+	require.Equal(t, 3, got.Version)
+}
+
+func TestSchemaRegistry_Upcast_MissingStep(t *testing.T) {
+	r := NewSchemaRegistry()
+	r.Register("Greeted", 1, greetV1{})
+	r.Register("Greeted", 2, greetV2{})
+
+	_, err := r.Upcast(Envelope{EventType: "Greeted", Version: 1, Payload: json.RawMessage(`{"name":"Ann"}`)})
+	require.ErrorIs(t, err, ErrMissingUpcaster)
+}
+
+func TestSchemaRegistry_Upcast_UnknownTypePassesThrough(t *testing.T) {
+	r := newGreetRegistry()
+
+	in := Envelope{EventType: "Other", Version: 4, Payload: json.RawMessage(`{}`)}
+	got, err := r.Upcast(in)
+	require.NoError(t, err)
+	require.Equal(t, in, got)
+}
+
+func TestSchemaRegistry_Upcast_NewerThanKnown(t *testing.T) {
+	r := newGreetRegistry()
+
+	_, err := r.Upcast(Envelope{EventType: "Greeted", Version: 4, Payload: json.RawMessage(`{}`)})
+	require.ErrorIs(t, err, ErrUnknownSchema)
+}
+
+func TestSchemas_PostEventsRegistered(t *testing.T) {
+	for _, typ := range []string{PostCreated, PostUpdated, PostDeleted} {
+		v, ok := Schemas.Latest(typ)
+		require.True(t, ok, typ)
+		require.Equal(t, 1, v)
+	}
+}
+
+func TestSchemas_CommentCreated_V1UpcastsToV2(t *testing.T) {
+	old := Envelope{
+		EventType: CommentCreated,
+		Version:   1,
+		Payload:   json.RawMessage(`{"comment_id":"1","post_id":"2","post_slug":"s","post_author_id":"3","text":"hi"}`),
+	}
+	require.NoError(t, Schemas.Validate(old))
+
+	got, err := Schemas.Upcast(old)
+	require.NoError(t, err)
+	require.Equal(t, 2, got.Version)
+	require.NoError(t, Schemas.Validate(got))
+
+	p, err := DecodePayload[CommentCreatedPayload](got)
+	require.NoError(t, err)
+	require.Equal(t, "2", p.PostID)
+	require.Empty(t, p.ParentID)
+}
+
+func TestSchemas_CommentCreated_ReplyNeedsParentAuthor(t *testing.T) {
+	_, err := Schemas.NewEnvelope(CommentCreated, "comment", "1", "1", CommentCreatedPayload{
+		CommentID: "1", PostID: "2", PostSlug: "s", PostAuthorID: "3", ParentID: "9",
+	})
+	require.ErrorIs(t, err, ErrInvalidPayload)
+}
+
+func TestSchemas_AccountEventsAreInternal(t *testing.T) {
+	public := Schemas.PublicTypes()
+	for _, typ := range []string{UserFollowed, UserPasswordChanged, UserEmailChangeRequested, UserEmailChanged, RefreshTokenReused,
+		UserMFAEnabled, UserMFADisabled, UserMFARecoveryCodesRenewed, UserIdentityLinked, UserRoleChanged,
+		UserSuspended, UserReactivated, UserPasswordResetForced, UserLoginUnlocked} {
+		require.True(t, Schemas.IsInternal(typ), typ)
+		require.NotContains(t, public, typ)
+		require.Contains(t, Schemas.Types(), typ)
+	}
+	require.Contains(t, public, PostCreated)
+}
@@ -0,0 +1,125 @@
+package events
+
+import "time"
+
+const (
+	UserFollowed                = "UserFollowed"
+	UserPasswordChanged         = "UserPasswordChanged"
+	UserEmailChangeRequested    = "UserEmailChangeRequested"
+	UserEmailChanged            = "UserEmailChanged"
+	RefreshTokenReused          = "RefreshTokenReused"
+	UserMFAEnabled              = "UserMFAEnabled"
+	UserMFADisabled             = "UserMFADisabled"
+	UserMFARecoveryCodesRenewed = "UserMFARecoveryCodesRenewed"
+	UserIdentityLinked          = "UserIdentityLinked"
+	UserRoleChanged             = "UserRoleChanged"
+	UserSuspended               = "UserSuspended"
+	UserReactivated             = "UserReactivated"
+	UserPasswordResetForced     = "UserPasswordResetForced"
+	UserLoginUnlocked           = "UserLoginUnlocked"
+)
+
+type UserFollowedPayload struct {
+	FollowerID string `json:"follower_id" validate:"required"`
+	FolloweeID string `json:"followee_id" validate:"required"`
+}
+
+type UserPasswordChangedPayload struct {
+	UserID string `json:"user_id" validate:"required"`
+}
+
+type UserEmailChangeRequestedPayload struct {
+	UserID   string `json:"user_id" validate:"required"`
+	NewEmail string `json:"new_email" validate:"required,email"`
+}
+
+type UserEmailChangedPayload struct {
+	UserID   string `json:"user_id" validate:"required"`
+	OldEmail string `json:"old_email" validate:"required,email"`
+	NewEmail string `json:"new_email" validate:"required,email"`
+}
+
+// RefreshTokenReusedPayload — предъявлен уже ротированный refresh-токен, сессия отозвана.
+// IP и UserAgent — того, кто предъявил токен.
+type RefreshTokenReusedPayload struct {
+	UserID    string `json:"user_id" validate:"required"`
+	SessionID string `json:"session_id" validate:"required"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+// UserMFAPayload — общий для включения/выключения 2FA и перевыпуска кодов восстановления
+type UserMFAPayload struct {
+	UserID string `json:"user_id" validate:"required"`
+}
+
+// UserIdentityLinkedPayload — к аккаунту привязан вход через внешнего провайдера
+type UserIdentityLinkedPayload struct {
+	UserID   string `json:"user_id" validate:"required"`
+	Provider string `json:"provider" validate:"required"`
+}
+
+// UserRoleChangedPayload — actor события — администратор, назначивший роль
+type UserRoleChangedPayload struct {
+	UserID  string `json:"user_id" validate:"required"`
+	OldRole string `json:"old_role" validate:"required"`
+	NewRole string `json:"new_role" validate:"required"`
+}
+
+// UserSuspendedPayload — Until пустой: блокировка бессрочная
+type UserSuspendedPayload struct {
+	UserID string     `json:"user_id" validate:"required"`
+	Reason string     `json:"reason" validate:"required"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// UserReactivatedPayload — Expired: снята по истечении срока, actor события пустой
+type UserReactivatedPayload struct {
+	UserID  string `json:"user_id" validate:"required"`
+	Expired bool   `json:"expired"`
+}
+
+type UserPasswordResetForcedPayload struct {
+	UserID string `json:"user_id" validate:"required"`
+}
+
+// UserLoginUnlockedPayload — админ снял блокировку входа после серии неудачных попыток
+type UserLoginUnlockedPayload struct {
+	UserID string `json:"user_id" validate:"required"`
+}
+
+func init() {
+	// граф подписок — не публичные данные: подписка "*" видела бы все follow в системе
+	Schemas.Register(UserFollowed, 1, UserFollowedPayload{})
+	Schemas.MarkInternal(UserFollowed)
+
+	Schemas.Register(UserPasswordChanged, 1, UserPasswordChangedPayload{})
+	Schemas.Register(UserEmailChangeRequested, 1, UserEmailChangeRequestedPayload{})
+	Schemas.Register(UserEmailChanged, 1, UserEmailChangedPayload{})
+	Schemas.MarkInternal(UserPasswordChanged)
+	Schemas.MarkInternal(UserEmailChangeRequested)
+	Schemas.MarkInternal(UserEmailChanged)
+
+	Schemas.Register(RefreshTokenReused, 1, RefreshTokenReusedPayload{})
+	Schemas.MarkInternal(RefreshTokenReused)
+
+	for _, typ := range []string{UserMFAEnabled, UserMFADisabled, UserMFARecoveryCodesRenewed} {
+		Schemas.Register(typ, 1, UserMFAPayload{})
+		Schemas.MarkInternal(typ)
+	}
+
+	Schemas.Register(UserIdentityLinked, 1, UserIdentityLinkedPayload{})
+	Schemas.MarkInternal(UserIdentityLinked)
+
+	Schemas.Register(UserRoleChanged, 1, UserRoleChangedPayload{})
+	Schemas.MarkInternal(UserRoleChanged)
+
+	Schemas.Register(UserSuspended, 1, UserSuspendedPayload{})
+	Schemas.Register(UserReactivated, 1, UserReactivatedPayload{})
+	Schemas.Register(UserPasswordResetForced, 1, UserPasswordResetForcedPayload{})
+	Schemas.Register(UserLoginUnlocked, 1, UserLoginUnlockedPayload{})
+	Schemas.MarkInternal(UserSuspended)
+	Schemas.MarkInternal(UserReactivated)
+	Schemas.MarkInternal(UserPasswordResetForced)
+	Schemas.MarkInternal(UserLoginUnlocked)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+)
+
+type AuditLogHandler struct {
+	repo *repositories.AuditLogRepository
+}
+
+func NewAuditLogHandler(repo *repositories.AuditLogRepository) *AuditLogHandler {
+	return &AuditLogHandler{repo: repo}
+}
+
+// Create идемпотентен по EventID, поэтому повторная доставка безопасна
+func (h *AuditLogHandler) Handle(ctx context.Context, env events.Envelope) error {
+	logEntry := models.AuditLog{
+		EventID:       env.EventID,
+		EventType:     env.EventType,
+		AggregateType: env.AggregateType,
+		AggregateID:   env.AggregateID,
+		ActorUserID:   env.ActorUserID,
+		CorrelationID: env.CorrelationID,
+		Version:       env.Version,
+		Payload:       string(env.Payload),
+		OccurredAt:    env.OccurredAt,
+	}
+
+	return h.repo.Create(ctx, &logEntry)
+}
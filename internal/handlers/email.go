@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"go_blog/internal/consumer"
+	"go_blog/internal/events"
+	"go_blog/internal/mail"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+
+	"gorm.io/gorm"
+)
+
+const EmailConsumer = "email"
+
+type commentMailData struct {
+	Actor     string
+	PostTitle string
+	PostSlug  string
+	Text      string
+}
+
+type followerMailData struct {
+	Actor string
+}
+
+// EmailHandler ставит письма о важных событиях в mail_outbox; отправляет команда mailer.
+// DedupKey по event_id — даже replay не шлёт письмо второй раз.
+type EmailHandler struct {
+	composer *mail.Composer
+	outbox   *repositories.MailOutboxRepository
+	prefs    *repositories.NotificationRepository
+}
+
+func NewEmailHandler(db *gorm.DB, composer *mail.Composer, outbox *repositories.MailOutboxRepository, prefs *repositories.NotificationRepository, processed consumer.ProcessedStore) consumer.Handler {
+	h := &EmailHandler{composer: composer, outbox: outbox, prefs: prefs}
+	return consumer.Idempotent(db, processed, EmailConsumer, h.handleTx)
+}
+
+func (h *EmailHandler) handleTx(ctx context.Context, tx *gorm.DB, env events.Envelope) error {
+	actor := parseID(env.ActorUserID)
+
+	switch env.EventType {
+	case events.CommentCreated:
+		p, err := events.DecodePayload[events.CommentCreatedPayload](env)
+		if err != nil {
+			return err
+		}
+		var post models.Post
+		if err := tx.WithContext(ctx).Unscoped().Select("id", "title").First(&post, parseID(p.PostID)).Error; err != nil {
+			return err
+		}
+		data := commentMailData{Actor: h.nickname(ctx, tx, actor), PostTitle: post.Title, PostSlug: p.PostSlug, Text: excerpt(p.Text)}
+
+		if p.ParentAuthorID != "" {
+			if err := h.send(ctx, tx, env, parseID(p.ParentAuthorID), actor, mail.TemplateCommentReply, data); err != nil {
+				return err
+			}
+		}
+		if p.PostAuthorID != p.ParentAuthorID {
+			return h.send(ctx, tx, env, parseID(p.PostAuthorID), actor, mail.TemplateCommentCreated, data)
+		}
+		return nil
+
+	case events.UserFollowed:
+		p, err := events.DecodePayload[events.UserFollowedPayload](env)
+		if err != nil {
+			return err
+		}
+		follower := parseID(p.FollowerID)
+		return h.send(ctx, tx, env, parseID(p.FolloweeID), follower, mail.TemplateNewFollower,
+			followerMailData{Actor: h.nickname(ctx, tx, follower)})
+	}
+
+	return nil
+}
+
+func (h *EmailHandler) send(ctx context.Context, tx *gorm.DB, env events.Envelope, userID, actorID uint, tmpl string, data any) error {
+	if userID == 0 || userID == actorID {
+		return nil
+	}
+
+	enabled, err := h.prefs.IsEnabledTx(ctx, tx, userID, mail.CategoryActivity)
+	if err != nil || !enabled {
+		return err
+	}
+
+	// на неподтверждённый адрес не пишем: его мог указать кто угодно
+	var user models.User
+	err = tx.WithContext(ctx).Select("id", "email", "nickname", "is_active", "email_verified_at").Where("id = ?", userID).Limit(1).Find(&user).Error
+	if err != nil || user.ID == 0 || !user.IsActive || user.EmailVerifiedAt == nil {
+		return err
+	}
+
+	m, err := h.composer.Compose(tmpl, mail.CategoryActivity, mail.Recipient{UserID: user.ID, Email: user.Email, Name: user.Nickname}, data)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s:%s:%d", env.EventID, tmpl, userID)
+	return h.outbox.EnqueueTx(ctx, tx, mail.ToOutbox(m, userID, tmpl, key))
+}
+
+func (h *EmailHandler) nickname(ctx context.Context, tx *gorm.DB, id uint) string {
+	var u models.User
+	if err := tx.WithContext(ctx).Unscoped().Select("nickname").Where("id = ?", id).Limit(1).Find(&u).Error; err != nil || u.Nickname == "" {
+		return "Someone"
+	}
+	return u.Nickname
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"go_blog/internal/events"
+	"go_blog/internal/mail"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailHandler_FollowQueuedOnce_RespectsOptOut(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	ctx := context.Background()
+
+	verified := time.Now().UTC()
+	followee := &models.User{Nickname: "bob", Email: "bob@test.com", Password: "x", IsActive: true, EmailVerifiedAt: &verified}
+	follower := &models.User{Nickname: "alice", Email: "alice@test.com", Password: "x", IsActive: true}
+	require.NoError(t, tx.Create(followee).Error)
+	require.NoError(t, tx.Create(follower).Error)
+
+	composer, err := mail.NewComposer(mail.Config{BaseURL: "http://blog.test", Secret: "s"})
+	require.NoError(t, err)
+	prefs := repositories.NewNotificationRepository(tx)
+	h := NewEmailHandler(tx, composer, repositories.NewMailOutboxRepository(tx), prefs, repositories.NewProcessedEventRepository(tx))
+
+	followEvent := func() events.Envelope {
+		env, err := events.Schemas.NewEnvelope(events.UserFollowed, "user", strconv.Itoa(int(followee.ID)), strconv.Itoa(int(follower.ID)),
+			events.UserFollowedPayload{FollowerID: strconv.Itoa(int(follower.ID)), FolloweeID: strconv.Itoa(int(followee.ID))})
+		require.NoError(t, err)
+		return env
+	}
+
+	first := followEvent()
+	require.NoError(t, h.Handle(ctx, first))
+	require.NoError(t, h.Handle(ctx, first)) // повторная доставка
+
+	var rows []models.MailOutbox
+	require.NoError(t, tx.Find(&rows).Error)
+	require.Len(t, rows, 1)
+	require.Equal(t, "bob@test.com", rows[0].To)
+	require.Equal(t, "alice started following you", rows[0].Subject)
+	require.Equal(t, models.MailPending, rows[0].Status)
+
+	require.NoError(t, prefs.SetPreferences(ctx, followee.ID, map[string]bool{mail.CategoryActivity: false}))
+	require.NoError(t, h.Handle(ctx, followEvent()))
+
+	var count int64
+	require.NoError(t, tx.Model(&models.MailOutbox{}).Count(&count).Error)
+	require.Equal(t, int64(1), count)
+}
+
+func TestEmailHandler_SkipsUnverifiedAddress(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	ctx := context.Background()
+
+	followee := &models.User{Nickname: "bob", Email: "bob@test.com", Password: "x", IsActive: true}
+	follower := &models.User{Nickname: "alice", Email: "alice@test.com", Password: "x", IsActive: true}
+	require.NoError(t, tx.Create(followee).Error)
+	require.NoError(t, tx.Create(follower).Error)
+
+	composer, err := mail.NewComposer(mail.Config{BaseURL: "http://blog.test", Secret: "s"})
+	require.NoError(t, err)
+	h := NewEmailHandler(tx, composer, repositories.NewMailOutboxRepository(tx), repositories.NewNotificationRepository(tx), repositories.NewProcessedEventRepository(tx))
+
+	env, err := events.Schemas.NewEnvelope(events.UserFollowed, "user", strconv.Itoa(int(followee.ID)), strconv.Itoa(int(follower.ID)),
+		events.UserFollowedPayload{FollowerID: strconv.Itoa(int(follower.ID)), FolloweeID: strconv.Itoa(int(followee.ID))})
+	require.NoError(t, err)
+	require.NoError(t, h.Handle(ctx, env))
+
+	var count int64
+	require.NoError(t, tx.Model(&models.MailOutbox{}).Count(&count).Error)
+	require.Zero(t, count)
+}
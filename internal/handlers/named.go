@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"go_blog/internal/consumer"
+	"go_blog/internal/mail"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Named — обработчики, которые можно вызвать по имени (команда replay).
+// Живых push-обработчиков (realtime, user-notify) здесь нет: replay разослал бы
+// клиентам старые уведомления как новые.
+func Named(db *gorm.DB, composer *mail.Composer) map[string]consumer.Handler {
+	return map[string]consumer.Handler{
+		"audit-log":      NewAuditLogHandler(repositories.NewAuditLogRepository(db)),
+		"webhook-fanout": NewWebhookFanoutHandler(repositories.NewWebhookRepository(db)),
+		"notifications": NewNotificationHandler(db,
+			repositories.NewNotificationRepository(db),
+			repositories.NewProcessedEventRepository(db)),
+		"email": NewEmailHandler(db, composer,
+			repositories.NewMailOutboxRepository(db),
+			repositories.NewNotificationRepository(db),
+			repositories.NewProcessedEventRepository(db)),
+	}
+}
+
+// Resets — очистка проекций для перестройки с нуля (replay -rebuild): таблицы проекции
+// и отметки processed_events её consumer'а удаляются одной транзакцией, затем replay
+// применяет историю заново. Обработчики с внешними эффектами (email, webhook-fanout)
+// так перестраивать нельзя — письма и доставки ушли бы повторно.
+func Resets(db *gorm.DB) map[string]func(ctx context.Context) error {
+	return map[string]func(ctx context.Context) error{
+		"notifications": func(ctx context.Context) error {
+			return resetProjection(ctx, db, NotificationsConsumer, &models.NotificationActor{}, &models.Notification{})
+		},
+	}
+}
+
+func resetProjection(ctx context.Context, db *gorm.DB, consumerName string, tables ...any) error {
+	processed := repositories.NewProcessedEventRepository(db)
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, table := range tables {
+			if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+		return processed.DeleteByConsumerTx(ctx, tx, consumerName)
+	})
+}
+
+func Names[V any](named map[string]V) []string {
+	out := make([]string, 0, len(named))
+	for name := range named {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamed_NoLivePushHandlers(t *testing.T) {
+	require.Equal(t, []string{"audit-log", "email", "notifications", "webhook-fanout"}, Names(Named(nil, nil)))
+}
+
+func TestResets_OnlyReplayableProjections(t *testing.T) {
+	resets := Resets(nil)
+	require.Equal(t, []string{"notifications"}, Names(resets))
+	for name := range resets {
+		require.Contains(t, Named(nil, nil), name)
+	}
+}
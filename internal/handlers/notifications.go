@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go_blog/internal/consumer"
+	"go_blog/internal/events"
+	"go_blog/internal/realtime"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"strconv"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const (
+	NotificationsConsumer = "notifications"
+	excerptLen            = 140
+)
+
+// NotificationHandler — проекция событий во входящие уведомления (таблица notifications).
+// Идемпотентен через processed_events: повторная доставка не увеличит счётчик группы.
+type NotificationHandler struct {
+	repo *repositories.NotificationRepository
+}
+
+func NewNotificationHandler(db *gorm.DB, repo *repositories.NotificationRepository, processed consumer.ProcessedStore) consumer.Handler {
+	h := &NotificationHandler{repo: repo}
+	return consumer.Idempotent(db, processed, NotificationsConsumer, h.handleTx)
+}
+
+type notificationData struct {
+	PostSlug string `json:"post_slug,omitempty"`
+	Text     string `json:"text,omitempty"`
+}
+
+func (h *NotificationHandler) handleTx(ctx context.Context, tx *gorm.DB, env events.Envelope) error {
+	actor := parseID(env.ActorUserID)
+
+	switch env.EventType {
+	case events.CommentCreated:
+		p, err := events.DecodePayload[events.CommentCreatedPayload](env)
+		if err != nil {
+			return err
+		}
+		postID, commentID := parseID(p.PostID), parseID(p.CommentID)
+		data := notificationData{PostSlug: p.PostSlug, Text: excerpt(p.Text)}
+
+		if p.ParentAuthorID != "" {
+			if err := h.add(ctx, tx, parseID(p.ParentAuthorID), actor, realtime.NotifyCommentReply, "", &postID, &commentID, data); err != nil {
+				return err
+			}
+		}
+		if p.PostAuthorID != p.ParentAuthorID {
+			return h.add(ctx, tx, parseID(p.PostAuthorID), actor, realtime.NotifyCommentCreated, "", &postID, &commentID, data)
+		}
+		return nil
+
+	case events.PostLiked:
+		p, err := events.DecodePayload[events.PostLikePayload](env)
+		if err != nil {
+			return err
+		}
+		postID := parseID(p.PostID)
+		return h.add(ctx, tx, parseID(p.PostAuthorID), actor, realtime.NotifyPostLiked,
+			realtime.NotifyPostLiked+":"+p.PostID, &postID, nil, notificationData{PostSlug: p.PostSlug})
+
+	case events.UserFollowed:
+		p, err := events.DecodePayload[events.UserFollowedPayload](env)
+		if err != nil {
+			return err
+		}
+		return h.add(ctx, tx, parseID(p.FolloweeID), parseID(p.FollowerID), realtime.NotifyUserFollowed,
+			realtime.NotifyUserFollowed, nil, nil, notificationData{})
+	}
+
+	return nil
+}
+
+func (h *NotificationHandler) add(ctx context.Context, tx *gorm.DB, userID, actorID uint, typ, group string, postID, commentID *uint, data notificationData) error {
+	// о своих действиях не уведомляем
+	if userID == 0 || userID == actorID {
+		return nil
+	}
+
+	enabled, err := h.repo.IsEnabledTx(ctx, tx, userID, typ)
+	if err != nil || !enabled {
+		return err
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return h.repo.UpsertTx(ctx, tx, &models.Notification{
+		UserID:      userID,
+		Type:        typ,
+		GroupKey:    group,
+		ActorUserID: actorID,
+		PostID:      postID,
+		CommentID:   commentID,
+		Data:        string(b),
+	})
+}
+
+func parseID(s string) uint {
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return uint(n)
+}
+
+func excerpt(s string) string {
+	if utf8.RuneCountInString(s) <= excerptLen {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:excerptLen]) + "…"
+}
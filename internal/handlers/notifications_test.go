@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go_blog/internal/events"
+	"go_blog/internal/replay"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func likeEvent(t *testing.T, actor string) events.Envelope {
+	t.Helper()
+	env, err := events.Schemas.NewEnvelope(events.PostLiked, "post", "1", actor, events.PostLikePayload{
+		PostID: "1", PostSlug: "p", PostAuthorID: "7", UserID: actor, LikesCount: 1,
+	})
+	require.NoError(t, err)
+	return env
+}
+
+func TestNotificationHandler_LikesAggregated_RedeliveryIgnored(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := repositories.NewNotificationRepository(tx)
+	h := NewNotificationHandler(tx, repo, repositories.NewProcessedEventRepository(tx))
+	ctx := context.Background()
+
+	first := likeEvent(t, "10")
+	require.NoError(t, h.Handle(ctx, first))
+	require.NoError(t, h.Handle(ctx, first)) // повторная доставка
+	require.NoError(t, h.Handle(ctx, likeEvent(t, "11")))
+	// автор лайкнул свой пост — не уведомляем
+	require.NoError(t, h.Handle(ctx, likeEvent(t, "7")))
+
+	items, total, err := repo.ListForUser(ctx, 7, true, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	require.Equal(t, 2, items[0].ActorCount)
+}
+
+func TestNotificationHandler_ReplyNotifiesParentAndPostAuthor(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := repositories.NewNotificationRepository(tx)
+	h := NewNotificationHandler(tx, repo, repositories.NewProcessedEventRepository(tx))
+	ctx := context.Background()
+
+	env, err := events.Schemas.NewEnvelope(events.CommentCreated, "comment", "5", "9", events.CommentCreatedPayload{
+		CommentID: "5", PostID: "1", PostSlug: "p", PostAuthorID: "7", Text: "hi",
+		ParentID: "4", ParentAuthorID: "8",
+	})
+	require.NoError(t, err)
+	require.NoError(t, h.Handle(ctx, env))
+
+	var got []models.Notification
+	require.NoError(t, tx.Order("user_id").Find(&got).Error)
+	require.Len(t, got, 2)
+	require.Equal(t, uint(7), got[0].UserID)
+	require.Equal(t, "comment.created", got[0].Type)
+	require.Equal(t, uint(8), got[1].UserID)
+	require.Equal(t, "comment.reply", got[1].Type)
+
+	var data notificationData
+	require.NoError(t, json.Unmarshal([]byte(got[1].Data), &data))
+	require.Equal(t, "hi", data.Text)
+}
+
+func TestNotificationHandler_RespectsPreferences(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := repositories.NewNotificationRepository(tx)
+	h := NewNotificationHandler(tx, repo, repositories.NewProcessedEventRepository(tx))
+	ctx := context.Background()
+
+	require.NoError(t, repo.SetPreferences(ctx, 7, map[string]bool{"post.liked": false}))
+	require.NoError(t, h.Handle(ctx, likeEvent(t, "10")))
+
+	count, err := repo.CountUnread(ctx, 7)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+type sliceSource []events.Envelope
+
+func (s sliceSource) Stream(ctx context.Context, fn func(events.Envelope) error) error {
+	for _, e := range s {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestNotificationHandler_RebuildIntoPopulatedProjection(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := repositories.NewNotificationRepository(tx)
+	h := NewNotificationHandler(tx, repo, repositories.NewProcessedEventRepository(tx))
+	ctx := context.Background()
+
+	env, err := events.Schemas.NewEnvelope(events.CommentCreated, "comment", "5", "9", events.CommentCreatedPayload{
+		CommentID: "5", PostID: "1", PostSlug: "p", PostAuthorID: "7", Text: "hi",
+	})
+	require.NoError(t, err)
+	history := sliceSource{env, likeEvent(t, "10"), likeEvent(t, "11")}
+
+	// живая обработка уже наполнила проекцию
+	for _, e := range history {
+		require.NoError(t, h.Handle(ctx, e))
+	}
+
+	// replay без перестройки ничего не задваивает
+	_, err = replay.New(history, h, replay.Options{}).Run(ctx)
+	require.NoError(t, err)
+	var got []models.Notification
+	require.NoError(t, tx.Order("id").Find(&got).Error)
+	require.Len(t, got, 2)
+	require.Equal(t, 2, got[1].ActorCount)
+
+	// испорченная проекция перестраивается с нуля из истории
+	require.NoError(t, tx.Model(&models.Notification{}).Where("id = ?", got[1].ID).Update("actor_count", 99).Error)
+	st, err := replay.New(history, h, replay.Options{Reset: Resets(tx)["notifications"]}).Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, st.Handled)
+
+	got = nil
+	require.NoError(t, tx.Order("id").Find(&got).Error)
+	require.Len(t, got, 2)
+	require.Equal(t, "comment.created", got[0].Type)
+	require.Equal(t, 2, got[1].ActorCount)
+}
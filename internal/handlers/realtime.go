@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"go_blog/internal/events"
+	"go_blog/internal/realtime"
+	"time"
+)
+
+// RealtimeHandler переводит доменные события в SSE-сообщения: в ленту поста и в общую
+type RealtimeHandler struct {
+	pub *realtime.Publisher
+}
+
+func NewRealtimeHandler(pub *realtime.Publisher) *RealtimeHandler {
+	return &RealtimeHandler{pub: pub}
+}
+
+type realtimeComment struct {
+	EventID   string    `json:"event_id"`
+	CommentID string    `json:"comment_id"`
+	PostID    string    `json:"post_id"`
+	PostSlug  string    `json:"post_slug"`
+	UserID    string    `json:"user_id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type realtimePost struct {
+	EventID   string    `json:"event_id"`
+	PostID    string    `json:"post_id"`
+	Slug      string    `json:"slug,omitempty"`
+	Title     string    `json:"title,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type realtimeLikes struct {
+	EventID    string `json:"event_id"`
+	PostID     string `json:"post_id"`
+	PostSlug   string `json:"post_slug"`
+	LikesCount int64  `json:"likes_count"`
+}
+
+func (h *RealtimeHandler) Handle(ctx context.Context, env events.Envelope) error {
+	switch env.EventType {
+	case events.CommentCreated:
+		p, err := events.DecodePayload[events.CommentCreatedPayload](env)
+		if err != nil {
+			return err
+		}
+		return h.publish(ctx, p.PostID, "comment.created", realtimeComment{
+			EventID:   env.EventID,
+			CommentID: p.CommentID,
+			PostID:    p.PostID,
+			PostSlug:  p.PostSlug,
+			UserID:    env.ActorUserID,
+			Text:      p.Text,
+			CreatedAt: env.OccurredAt,
+		})
+
+	case events.PostCreated:
+		p, err := events.DecodePayload[events.PostCreatedPayload](env)
+		if err != nil {
+			return err
+		}
+		// у нового поста ещё нет подписчиков — только общая лента
+		_, err = h.pub.Publish(ctx, realtime.GlobalTopic, "post.created", realtimePost{
+			EventID: env.EventID, PostID: p.PostID, Slug: p.Slug, Title: p.Title, UpdatedAt: env.OccurredAt,
+		})
+		return err
+
+	case events.PostUpdated:
+		p, err := events.DecodePayload[events.PostUpdatedPayload](env)
+		if err != nil {
+			return err
+		}
+		return h.publish(ctx, p.PostID, "post.updated", realtimePost{
+			EventID: env.EventID, PostID: p.PostID, Slug: p.Slug, Title: p.Title, UpdatedAt: env.OccurredAt,
+		})
+
+	case events.PostDeleted:
+		p, err := events.DecodePayload[events.PostDeletedPayload](env)
+		if err != nil {
+			return err
+		}
+		return h.publish(ctx, p.PostID, "post.deleted", realtimePost{
+			EventID: env.EventID, PostID: p.PostID, UpdatedAt: env.OccurredAt,
+		})
+
+	case events.PostLiked, events.PostUnliked:
+		p, err := events.DecodePayload[events.PostLikePayload](env)
+		if err != nil {
+			return err
+		}
+		return h.publish(ctx, p.PostID, "post.likes", realtimeLikes{
+			EventID: env.EventID, PostID: p.PostID, PostSlug: p.PostSlug, LikesCount: p.LikesCount,
+		})
+	}
+
+	return nil
+}
+
+func (h *RealtimeHandler) publish(ctx context.Context, postID, event string, data any) error {
+	if _, err := h.pub.Publish(ctx, realtime.PostTopic(postID), event, data); err != nil {
+		return err
+	}
+	_, err := h.pub.Publish(ctx, realtime.GlobalTopic, event, data)
+	return err
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"go_blog/internal/events"
+	"go_blog/internal/realtime"
+	"time"
+)
+
+// UserNotifyHandler шлёт личные уведомления в топик пользователя; /ws доставляет их
+// на тот инстанс API, где пользователь подключён
+type UserNotifyHandler struct {
+	pub *realtime.Publisher
+}
+
+func NewUserNotifyHandler(pub *realtime.Publisher) *UserNotifyHandler {
+	return &UserNotifyHandler{pub: pub}
+}
+
+type userNotification struct {
+	Type        string    `json:"type"`
+	EventID     string    `json:"event_id"`
+	ActorUserID string    `json:"actor_user_id"`
+	PostID      string    `json:"post_id,omitempty"`
+	PostSlug    string    `json:"post_slug,omitempty"`
+	CommentID   string    `json:"comment_id,omitempty"`
+	Text        string    `json:"text,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (h *UserNotifyHandler) Handle(ctx context.Context, env events.Envelope) error {
+	switch env.EventType {
+	case events.CommentCreated:
+		p, err := events.DecodePayload[events.CommentCreatedPayload](env)
+		if err != nil {
+			return err
+		}
+		n := userNotification{
+			EventID:     env.EventID,
+			ActorUserID: env.ActorUserID,
+			PostID:      p.PostID,
+			PostSlug:    p.PostSlug,
+			CommentID:   p.CommentID,
+			Text:        p.Text,
+			CreatedAt:   env.OccurredAt,
+		}
+
+		if p.ParentAuthorID != "" {
+			n.Type = realtime.NotifyCommentReply
+			if err := h.notify(ctx, p.ParentAuthorID, n); err != nil {
+				return err
+			}
+		}
+		// автор поста, которому уже пришёл reply, второй раз не нужен
+		if p.PostAuthorID != p.ParentAuthorID {
+			n.Type = realtime.NotifyCommentCreated
+			return h.notify(ctx, p.PostAuthorID, n)
+		}
+		return nil
+
+	case events.PostLiked:
+		p, err := events.DecodePayload[events.PostLikePayload](env)
+		if err != nil {
+			return err
+		}
+		return h.notify(ctx, p.PostAuthorID, userNotification{
+			Type:        realtime.NotifyPostLiked,
+			EventID:     env.EventID,
+			ActorUserID: env.ActorUserID,
+			PostID:      p.PostID,
+			PostSlug:    p.PostSlug,
+			CreatedAt:   env.OccurredAt,
+		})
+
+	case events.UserFollowed:
+		p, err := events.DecodePayload[events.UserFollowedPayload](env)
+		if err != nil {
+			return err
+		}
+		return h.notify(ctx, p.FolloweeID, userNotification{
+			Type:        realtime.NotifyUserFollowed,
+			EventID:     env.EventID,
+			ActorUserID: p.FollowerID,
+			CreatedAt:   env.OccurredAt,
+		})
+	}
+
+	return nil
+}
+
+func (h *UserNotifyHandler) notify(ctx context.Context, userID string, n userNotification) error {
+	// о своих действиях не уведомляем
+	if userID == "" || userID == n.ActorUserID {
+		return nil
+	}
+	return h.pub.PublishLive(ctx, realtime.UserTopic(userID), n.Type, n)
+}
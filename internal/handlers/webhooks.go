@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"time"
+)
+
+// WebhookFanoutHandler раскладывает событие по подпискам в webhook_deliveries,
+// саму отправку делает воркер webhooks (cmd webhooks/)
+type WebhookFanoutHandler struct {
+	repo *repositories.WebhookRepository
+}
+
+func NewWebhookFanoutHandler(repo *repositories.WebhookRepository) *WebhookFanoutHandler {
+	return &WebhookFanoutHandler{repo: repo}
+}
+
+func (h *WebhookFanoutHandler) Handle(ctx context.Context, env events.Envelope) error {
+	// подписка "*" не должна получать события аккаунтов других пользователей
+	if events.Schemas.IsInternal(env.EventType) {
+		return nil
+	}
+
+	subs, err := h.repo.ActiveForEvent(ctx, env.EventType)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(events.ToCloudEvent(env))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	items := make([]models.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		items = append(items, models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        env.EventID,
+			EventType:      env.EventType,
+			Payload:        string(body),
+			Status:         models.WebhookPending,
+			NextAttemptAt:  now,
+		})
+	}
+
+	// повторная доставка события упрётся в unique (subscription_id, event_id)
+	return h.repo.CreateDeliveries(ctx, items)
+}
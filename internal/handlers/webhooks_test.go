@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookFanout_WildcardSkipsInternalEvents(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	h := NewWebhookFanoutHandler(repositories.NewWebhookRepository(tx))
+	ctx := context.Background()
+
+	sub := &models.WebhookSubscription{UserID: 1, URL: "https://example.com/hook", Secret: "s", EventTypes: "*", IsActive: true}
+	require.NoError(t, tx.Create(sub).Error)
+
+	followed, err := events.Schemas.NewEnvelope(events.UserFollowed, "user", "2", "3", events.UserFollowedPayload{
+		FollowerID: "3", FolloweeID: "2",
+	})
+	require.NoError(t, err)
+	created, err := events.Schemas.NewEnvelope(events.PostCreated, "post", "1", "2", events.PostCreatedPayload{
+		PostID: "1", Title: "t", Slug: "t",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, h.Handle(ctx, followed))
+	require.NoError(t, h.Handle(ctx, created))
+
+	var types []string
+	require.NoError(t, tx.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", sub.ID).Pluck("event_type", &types).Error)
+	require.Equal(t, []string{events.PostCreated}, types)
+}
@@ -0,0 +1,103 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// JWK — публичный ключ в формате RFC 7517 (RSA и OKP/Ed25519)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS — все ключи проверки, включая уходящие после ротации
+func (kr *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(kr.keys))}
+	for _, k := range kr.keys {
+		set.Keys = append(set.Keys, k.JWK())
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func (k Key) JWK() JWK {
+	j := JWK{Kid: k.ID, Use: "sig", Alg: k.Alg}
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		j.Kty = "RSA"
+		j.N = b64(pub.N.Bytes())
+		j.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		j.Kty = "OKP"
+		j.Crv = "Ed25519"
+		j.X = b64(pub)
+	}
+	return j
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Key — ключ проверки из JWK (например, из JWKS внешнего провайдера)
+func (j JWK) Key() (Key, error) {
+	switch {
+	case j.Kty == "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return Key{}, fmt.Errorf("key %s: bad n: %w", j.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return Key{}, fmt.Errorf("key %s: bad e: %w", j.Kid, err)
+		}
+		if len(e) == 0 || len(e) > 4 {
+			return Key{}, fmt.Errorf("key %s: bad e", j.Kid)
+		}
+		return NewKey(j.Kid, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())})
+	case j.Kty == "OKP" && j.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return Key{}, fmt.Errorf("key %s: bad x", j.Kid)
+		}
+		return NewKey(j.Kid, ed25519.PublicKey(x))
+	default:
+		return Key{}, fmt.Errorf("key %s: %w %s", j.Kid, ErrUnsupportedKey, j.Kty)
+	}
+}
+
+// VerifyKeyring — связка только для проверки из JWKS; ключи неподдерживаемых типов и
+// чужого назначения (use != sig) пропускаются
+func (set JWKSet) VerifyKeyring() (*Keyring, error) {
+	var keys []Key
+	for _, j := range set.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		k, err := j.Key()
+		if err != nil {
+			continue
+		}
+		// alg в JWK необязателен, но если указан — должен совпасть с типом ключа
+		if j.Alg != "" && j.Alg != k.Alg {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return NewVerifyKeyring(keys...)
+}
@@ -0,0 +1,146 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+
+	minRSAKeyBits = 2048
+)
+
+var (
+	ErrUnknownKey     = errors.New("unknown signing key")
+	ErrNoSigningKey   = errors.New("no signing key")
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	ErrAlgKeyMismatch = errors.New("token alg does not match key")
+)
+
+var signingMethodByAlg = map[string]jwt.SigningMethod{
+	AlgRS256: jwt.SigningMethodRS256,
+	AlgEdDSA: jwt.SigningMethodEdDSA,
+}
+
+// Key — ключ с идентификатором (kid). Private == nil — ключ только для проверки:
+// так остаются старые ключи после ротации, пока не истекут выданные ими токены.
+type Key struct {
+	ID      string
+	Alg     string
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// NewKey определяет алгоритм по типу ключа: RSA -> RS256, Ed25519 -> EdDSA
+func NewKey(id string, k any) (Key, error) {
+	switch v := k.(type) {
+	case *rsa.PrivateKey:
+		if v.N.BitLen() < minRSAKeyBits {
+			return Key{}, fmt.Errorf("key %s: rsa key must be at least %d bits", id, minRSAKeyBits)
+		}
+		return Key{ID: id, Alg: AlgRS256, Private: v, Public: &v.PublicKey}, nil
+	case *rsa.PublicKey:
+		if v.N.BitLen() < minRSAKeyBits {
+			return Key{}, fmt.Errorf("key %s: rsa key must be at least %d bits", id, minRSAKeyBits)
+		}
+		return Key{ID: id, Alg: AlgRS256, Public: v}, nil
+	case ed25519.PrivateKey:
+		return Key{ID: id, Alg: AlgEdDSA, Private: v, Public: v.Public()}, nil
+	case ed25519.PublicKey:
+		return Key{ID: id, Alg: AlgEdDSA, Public: v}, nil
+	default:
+		return Key{}, fmt.Errorf("key %s: %w %T", id, ErrUnsupportedKey, k)
+	}
+}
+
+// Keyring — один ключ подписи и несколько ключей проверки
+type Keyring struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewKeyring: signingID — kid ключа подписи, у него должен быть приватный ключ
+func NewKeyring(signingID string, keys ...Key) (*Keyring, error) {
+	kr, err := NewVerifyKeyring(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	signing, ok := kr.keys[signingID]
+	if !ok || signing.Private == nil {
+		return nil, fmt.Errorf("%w: %q", ErrNoSigningKey, signingID)
+	}
+	kr.signing = signing
+	return kr, nil
+}
+
+// NewVerifyKeyring — связка без ключа подписи: только Parse
+func NewVerifyKeyring(keys ...Key) (*Keyring, error) {
+	kr := &Keyring{keys: make(map[string]*Key, len(keys))}
+	for i := range keys {
+		k := keys[i]
+		if k.ID == "" {
+			return nil, errors.New("key without id")
+		}
+		if _, dup := kr.keys[k.ID]; dup {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		kr.keys[k.ID] = &k
+	}
+	return kr, nil
+}
+
+// SigningKeyID — kid, которым подписываются новые токены
+func (kr *Keyring) SigningKeyID() string {
+	if kr.signing == nil {
+		return ""
+	}
+	return kr.signing.ID
+}
+
+func (kr *Keyring) Sign(claims jwt.Claims) (string, error) {
+	if kr.signing == nil {
+		return "", ErrNoSigningKey
+	}
+	t := jwt.NewWithClaims(signingMethodByAlg[kr.signing.Alg], claims)
+	t.Header["kid"] = kr.signing.ID
+	return t.SignedString(kr.signing.Private)
+}
+
+// Parse принимает только алгоритмы ключей связки; алгоритм токена обязан совпасть с алгоритмом ключа из kid,
+// поэтому подмена на HS256 с публичным ключом в роли секрета или alg=none не проходит
+func (kr *Keyring) Parse(tokenStr string, claims jwt.Claims, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	opts = append([]jwt.ParserOption{jwt.WithValidMethods(kr.algs())}, opts...)
+	return jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		k, ok := kr.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if t.Method.Alg() != k.Alg {
+			return nil, ErrAlgKeyMismatch
+		}
+		return k.Public, nil
+	}, opts...)
+}
+
+func (kr *Keyring) algs() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, k := range kr.keys {
+		if !seen[k.Alg] {
+			seen[k.Alg] = true
+			out = append(out, k.Alg)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
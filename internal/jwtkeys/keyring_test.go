@@ -0,0 +1,191 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaKey(t *testing.T, id string) Key {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	k, err := NewKey(id, priv)
+	require.NoError(t, err)
+	return k
+}
+
+func edKey(t *testing.T, id string) Key {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	k, err := NewKey(id, priv)
+	require.NoError(t, err)
+	return k
+}
+
+func publicOnly(k Key) Key {
+	k.Private = nil
+	return k
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestKeyring_SignAndParse(t *testing.T) {
+	for _, k := range []Key{rsaKey(t, "rsa-1"), edKey(t, "ed-1")} {
+		kr, err := NewKeyring(k.ID, k)
+		require.NoError(t, err)
+
+		token, err := kr.Sign(testClaims())
+		require.NoError(t, err)
+
+		claims := jwt.MapClaims{}
+		parsed, err := kr.Parse(token, claims)
+		require.NoError(t, err, k.Alg)
+		require.Equal(t, k.ID, parsed.Header["kid"])
+		require.Equal(t, k.Alg, parsed.Method.Alg())
+		require.Equal(t, "1", claims["sub"])
+	}
+}
+
+func TestKeyring_RotationKeepsOldTokensValid(t *testing.T) {
+	oldKey, newKey := rsaKey(t, "2025-01"), edKey(t, "2025-02")
+
+	before, err := NewKeyring(oldKey.ID, oldKey)
+	require.NoError(t, err)
+	oldToken, err := before.Sign(testClaims())
+	require.NoError(t, err)
+
+	// новый ключ подписывает, старый остаётся только для проверки
+	after, err := NewKeyring(newKey.ID, newKey, publicOnly(oldKey))
+	require.NoError(t, err)
+	newToken, err := after.Sign(testClaims())
+	require.NoError(t, err)
+
+	_, err = after.Parse(oldToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	parsed, err := after.Parse(newToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	require.Equal(t, newKey.ID, parsed.Header["kid"])
+
+	// публичный ключ не может подписывать
+	_, err = NewKeyring(oldKey.ID, newKey, publicOnly(oldKey))
+	require.ErrorIs(t, err, ErrNoSigningKey)
+}
+
+func TestKeyring_StrictAlgorithm(t *testing.T) {
+	rk, ek := rsaKey(t, "rsa"), edKey(t, "ed")
+	kr, err := NewKeyring(rk.ID, rk, ek)
+	require.NoError(t, err)
+
+	// HS256 с публичным ключом в роли секрета
+	pubDER, err := x509.MarshalPKIXPublicKey(rk.Public)
+	require.NoError(t, err)
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	hs.Header["kid"] = rk.ID
+	forged, err := hs.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	require.NoError(t, err)
+	_, err = kr.Parse(forged, jwt.MapClaims{})
+	require.Error(t, err)
+
+	// alg=none
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims())
+	none.Header["kid"] = rk.ID
+	unsigned, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = kr.Parse(unsigned, jwt.MapClaims{})
+	require.Error(t, err)
+
+	// EdDSA-токен, выдающий себя за RSA-ключ
+	ed := jwt.NewWithClaims(jwt.SigningMethodEdDSA, testClaims())
+	ed.Header["kid"] = rk.ID
+	mismatched, err := ed.SignedString(ek.Private)
+	require.NoError(t, err)
+	_, err = kr.Parse(mismatched, jwt.MapClaims{})
+	require.ErrorIs(t, err, ErrAlgKeyMismatch)
+
+	// неизвестный kid
+	other := edKey(t, "other")
+	otherKr, err := NewKeyring(other.ID, other)
+	require.NoError(t, err)
+	foreign, err := otherKr.Sign(testClaims())
+	require.NoError(t, err)
+	_, err = kr.Parse(foreign, jwt.MapClaims{})
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestNewKey_RejectsShortRSA(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = NewKey("weak", priv)
+	require.Error(t, err)
+}
+
+func TestLoadDir_AndJWKS(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edPriv)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "current.pem"), "PRIVATE KEY", der)
+
+	rk := rsaKey(t, "previous")
+	der, err = x509.MarshalPKIXPublicKey(rk.Public)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "previous.pem"), "PUBLIC KEY", der)
+
+	kr, err := LoadDir(dir, "")
+	require.NoError(t, err)
+	require.Equal(t, "current", kr.SigningKeyID())
+
+	set := kr.JWKS()
+	require.Len(t, set.Keys, 2)
+	require.Equal(t, JWK{Kty: "OKP", Kid: "current", Use: "sig", Alg: AlgEdDSA, Crv: "Ed25519", X: b64(edPriv.Public().(ed25519.PublicKey))}, set.Keys[0])
+	require.Equal(t, "RSA", set.Keys[1].Kty)
+	require.Equal(t, "previous", set.Keys[1].Kid)
+	require.Equal(t, AlgRS256, set.Keys[1].Alg)
+	require.Equal(t, "AQAB", set.Keys[1].E)
+
+	_, err = LoadDir(dir, "previous")
+	require.ErrorIs(t, err, ErrNoSigningKey)
+	_, err = LoadDir(t.TempDir(), "")
+	require.Error(t, err)
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600))
+}
+
+func TestJWKSet_VerifyKeyring(t *testing.T) {
+	rk, ek := rsaKey(t, "rsa"), edKey(t, "ed")
+	kr, err := NewKeyring("rsa", rk, ek)
+	require.NoError(t, err)
+	token, err := kr.Sign(testClaims())
+	require.NoError(t, err)
+
+	set := kr.JWKS()
+	// чужие и неподдерживаемые ключи не мешают
+	set.Keys = append(set.Keys, JWK{Kty: "EC", Kid: "ec", Crv: "P-256"}, JWK{Kty: "RSA", Kid: "enc", Use: "enc", N: set.Keys[1].N, E: "AQAB"})
+
+	verify, err := set.VerifyKeyring()
+	require.NoError(t, err)
+	_, err = verify.Parse(token, jwt.MapClaims{})
+	require.NoError(t, err)
+
+	_, err = verify.Sign(testClaims())
+	require.ErrorIs(t, err, ErrNoSigningKey)
+	require.Empty(t, verify.SigningKeyID())
+}
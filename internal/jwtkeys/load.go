@@ -0,0 +1,96 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ParsePEM читает PKCS#8/PKCS#1 приватный или PKIX публичный ключ
+func ParsePEM(id string, data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("key %s: no PEM block", id)
+	}
+
+	var (
+		k   any
+		err error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		k, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		k, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		k, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("key %s: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("key %s: %w", id, err)
+	}
+	return NewKey(id, k)
+}
+
+// LoadDir: каждый <kid>.pem в dir — ключ связки. Пустой signingID допустим,
+// если приватный ключ в каталоге ровно один.
+//
+// Ротация: положить новый приватный ключ, переключить signingID,
+// старый заменить публичной частью и удалить после истечения access-токенов.
+func LoadDir(dir, signingID string) (*Keyring, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	keys := make([]Key, 0, len(paths))
+	var private []string
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		k, err := ParsePEM(strings.TrimSuffix(filepath.Base(p), ".pem"), data)
+		if err != nil {
+			return nil, err
+		}
+		if k.Private != nil {
+			private = append(private, k.ID)
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no *.pem keys in %s", dir)
+	}
+
+	if signingID == "" {
+		if len(private) != 1 {
+			return nil, errors.New("several private keys: signing key id is required")
+		}
+		signingID = private[0]
+	}
+	return NewKeyring(signingID, keys...)
+}
+
+// Ephemeral — случайный Ed25519 на время жизни процесса (dev и тесты):
+// токены не переживают рестарт и не проверяются другими инстансами
+func Ephemeral() (*Keyring, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	k, err := NewKey("ephemeral", priv)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyring(k.ID, k)
+}
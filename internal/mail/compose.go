@@ -0,0 +1,93 @@
+package mail
+
+import (
+	"encoding/json"
+	"errors"
+	"go_blog/internal/ports"
+	"go_blog/models"
+	"net/url"
+)
+
+type Recipient struct {
+	UserID uint
+	Email  string
+	Name   string
+}
+
+// Composer рендерит письмо и добавляет подписанную ссылку отписки
+type Composer struct {
+	renderer    *Renderer
+	signer      *Signer
+	baseURL     string
+	frontendURL string
+}
+
+var ErrNoSigningSecret = errors.New("MAIL_SIGNING_SECRET is not set")
+
+func NewComposer(cfg Config) (*Composer, error) {
+	// без секрета ссылку отписки подделает кто угодно
+	if cfg.Secret == "" {
+		return nil, ErrNoSigningSecret
+	}
+	r, err := NewRenderer()
+	if err != nil {
+		return nil, err
+	}
+	frontend := cfg.FrontendURL
+	if frontend == "" {
+		frontend = cfg.BaseURL
+	}
+	return &Composer{renderer: r, signer: NewSigner(cfg.Secret), baseURL: cfg.BaseURL, frontendURL: frontend}, nil
+}
+
+func (c *Composer) UnsubscribeURL(userID uint, category string) string {
+	return c.baseURL + "/unsubscribe?token=" + url.QueryEscape(c.signer.Token(userID, category))
+}
+
+// Link — абсолютная ссылка на страницу фронта
+func (c *Composer) Link(path string) string {
+	return c.frontendURL + path
+}
+
+// Compose: category == "" — служебное письмо без отписки
+func (c *Composer) Compose(tmpl, category string, to Recipient, data any) (ports.Mail, error) {
+	v := View{Recipient: to.Name, FrontendURL: c.frontendURL, Data: data}
+	headers := map[string]string{}
+	if category != "" {
+		v.UnsubscribeURL = c.UnsubscribeURL(to.UserID, category)
+		// RFC 8058: почтовый клиент сам шлёт POST на ссылку
+		headers["List-Unsubscribe"] = "<" + v.UnsubscribeURL + ">"
+		headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+	}
+
+	subject, text, html, err := c.renderer.Render(tmpl, v)
+	if err != nil {
+		return ports.Mail{}, err
+	}
+	return ports.Mail{To: to.Email, Subject: subject, Text: text, HTML: html, Headers: headers}, nil
+}
+
+// ToOutbox — строка очереди для отрендеренного письма
+func ToOutbox(m ports.Mail, userID uint, tmpl, dedupKey string) *models.MailOutbox {
+	headers, _ := json.Marshal(m.Headers)
+	row := &models.MailOutbox{
+		DedupKey: dedupKey,
+		Template: tmpl,
+		To:       m.To,
+		Subject:  m.Subject,
+		TextBody: m.Text,
+		HTMLBody: m.HTML,
+		Headers:  string(headers),
+	}
+	if userID != 0 {
+		row.UserID = &userID
+	}
+	return row
+}
+
+// FromOutbox — обратно в письмо для Mailer
+func FromOutbox(row models.MailOutbox) ports.Mail {
+	var headers map[string]string
+	_ = json.Unmarshal([]byte(row.Headers), &headers)
+	return ports.Mail{To: row.To, Subject: row.Subject, Text: row.TextBody, HTML: row.HTMLBody, Headers: headers}
+}
@@ -0,0 +1,78 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"go_blog/internal/repositories"
+	"time"
+)
+
+const (
+	DigestPeriod   = 7 * 24 * time.Hour
+	digestMaxPosts = 20
+)
+
+type DigestPost struct {
+	Title  string
+	Slug   string
+	Author string
+}
+
+type DigestData struct {
+	Posts []DigestPost
+}
+
+// Digest ставит в очередь еженедельный дайджест. Запускается по расписанию (mailer -digest);
+// повторный запуск в ту же неделю ничего не дублирует — DedupKey по ISO-неделе.
+type Digest struct {
+	follows  *repositories.FollowRepository
+	outbox   *repositories.MailOutboxRepository
+	composer *Composer
+}
+
+func NewDigest(follows *repositories.FollowRepository, outbox *repositories.MailOutboxRepository, composer *Composer) *Digest {
+	return &Digest{follows: follows, outbox: outbox, composer: composer}
+}
+
+// Run — дайджест за неделю до now; возвращает число поставленных писем
+func (d *Digest) Run(ctx context.Context, now time.Time) (int, error) {
+	now = now.UTC()
+	rows, err := d.follows.DigestRows(ctx, now.Add(-DigestPeriod), now, CategoryDigest)
+	if err != nil {
+		return 0, err
+	}
+
+	year, week := now.ISOWeek()
+	queued := 0
+
+	for i := 0; i < len(rows); {
+		j := i
+		for j < len(rows) && rows[j].RecipientID == rows[i].RecipientID {
+			j++
+		}
+
+		batch := rows[i:j]
+		if len(batch) > digestMaxPosts {
+			batch = batch[:digestMaxPosts]
+		}
+		data := DigestData{Posts: make([]DigestPost, 0, len(batch))}
+		for _, r := range batch {
+			data.Posts = append(data.Posts, DigestPost{Title: r.Title, Slug: r.Slug, Author: r.AuthorNickname})
+		}
+
+		r := rows[i]
+		m, err := d.composer.Compose(TemplateWeeklyDigest, CategoryDigest,
+			Recipient{UserID: r.RecipientID, Email: r.RecipientEmail, Name: r.RecipientNickname}, data)
+		if err != nil {
+			return queued, err
+		}
+		key := fmt.Sprintf("digest:%d:%d-W%02d", r.RecipientID, year, week)
+		if err := d.outbox.Enqueue(ctx, ToOutbox(m, r.RecipientID, TemplateWeeklyDigest, key)); err != nil {
+			return queued, err
+		}
+		queued++
+		i = j
+	}
+
+	return queued, nil
+}
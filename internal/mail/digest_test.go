@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"context"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigest_QueuesOncePerWeek_RespectsOptOut(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	author := &models.User{Nickname: "author", Email: "author@test.com", Password: "x", IsActive: true, EmailVerifiedAt: &now}
+	reader := &models.User{Nickname: "reader", Email: "reader@test.com", Password: "x", IsActive: true, EmailVerifiedAt: &now}
+	optedOut := &models.User{Nickname: "quiet", Email: "quiet@test.com", Password: "x", IsActive: true, EmailVerifiedAt: &now}
+	unverified := &models.User{Nickname: "fresh", Email: "fresh@test.com", Password: "x", IsActive: true}
+	require.NoError(t, tx.Create(author).Error)
+	require.NoError(t, tx.Create(reader).Error)
+	require.NoError(t, tx.Create(optedOut).Error)
+	require.NoError(t, tx.Create(unverified).Error)
+
+	require.NoError(t, tx.Create(&models.Follow{FollowerID: reader.ID, FolloweeID: author.ID}).Error)
+	require.NoError(t, tx.Create(&models.Follow{FollowerID: optedOut.ID, FolloweeID: author.ID}).Error)
+	require.NoError(t, tx.Create(&models.Follow{FollowerID: unverified.ID, FolloweeID: author.ID}).Error)
+	require.NoError(t, tx.Create(&models.NotificationPreference{UserID: optedOut.ID, Type: CategoryDigest, Enabled: false}).Error)
+
+	require.NoError(t, tx.Create(&models.Post{Title: "Fresh", Slug: "fresh", UserID: author.ID, IsActive: true}).Error)
+	old := &models.Post{Title: "Old", Slug: "old", UserID: author.ID, IsActive: true}
+	require.NoError(t, tx.Create(old).Error)
+	require.NoError(t, tx.Model(old).Update("created_at", now.Add(-2*DigestPeriod)).Error)
+
+	composer, err := NewComposer(Config{BaseURL: "http://blog.test", Secret: "s"})
+	require.NoError(t, err)
+	d := NewDigest(repositories.NewFollowRepository(tx), repositories.NewMailOutboxRepository(tx), composer)
+
+	n, err := d.Run(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	// повторный запуск в ту же неделю — без дублей
+	_, err = d.Run(ctx, now.Add(2*time.Second))
+	require.NoError(t, err)
+
+	var rows []models.MailOutbox
+	require.NoError(t, tx.Find(&rows).Error)
+	require.Len(t, rows, 1)
+	require.Equal(t, "reader@test.com", rows[0].To)
+	require.Contains(t, rows[0].TextBody, "Fresh")
+	require.NotContains(t, rows[0].TextBody, "Old")
+}
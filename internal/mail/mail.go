@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Категории писем — отписка работает по категории. Хранятся в notification_preferences
+// рядом с типами уведомлений: нет записи — категория включена.
+const (
+	CategoryActivity = "email.activity" // комментарии, ответы, новые подписчики
+	CategoryDigest   = "email.digest"   // еженедельный дайджест
+)
+
+var Categories = []string{CategoryActivity, CategoryDigest}
+
+type Config struct {
+	Driver      string // smtp | file
+	From        string
+	BaseURL     string // API: ссылка отписки
+	FrontendURL string // страницы фронта в письмах; по умолчанию BaseURL
+	Secret      string // подпись ссылок отписки, MAIL_SIGNING_SECRET; общий с JWT_SECRET не берётся
+	FileDir     string
+	SMTPHost    string
+	SMTPPort    int
+	SMTPUser    string
+	SMTPPass    string
+}
+
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Driver:   getenv("MAIL_DRIVER", "file"),
+		From:     getenv("MAIL_FROM", "Go Blog <no-reply@localhost>"),
+		BaseURL:  strings.TrimRight(getenv("APP_BASE_URL", "http://localhost:8080"), "/"),
+		Secret:   os.Getenv("MAIL_SIGNING_SECRET"),
+		FileDir:  getenv("MAIL_FILE_DIR", "tmp/mail"),
+		SMTPHost: os.Getenv("SMTP_HOST"),
+		SMTPUser: os.Getenv("SMTP_USERNAME"),
+		SMTPPass: os.Getenv("SMTP_PASSWORD"),
+	}
+	cfg.FrontendURL = strings.TrimRight(getenv("APP_FRONTEND_URL", cfg.BaseURL), "/")
+	cfg.SMTPPort, _ = strconv.Atoi(os.Getenv("SMTP_PORT"))
+	return cfg
+}
+
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
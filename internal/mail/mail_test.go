@@ -0,0 +1,193 @@
+package mail
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"go_blog/internal/adapters/mailer/memory"
+	"go_blog/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_RoundTrip(t *testing.T) {
+	s := NewSigner("secret")
+
+	uid, category, err := s.Verify(s.Token(42, CategoryDigest))
+	require.NoError(t, err)
+	require.Equal(t, uint(42), uid)
+	require.Equal(t, CategoryDigest, category)
+}
+
+func TestSigner_RejectsTamperedAndForeign(t *testing.T) {
+	s := NewSigner("secret")
+	token := s.Token(42, CategoryDigest)
+
+	payload, sig, _ := strings.Cut(token, ".")
+	forged := NewSigner("secret").Token(43, CategoryDigest)
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	for _, bad := range []string{
+		"",
+		payload,
+		forgedPayload + "." + sig,
+		NewSigner("other").Token(42, CategoryDigest),
+		s.Token(42, "email.unknown"),
+	} {
+		_, _, err := s.Verify(bad)
+		require.ErrorIs(t, err, ErrInvalidUnsubscribeToken, bad)
+	}
+}
+
+func TestSigner_ExpiresAndRejectsLegacyFormat(t *testing.T) {
+	s := NewSigner("secret")
+	issued := time.Now()
+	s.now = func() time.Time { return issued }
+	token := s.Token(42, CategoryDigest)
+
+	s.now = func() time.Time { return issued.Add(UnsubscribeTokenTTL - time.Minute) }
+	_, _, err := s.Verify(token)
+	require.NoError(t, err)
+
+	s.now = func() time.Time { return issued.Add(UnsubscribeTokenTTL + time.Minute) }
+	_, _, err = s.Verify(token)
+	require.ErrorIs(t, err, ErrInvalidUnsubscribeToken)
+
+	// подпись верная, но payload без версии и времени выпуска
+	legacy := "42:" + CategoryDigest
+	enc := base64.RawURLEncoding
+	_, _, err = s.Verify(enc.EncodeToString([]byte(legacy)) + "." + enc.EncodeToString(s.sign(legacy)))
+	require.ErrorIs(t, err, ErrInvalidUnsubscribeToken)
+}
+
+func TestNewComposer_RequiresSigningSecret(t *testing.T) {
+	_, err := NewComposer(Config{BaseURL: "https://blog.test"})
+	require.ErrorIs(t, err, ErrNoSigningSecret)
+}
+
+func TestComposer_RendersBothPartsWithUnsubscribe(t *testing.T) {
+	c, err := NewComposer(Config{BaseURL: "https://blog.test", Secret: "secret"})
+	require.NoError(t, err)
+
+	m, err := c.Compose(TemplateCommentCreated, CategoryActivity,
+		Recipient{UserID: 7, Email: "bob@test.com", Name: "bob"},
+		struct{ Actor, PostTitle, PostSlug, Text string }{"alice", "Hello\nworld", "hello", "<script>x</script>"})
+	require.NoError(t, err)
+
+	require.Equal(t, "bob@test.com", m.To)
+	require.Equal(t, `alice commented on "Hello world"`, m.Subject)
+	require.Contains(t, m.Text, "https://blog.test/posts/hello")
+	require.Contains(t, m.Text, "Unsubscribe: https://blog.test/unsubscribe?token=")
+	require.Contains(t, m.HTML, "&lt;script&gt;")
+	require.NotContains(t, m.HTML, "<script>")
+
+	unsub := strings.Trim(m.Headers["List-Unsubscribe"], "<>")
+	require.True(t, strings.HasPrefix(unsub, "https://blog.test/unsubscribe?token="))
+	require.Equal(t, "List-Unsubscribe=One-Click", m.Headers["List-Unsubscribe-Post"])
+}
+
+func TestComposer_Digest(t *testing.T) {
+	c, err := NewComposer(Config{BaseURL: "https://blog.test", Secret: "secret"})
+	require.NoError(t, err)
+
+	m, err := c.Compose(TemplateWeeklyDigest, CategoryDigest, Recipient{UserID: 1, Email: "a@test.com", Name: "a"},
+		DigestData{Posts: []DigestPost{{Title: "One", Slug: "one", Author: "x"}, {Title: "Two", Slug: "two", Author: "y"}}})
+	require.NoError(t, err)
+	require.Equal(t, "Your weekly digest: 2 new posts", m.Subject)
+	require.Contains(t, m.HTML, `href="https://blog.test/posts/two"`)
+}
+
+func TestComposer_PageLinksUseFrontendURL(t *testing.T) {
+	c, err := NewComposer(Config{BaseURL: "https://api.blog.test", FrontendURL: "https://blog.test", Secret: "secret"})
+	require.NoError(t, err)
+
+	m, err := c.Compose(TemplateCommentCreated, CategoryActivity,
+		Recipient{UserID: 7, Email: "bob@test.com", Name: "bob"},
+		struct{ Actor, PostTitle, PostSlug, Text string }{"alice", "Hello", "hello", "hi"})
+	require.NoError(t, err)
+	require.Contains(t, m.Text, "https://blog.test/posts/hello")
+	// отписка — маршрут API
+	require.Contains(t, m.Text, "Unsubscribe: https://api.blog.test/unsubscribe?token=")
+
+	q := NewQueue(c, nil)
+	for _, path := range []string{"/verify-email?token=t", "/reset-password?token=t", "/confirm-email-change?token=t", "/forgot-password"} {
+		require.Equal(t, "https://blog.test"+path, q.Link(path))
+	}
+}
+
+func TestConfigFromEnv_FrontendURLDefaultsToBaseURL(t *testing.T) {
+	t.Setenv("APP_BASE_URL", "https://api.blog.test/")
+	t.Setenv("APP_FRONTEND_URL", "")
+	cfg := ConfigFromEnv()
+	require.Equal(t, "https://api.blog.test", cfg.BaseURL)
+	require.Equal(t, "https://api.blog.test", cfg.FrontendURL)
+
+	t.Setenv("APP_FRONTEND_URL", "https://blog.test/")
+	require.Equal(t, "https://blog.test", ConfigFromEnv().FrontendURL)
+}
+
+type fakeStore struct {
+	items  []models.MailOutbox
+	sent   map[uint]int
+	failed map[uint]*time.Time
+}
+
+func (f *fakeStore) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.MailOutbox, error) {
+	out := f.items
+	f.items = nil
+	return out, nil
+}
+
+func (f *fakeStore) MarkSent(ctx context.Context, id uint, attempts int) error {
+	f.sent[id] = attempts
+	return nil
+}
+
+func (f *fakeStore) MarkFailed(ctx context.Context, id uint, attempts int, lastErr string, next *time.Time) error {
+	f.failed[id] = next
+	return nil
+}
+
+func newFakeStore(items ...models.MailOutbox) *fakeStore {
+	return &fakeStore{items: items, sent: map[uint]int{}, failed: map[uint]*time.Time{}}
+}
+
+func TestWorker_SendsAndMarksSent(t *testing.T) {
+	store := newFakeStore(models.MailOutbox{ID: 1, To: "a@test.com", Subject: "s", TextBody: "t", Headers: `{"X-A":"1"}`})
+	mailer := memory.New()
+
+	n, err := NewWorker(store, mailer, DefaultWorkerConfig()).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, 1, store.sent[1])
+
+	sent := mailer.Messages()
+	require.Len(t, sent, 1)
+	require.Equal(t, "1", sent[0].Headers["X-A"])
+}
+
+func TestWorker_FailureSchedulesRetryThenGivesUp(t *testing.T) {
+	mailer := memory.New()
+	mailer.Err = errors.New("smtp down")
+	cfg := DefaultWorkerConfig()
+
+	store := newFakeStore(models.MailOutbox{ID: 1, Attempts: 1})
+	w := NewWorker(store, mailer, cfg)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	_, err := w.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, store.failed[1])
+	require.Equal(t, now.Add(2*cfg.BaseBackoff), *store.failed[1])
+
+	store.items = []models.MailOutbox{{ID: 2, Attempts: cfg.MaxAttempts - 1}}
+	_, err = w.RunOnce(context.Background())
+	require.NoError(t, err)
+	next, ok := store.failed[2]
+	require.True(t, ok)
+	require.Nil(t, next)
+}
@@ -0,0 +1,29 @@
+package mail
+
+import (
+	"context"
+	"go_blog/internal/repositories"
+)
+
+// Queue — постановка письма в mail_outbox из сервисов (одна вставка в БД, без SMTP)
+type Queue struct {
+	composer *Composer
+	outbox   *repositories.MailOutboxRepository
+}
+
+func NewQueue(composer *Composer, outbox *repositories.MailOutboxRepository) *Queue {
+	return &Queue{composer: composer, outbox: outbox}
+}
+
+func (q *Queue) Enqueue(ctx context.Context, tmpl, category string, to Recipient, data any, dedupKey string) error {
+	m, err := q.composer.Compose(tmpl, category, to, data)
+	if err != nil {
+		return err
+	}
+	return q.outbox.Enqueue(ctx, ToOutbox(m, to.UserID, tmpl, dedupKey))
+}
+
+// Link — абсолютная ссылка на страницу фронта (APP_FRONTEND_URL)
+func (q *Queue) Link(path string) string {
+	return q.composer.Link(path)
+}
@@ -0,0 +1,92 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+// Шаблоны: <name>.txt.tmpl определяет subject и text, <name>.html.tmpl — content для layout
+const (
+	TemplateCommentCreated = "comment_created"
+	TemplateCommentReply   = "comment_reply"
+	TemplateNewFollower    = "new_follower"
+	TemplateWeeklyDigest   = "weekly_digest"
+	TemplateVerifyEmail    = "verify_email"
+	TemplatePasswordReset  = "password_reset"
+	TemplateEmailChange    = "email_change"
+	TemplateAccountLocked  = "account_locked"
+)
+
+var templateNames = []string{
+	TemplateCommentCreated, TemplateCommentReply, TemplateNewFollower, TemplateWeeklyDigest,
+	TemplateVerifyEmail, TemplatePasswordReset, TemplateEmailChange, TemplateAccountLocked,
+}
+
+// View — то, что видит шаблон; данные конкретного письма в Data
+type View struct {
+	Recipient      string
+	FrontendURL    string
+	UnsubscribeURL string
+	Subject        string
+	Data           any
+}
+
+type Renderer struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{
+		text: make(map[string]*texttemplate.Template, len(templateNames)),
+		html: make(map[string]*htmltemplate.Template, len(templateNames)),
+	}
+	for _, name := range templateNames {
+		t, err := texttemplate.New(name).Option("missingkey=error").
+			ParseFS(templatesFS, "templates/footer.txt.tmpl", "templates/"+name+".txt.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		h, err := htmltemplate.New(name).Option("missingkey=error").
+			ParseFS(templatesFS, "templates/layout.html.tmpl", "templates/"+name+".html.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		r.text[name], r.html[name] = t, h
+	}
+	return r, nil
+}
+
+func (r *Renderer) Render(name string, v View) (subject, text, html string, err error) {
+	t, ok := r.text[name]
+	if !ok {
+		return "", "", "", fmt.Errorf("mail: unknown template %q", name)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "subject", v); err != nil {
+		return "", "", "", err
+	}
+	// перевод строки в теме — инъекция заголовков
+	subject = strings.Join(strings.Fields(buf.String()), " ")
+	v.Subject = subject
+
+	buf.Reset()
+	if err := t.ExecuteTemplate(&buf, "text", v); err != nil {
+		return "", "", "", err
+	}
+	text = buf.String()
+
+	buf.Reset()
+	if err := r.html[name].ExecuteTemplate(&buf, "layout", v); err != nil {
+		return "", "", "", err
+	}
+	return subject, text, buf.String(), nil
+}
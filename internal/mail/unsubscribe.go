@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+// UnsubscribeTokenTTL — ссылка из письма работает три месяца; дальше отписка только из настроек
+const UnsubscribeTokenTTL = 90 * 24 * time.Hour
+
+// unsubscribeTokenVersion меняется вместе с форматом payload: токены старого формата не проходят
+const unsubscribeTokenVersion = "1"
+
+// Signer подписывает ссылки отписки:
+// base64url("<version>:<uid>:<category>:<issued_at>").base64url(hmac)
+type Signer struct {
+	secret []byte
+	now    func() time.Time
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret), now: time.Now}
+}
+
+func (s *Signer) Token(userID uint, category string) string {
+	payload := strings.Join([]string{
+		unsubscribeTokenVersion,
+		strconv.FormatUint(uint64(userID), 10),
+		category,
+		strconv.FormatInt(s.now().Unix(), 10),
+	}, ":")
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(payload)) + "." + enc.EncodeToString(s.sign(payload))
+}
+
+func (s *Signer) Verify(token string) (uint, string, error) {
+	enc := base64.RawURLEncoding
+
+	p, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	payload, err := enc.DecodeString(p)
+	if err != nil {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	mac, err := enc.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, s.sign(string(payload))) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	parts := strings.Split(string(payload), ":")
+	if len(parts) != 4 || parts[0] != unsubscribeTokenVersion || !slices.Contains(Categories, parts[2]) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	uid, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || uid == 0 {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	iat, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || s.now().Sub(time.Unix(iat, 0)) > UnsubscribeTokenTTL {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	return uint(uid), parts[2], nil
+}
+
+func (s *Signer) sign(payload string) []byte {
+	m := hmac.New(sha256.New, s.secret)
+	m.Write([]byte("unsubscribe:" + payload))
+	return m.Sum(nil)
+}
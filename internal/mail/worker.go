@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"context"
+	"go_blog/internal/ports"
+	"go_blog/models"
+	"log"
+	"time"
+)
+
+type Store interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.MailOutbox, error)
+	MarkSent(ctx context.Context, id uint, attempts int) error
+	MarkFailed(ctx context.Context, id uint, attempts int, lastErr string, nextAttemptAt *time.Time) error
+}
+
+type WorkerConfig struct {
+	BatchSize   int
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Lease       time.Duration
+	SendTimeout time.Duration
+}
+
+func DefaultWorkerConfig() WorkerConfig {
+	return WorkerConfig{
+		BatchSize:   20,
+		MaxAttempts: 6,
+		BaseBackoff: time.Minute,
+		MaxBackoff:  2 * time.Hour,
+		Lease:       2 * time.Minute,
+		SendTimeout: 30 * time.Second,
+	}
+}
+
+// Worker отправляет письма из mail_outbox; HTTP-хендлеры почту не ждут никогда
+type Worker struct {
+	store  Store
+	mailer ports.Mailer
+	cfg    WorkerConfig
+	now    func() time.Time
+}
+
+func NewWorker(store Store, mailer ports.Mailer, cfg WorkerConfig) *Worker {
+	return &Worker{store: store, mailer: mailer, cfg: cfg, now: time.Now}
+}
+
+// RunOnce отправляет одну пачку; возвращает, сколько писем было взято
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	items, err := w.store.ClaimDue(ctx, w.cfg.BatchSize, w.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range items {
+		if err := w.send(ctx, &items[i]); err != nil {
+			log.Printf("mail %d: %v", items[i].ID, err)
+		}
+	}
+	return len(items), nil
+}
+
+func (w *Worker) send(ctx context.Context, item *models.MailOutbox) error {
+	attempt := item.Attempts + 1
+
+	sendCtx, cancel := context.WithTimeout(ctx, w.cfg.SendTimeout)
+	err := w.mailer.Send(sendCtx, FromOutbox(*item))
+	cancel()
+
+	if err == nil {
+		return w.store.MarkSent(ctx, item.ID, attempt)
+	}
+
+	var next *time.Time
+	if attempt < w.cfg.MaxAttempts {
+		t := w.now().UTC().Add(w.backoff(attempt))
+		next = &t
+	}
+	if err := w.store.MarkFailed(ctx, item.ID, attempt, err.Error(), next); err != nil {
+		return err
+	}
+	return err
+}
+
+// backoff: base * 2^(attempt-1), не больше MaxBackoff
+func (w *Worker) backoff(attempt int) time.Duration {
+	b := w.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		b *= 2
+		if b >= w.cfg.MaxBackoff {
+			return w.cfg.MaxBackoff
+		}
+	}
+	return b
+}
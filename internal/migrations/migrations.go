@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"go_blog/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Migration — разовый шаг после AutoMigrate: перенос данных, удаление колонок.
+// ID не меняется после выкладки — по нему шаг помечается применённым.
+type Migration struct {
+	ID string
+	Up func(tx *gorm.DB) error
+}
+
+// All — по порядку применения; новые шаги только в конец
+var All = []Migration{
+	{
+		// подтверждение email появилось позже регистрации: кто уже зарегистрирован,
+		// считается подтверждённым с даты регистрации, иначе verifiedGate закроет им посты
+		ID: "0001_backfill_email_verified_at",
+		Up: func(tx *gorm.DB) error {
+			return tx.Model(&models.User{}).
+				Where("email_verified_at IS NULL AND created_at < ?", time.Now().UTC()).
+				Update("email_verified_at", gorm.Expr("created_at")).Error
+		},
+	},
+	{
+		// тела ответов подписчиков больше не храним (SSRF): убираем и накопленные
+		ID: "0002_drop_webhook_attempts_response_body",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.WebhookAttempt{}, "response_body") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&models.WebhookAttempt{}, "response_body")
+		},
+	},
+}
+
+// Run применяет ещё не применённые шаги, каждый в своей транзакции вместе с отметкой.
+// Несколько инстансов на старте не повторят шаг: второй ждёт вставку отметки первым и пропускает его.
+func Run(ctx context.Context, db *gorm.DB, steps []Migration) error {
+	if err := db.WithContext(ctx).AutoMigrate(&models.SchemaMigration{}); err != nil {
+		return err
+	}
+
+	for _, m := range steps {
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.SchemaMigration{ID: m.ID, AppliedAt: time.Now().UTC()})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return nil
+			}
+			log.Printf("migrations: applying %s", m.ID)
+			return m.Up(tx)
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+	}
+	return nil
+}
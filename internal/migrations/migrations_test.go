@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"context"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_BackfillsEmailVerifiedOnce(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	ctx := context.Background()
+
+	registered := time.Now().UTC().Add(-24 * time.Hour).Truncate(time.Second)
+	old := &models.User{Nickname: "old", Email: "old@test.com", Password: "x", IsActive: true}
+	require.NoError(t, tx.Create(old).Error)
+	require.NoError(t, tx.Model(old).Update("created_at", registered).Error)
+
+	require.NoError(t, Run(ctx, tx, All))
+
+	var got models.User
+	require.NoError(t, tx.First(&got, old.ID).Error)
+	require.NotNil(t, got.EmailVerifiedAt)
+	require.True(t, registered.Equal(got.EmailVerifiedAt.UTC()))
+
+	// после выкладки шаг не повторяется: новые адреса ждут подтверждения
+	fresh := &models.User{Nickname: "fresh", Email: "fresh@test.com", Password: "x", IsActive: true}
+	require.NoError(t, tx.Create(fresh).Error)
+	require.NoError(t, Run(ctx, tx, All))
+
+	require.NoError(t, tx.First(&got, fresh.ID).Error)
+	require.Nil(t, got.EmailVerifiedAt)
+}
+
+func TestRun_DropsWebhookResponseBody(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	require.NoError(t, tx.Exec("ALTER TABLE webhook_attempts ADD COLUMN response_body text").Error)
+	require.NoError(t, Run(context.Background(), tx, All))
+	require.False(t, tx.Migrator().HasColumn(&models.WebhookAttempt{}, "response_body"))
+}
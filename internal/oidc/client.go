@@ -0,0 +1,265 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_blog/internal/jwtkeys"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrDiscovery      = errors.New("oidc discovery failed")
+	ErrTokenExchange  = errors.New("oidc code exchange failed")
+	ErrInvalidIDToken = errors.New("invalid id token")
+)
+
+const (
+	// JWKS перечитывается не чаще, даже если пришёл токен с незнакомым kid
+	jwksMinRefresh = time.Minute
+	maxBodySize    = 1 << 20
+	clockSkew      = 30 * time.Second
+)
+
+// Claims — то, что нужно от id_token для входа
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client — authorization code flow с PKCE для одного провайдера.
+// Discovery и JWKS загружаются лениво: недоступный провайдер не мешает старту.
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu     sync.Mutex
+	meta   *metadata
+	keys   *jwtkeys.Keyring
+	keysAt time.Time
+}
+
+func NewClient(cfg Config, hc *http.Client) *Client {
+	if hc == nil {
+		hc = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Client{cfg: cfg, http: hc}
+}
+
+func (c *Client) Name() string {
+	return c.cfg.Name
+}
+
+// AuthCodeURL — куда отправить пользователя; verifier остаётся у нас, наружу уходит только challenge
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", c.cfg.ClientID)
+	q.Set("redirect_uri", c.cfg.RedirectURL)
+	q.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", S256Challenge(verifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange меняет code на id_token и проверяет его: подпись по JWKS провайдера, iss, aud, exp и nonce
+func (c *Client) Exchange(ctx context.Context, code, verifier, nonce string) (Claims, error) {
+	meta, err := c.discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", c.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic: id и секрет form-urlencoded (RFC 6749, 2.3.1)
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	var tok struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := c.doJSON(req, &tok)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+	if status != http.StatusOK || tok.IDToken == "" {
+		return Claims{}, fmt.Errorf("%w: status %d %s %s", ErrTokenExchange, status, tok.Error, tok.ErrorDescription)
+	}
+
+	return c.verify(ctx, meta, tok.IDToken, nonce)
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     any    `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+func (c *Client) verify(ctx context.Context, meta *metadata, raw, nonce string) (Claims, error) {
+	parse := func(kr *jwtkeys.Keyring) (*idTokenClaims, error) {
+		var claims idTokenClaims
+		_, err := kr.Parse(raw, &claims,
+			jwt.WithIssuer(meta.Issuer),
+			jwt.WithAudience(c.cfg.ClientID),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(clockSkew),
+		)
+		return &claims, err
+	}
+
+	kr, err := c.keyring(ctx, meta, false)
+	if err != nil {
+		return Claims{}, err
+	}
+	claims, err := parse(kr)
+	if errors.Is(err, jwtkeys.ErrUnknownKey) {
+		// провайдер мог ротировать ключи
+		if kr, err = c.keyring(ctx, meta, true); err != nil {
+			return Claims{}, err
+		}
+		claims, err = parse(kr)
+	}
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return Claims{}, fmt.Errorf("%w: no sub", ErrInvalidIDToken)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return Claims{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	// некоторые провайдеры присылают email_verified строкой
+	verified := claims.EmailVerified == true || claims.EmailVerified == "true"
+	return Claims{
+		Subject:           claims.Subject,
+		Email:             strings.TrimSpace(claims.Email),
+		EmailVerified:     verified,
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+func (c *Client) discover(ctx context.Context) (*metadata, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.meta != nil {
+		return c.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var meta metadata
+	status, err := c.doJSON(req, &meta)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrDiscovery, status)
+	}
+	// OIDC Discovery, 4.3: issuer документа обязан совпасть с тем, у кого спрашивали
+	if strings.TrimRight(meta.Issuer, "/") != c.cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch %q", ErrDiscovery, meta.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete metadata", ErrDiscovery)
+	}
+
+	c.meta = &meta
+	return c.meta, nil
+}
+
+func (c *Client) keyring(ctx context.Context, meta *metadata, refresh bool) (*jwtkeys.Keyring, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keys != nil && (!refresh || time.Since(c.keysAt) < jwksMinRefresh) {
+		return c.keys, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set jwtkeys.JWKSet
+	status, err := c.doJSON(req, &set)
+	if err != nil || status != http.StatusOK {
+		if c.keys != nil {
+			// старые ключи лучше, чем никаких
+			return c.keys, nil
+		}
+		return nil, fmt.Errorf("%w: jwks status %d: %v", ErrDiscovery, status, err)
+	}
+	kr, err := set.VerifyKeyring()
+	if err != nil {
+		return nil, fmt.Errorf("%w: jwks: %v", ErrDiscovery, err)
+	}
+
+	c.keys, c.keysAt = kr, time.Now()
+	return c.keys, nil
+}
+
+func (c *Client) doJSON(req *http.Request, v any) (int, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,87 @@
+package oidc_test
+
+import (
+	"context"
+	"go_blog/internal/oidc"
+	"go_blog/internal/oidc/oidctest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CodeFlowWithPKCE(t *testing.T) {
+	p := oidctest.NewProvider(t)
+	c := oidc.NewClient(p.Config("stub", "http://app/callback"), nil)
+	ctx := context.Background()
+
+	verifier, err := oidc.RandomString()
+	require.NoError(t, err)
+	authURL, err := c.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+	require.NotContains(t, authURL, verifier)
+
+	code, state := p.Authorize(t, authURL, oidctest.User{Subject: "sub-1", Email: "a@test.com", EmailVerified: true, Name: "Ann"})
+	require.Equal(t, "state-1", state)
+
+	// чужой verifier провайдер не примет
+	_, err = c.Exchange(ctx, code, "other-verifier", "nonce-1")
+	require.ErrorIs(t, err, oidc.ErrTokenExchange)
+
+	code, _ = p.Authorize(t, authURL, oidctest.User{Subject: "sub-1", Email: "a@test.com", EmailVerified: true, Name: "Ann"})
+	claims, err := c.Exchange(ctx, code, verifier, "nonce-1")
+	require.NoError(t, err)
+	require.Equal(t, oidc.Claims{Subject: "sub-1", Email: "a@test.com", EmailVerified: true, Name: "Ann"}, claims)
+
+	// code одноразовый
+	_, err = c.Exchange(ctx, code, verifier, "nonce-1")
+	require.ErrorIs(t, err, oidc.ErrTokenExchange)
+}
+
+func TestClient_RejectsWrongNonceAndAudience(t *testing.T) {
+	p := oidctest.NewProvider(t)
+	ctx := context.Background()
+	verifier, err := oidc.RandomString()
+	require.NoError(t, err)
+
+	c := oidc.NewClient(p.Config("stub", "http://app/callback"), nil)
+	authURL, err := c.AuthCodeURL(ctx, "s", "expected-nonce", verifier)
+	require.NoError(t, err)
+
+	p.Nonce = "replayed-nonce"
+	code, _ := p.Authorize(t, authURL, oidctest.User{Subject: "sub-1"})
+	_, err = c.Exchange(ctx, code, verifier, "expected-nonce")
+	require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	p.Nonce = ""
+
+	// id_token выдан другому клиенту
+	cfg := p.Config("stub", "http://app/callback")
+	cfg.ClientID = "someone-else"
+	other := oidc.NewClient(cfg, nil)
+	code, _ = p.Authorize(t, authURL, oidctest.User{Subject: "sub-1"})
+	_, err = other.Exchange(ctx, code, verifier, "expected-nonce")
+	require.Error(t, err)
+}
+
+func TestClient_DiscoveryIssuerMismatch(t *testing.T) {
+	p := oidctest.NewProvider(t)
+	cfg := p.Config("stub", "http://app/callback")
+	cfg.Issuer = p.URL + "/tenant"
+
+	_, err := oidc.NewClient(cfg, nil).AuthCodeURL(context.Background(), "s", "n", "v")
+	require.ErrorIs(t, err, oidc.ErrDiscovery)
+}
+
+func TestConfigsFromEnv(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "Google, broken")
+	t.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com/")
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "id")
+	t.Setenv("OIDC_GOOGLE_CLIENT_SECRET", "secret")
+	t.Setenv("OIDC_GOOGLE_REDIRECT_URL", "https://blog/auth/oidc/google/callback")
+	t.Setenv("OIDC_BROKEN_ISSUER", "https://broken")
+
+	cfgs := oidc.ConfigsFromEnv()
+	require.Len(t, cfgs, 1)
+	require.Equal(t, "google", cfgs[0].Name)
+	require.Equal(t, "https://accounts.google.com", cfgs[0].Issuer)
+	require.Equal(t, []string{"openid", "email", "profile"}, cfgs[0].Scopes)
+}
@@ -0,0 +1,64 @@
+package oidc
+
+import (
+	"os"
+	"strings"
+)
+
+// FrontendCallbackURL — страница SPA, куда callback отправляет браузер с одноразовым ?code=:
+// OIDC_FRONTEND_CALLBACK_URL, по умолчанию APP_FRONTEND_URL + /auth/oidc/callback
+func FrontendCallbackURL() string {
+	if v := os.Getenv("OIDC_FRONTEND_CALLBACK_URL"); v != "" {
+		return v
+	}
+	return strings.TrimRight(os.Getenv("APP_FRONTEND_URL"), "/") + "/auth/oidc/callback"
+}
+
+// Config — один провайдер. Из окружения:
+//
+//	OIDC_PROVIDERS=google,gitlab
+//	OIDC_GOOGLE_ISSUER, OIDC_GOOGLE_CLIENT_ID, OIDC_GOOGLE_CLIENT_SECRET, OIDC_GOOGLE_REDIRECT_URL
+//	OIDC_GOOGLE_SCOPES — через запятую, по умолчанию openid,email,profile
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+var defaultScopes = []string{"openid", "email", "profile"}
+
+// ConfigsFromEnv пропускает провайдеров без issuer или client id
+func ConfigsFromEnv() []Config {
+	var out []Config
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg := Config{
+			Name:         name,
+			Issuer:       strings.TrimRight(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       defaultScopes,
+		}
+		if v := os.Getenv(prefix + "SCOPES"); v != "" {
+			cfg.Scopes = nil
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					cfg.Scopes = append(cfg.Scopes, s)
+				}
+			}
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			continue
+		}
+		out = append(out, cfg)
+	}
+	return out
+}
@@ -0,0 +1,16 @@
+package ports
+
+import "context"
+
+// Mail — готовое к отправке письмо (уже отрендеренное)
+type Mail struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, m Mail) error
+}
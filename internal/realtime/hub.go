@@ -0,0 +1,174 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Hub — одна подписка на Redis pub/sub на инстанс, дальше раздаём локальным клиентам
+type Hub struct {
+	rdb    *redis.Client
+	buffer int
+
+	mu   sync.Mutex
+	subs map[string]map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	C     chan Message
+	topic string
+	hub   *Hub
+
+	once    sync.Once
+	dropped chan struct{}
+}
+
+func NewHub(rdb *redis.Client, buffer int) *Hub {
+	if buffer <= 0 {
+		buffer = 64
+	}
+	return &Hub{rdb: rdb, buffer: buffer, subs: make(map[string]map[*Subscription]struct{})}
+}
+
+// ErrSubscriptionClosed — канал pub/sub закрылся, хотя ctx ещё жив
+var ErrSubscriptionClosed = errors.New("realtime: pubsub channel closed")
+
+// Run читает pub/sub до отмены ctx; go-redis сам переподключается
+func (h *Hub) Run(ctx context.Context) error {
+	ps := h.rdb.PSubscribe(ctx, channelPrefix+"*")
+	defer ps.Close()
+
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m, ok := <-ch:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return ErrSubscriptionClosed
+			}
+			var msg Message
+			if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+				log.Printf("realtime: bad message on %s: %v", m.Channel, err)
+				continue
+			}
+			h.Deliver(strings.TrimPrefix(m.Channel, channelPrefix), msg)
+		}
+	}
+}
+
+// Supervise держит Run живым до отмены ctx: после ошибки или паники перезапускает через backoff
+func (h *Hub) Supervise(ctx context.Context, backoff time.Duration) {
+	supervise(ctx, h.Run, backoff)
+}
+
+func supervise(ctx context.Context, run func(context.Context) error, backoff time.Duration) {
+	for {
+		err := runSafe(ctx, run)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = ErrSubscriptionClosed
+		}
+		log.Printf("realtime: hub stopped, restarting in %s: %v", backoff, err)
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// runSafe превращает панику в ошибку, чтобы один битый Deliver не убил раздачу на весь инстанс
+func runSafe(ctx context.Context, run func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+func (h *Hub) Subscribe(topic string) *Subscription {
+	s := &Subscription{
+		C:       make(chan Message, h.buffer),
+		topic:   topic,
+		hub:     h,
+		dropped: make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	if h.subs[topic] == nil {
+		h.subs[topic] = make(map[*Subscription]struct{})
+	}
+	h.subs[topic][s] = struct{}{}
+	h.mu.Unlock()
+
+	return s
+}
+
+// History — записи топика после afterID (не включая), старые первыми
+func (h *Hub) History(ctx context.Context, topic, afterID string, limit int64) ([]Message, error) {
+	entries, err := h.rdb.XRangeN(ctx, streamPrefix+topic, "("+afterID, "+", limit).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Message, 0, len(entries))
+	for _, e := range entries {
+		event, _ := e.Values["event"].(string)
+		data, _ := e.Values["data"].(string)
+		out = append(out, Message{ID: e.ID, Event: event, Data: json.RawMessage(data)})
+	}
+	return out, nil
+}
+
+// Deliver раздаёт сообщение локальным подписчикам (из Run или напрямую в тестах).
+// Не блокируется: клиент, который не успевает читать, отключается
+// и переподключится (SSE — с Last-Event-ID)
+func (h *Hub) Deliver(topic string, msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.subs[topic] {
+		select {
+		case s.C <- msg:
+		default:
+			h.removeLocked(s)
+			s.once.Do(func() { close(s.dropped) })
+		}
+	}
+}
+
+func (h *Hub) removeLocked(s *Subscription) {
+	delete(h.subs[s.topic], s)
+	if len(h.subs[s.topic]) == 0 {
+		delete(h.subs, s.topic)
+	}
+}
+
+// Dropped закрывается, если hub отключил медленного клиента
+func (s *Subscription) Dropped() <-chan struct{} {
+	return s.dropped
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	s.hub.removeLocked(s)
+	s.hub.mu.Unlock()
+}
@@ -0,0 +1,77 @@
+package realtime
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// GlobalTopic — общая лента (GET /stream)
+const GlobalTopic = "global"
+
+const (
+	streamPrefix  = "rt:stream:"
+	channelPrefix = "rt:pub:"
+)
+
+func PostTopic(postID string) string {
+	return "post:" + postID
+}
+
+// Типы личных уведомлений (событие в UserTopic)
+const (
+	NotifyCommentCreated = "comment.created" // новый комментарий к вашему посту
+	NotifyCommentReply   = "comment.reply"   // ответ на ваш комментарий
+	NotifyPostLiked      = "post.liked"
+	NotifyUserFollowed   = "user.followed"
+)
+
+var NotificationTypes = []string{NotifyCommentCreated, NotifyCommentReply, NotifyPostLiked, NotifyUserFollowed}
+
+// UserTopic — личные уведомления пользователя (/ws)
+func UserTopic(userID string) string {
+	return "user:" + userID
+}
+
+// Message — то, что уходит клиенту одним SSE-событием.
+// ID — id записи в Redis Stream, по нему клиент докачивает пропущенное (Last-Event-ID).
+type Message struct {
+	ID    string          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// parseID разбирает id Redis Stream вида "<ms>-<seq>"
+func parseID(id string) (ms, seq uint64, ok bool) {
+	a, b, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	ms, err1 := strconv.ParseUint(a, 10, 64)
+	seq, err2 := strconv.ParseUint(b, 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
+
+func ValidID(id string) bool {
+	_, _, ok := parseID(id)
+	return ok
+}
+
+// after: id строго новее last; пустой last — всё новее
+func after(id, last string) bool {
+	if last == "" {
+		return true
+	}
+	ms1, seq1, ok1 := parseID(id)
+	ms2, seq2, ok2 := parseID(last)
+	if !ok1 || !ok2 {
+		return true
+	}
+	if ms1 != ms2 {
+		return ms1 > ms2
+	}
+	return seq1 > seq2
+}
@@ -0,0 +1,63 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Publisher пишет событие в Redis Stream топика (история для Last-Event-ID)
+// и в pub/sub канал (живая доставка на все инстансы API)
+type Publisher struct {
+	rdb    *redis.Client
+	maxLen int64
+}
+
+func NewPublisher(rdb *redis.Client, maxLen int64) *Publisher {
+	if maxLen <= 0 {
+		maxLen = 1000
+	}
+	return &Publisher{rdb: rdb, maxLen: maxLen}
+}
+
+func (p *Publisher) Publish(ctx context.Context, topic, event string, data any) (Message, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	id, err := p.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamPrefix + topic,
+		MaxLen: p.maxLen,
+		Approx: true,
+		Values: map[string]any{"event": event, "data": string(b)},
+	}).Result()
+	if err != nil {
+		return Message{}, err
+	}
+
+	msg := Message{ID: id, Event: event, Data: b}
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return Message{}, err
+	}
+	if err := p.rdb.Publish(ctx, channelPrefix+topic, raw).Err(); err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+// PublishLive — только pub/sub, без Redis Stream: личные уведомления
+// не докачиваются по Last-Event-ID
+func (p *Publisher) PublishLive(ctx context.Context, topic, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(Message{Event: event, Data: b})
+	if err != nil {
+		return err
+	}
+	return p.rdb.Publish(ctx, channelPrefix+topic, raw).Err()
+}
@@ -0,0 +1,180 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource struct {
+	*Hub
+	history []Message
+	afterID string
+}
+
+func (f *fakeSource) History(ctx context.Context, topic, afterID string, limit int64) ([]Message, error) {
+	f.afterID = afterID
+	return f.history, nil
+}
+
+func msg(id, event string) Message {
+	return Message{ID: id, Event: event, Data: json.RawMessage(`{"n":"` + id + `"}`)}
+}
+
+func subscribers(h *Hub, topic string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs[topic])
+}
+
+// serve запускает ServeSSE и возвращает функцию, которая останавливает его и отдаёт тело ответа
+func serve(t *testing.T, src Source, topic, lastID string, heartbeat time.Duration) func() string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ServeSSE(ctx, rec, src, topic, lastID, heartbeat)
+	}()
+
+	return func() string {
+		cancel()
+		<-done
+		return rec.Body.String()
+	}
+}
+
+func TestAfter(t *testing.T) {
+	require.True(t, after("2-0", "1-5"))
+	require.True(t, after("1-6", "1-5"))
+	require.False(t, after("1-5", "1-5"))
+	require.False(t, after("1-4", "1-5"))
+	require.True(t, after("1-0", ""))
+	require.False(t, ValidID("garbage"))
+}
+
+func TestHub_FanoutByTopic(t *testing.T) {
+	h := NewHub(nil, 4)
+	a := h.Subscribe("post:1")
+	b := h.Subscribe("post:1")
+	other := h.Subscribe("post:2")
+	defer a.Close()
+	defer b.Close()
+	defer other.Close()
+
+	h.Deliver("post:1", msg("1-0", "comment.created"))
+
+	require.Equal(t, "1-0", (<-a.C).ID)
+	require.Equal(t, "1-0", (<-b.C).ID)
+	require.Empty(t, other.C)
+}
+
+func TestHub_SlowSubscriberDropped(t *testing.T) {
+	h := NewHub(nil, 1)
+	slow := h.Subscribe("global")
+
+	h.Deliver("global", msg("1-0", "x"))
+	h.Deliver("global", msg("2-0", "x"))
+
+	select {
+	case <-slow.Dropped():
+	default:
+		t.Fatal("slow subscriber was not dropped")
+	}
+	require.Zero(t, subscribers(h, "global"))
+
+	// Close после drop безопасен
+	slow.Close()
+}
+
+func TestServeSSE_LiveEvents(t *testing.T) {
+	h := NewHub(nil, 8)
+	stop := serve(t, &fakeSource{Hub: h}, "post:1", "", time.Hour)
+
+	require.Eventually(t, func() bool { return subscribers(h, "post:1") == 1 }, time.Second, 5*time.Millisecond)
+	h.Deliver("post:1", msg("5-0", "comment.created"))
+	time.Sleep(20 * time.Millisecond)
+
+	body := stop()
+	require.Contains(t, body, "retry: ")
+	require.Contains(t, body, "id: 5-0\nevent: comment.created\ndata: {\"n\":\"5-0\"}\n\n")
+	require.Zero(t, subscribers(h, "post:1"))
+}
+
+func TestServeSSE_ResumesFromLastEventID(t *testing.T) {
+	h := NewHub(nil, 8)
+	src := &fakeSource{Hub: h, history: []Message{msg("2-0", "post.likes"), msg("3-0", "post.likes")}}
+	stop := serve(t, src, "post:1", "1-0", time.Hour)
+
+	require.Eventually(t, func() bool { return subscribers(h, "post:1") == 1 }, time.Second, 5*time.Millisecond)
+	// 3-0 пришёл и в истории, и живьём — клиент должен увидеть его один раз
+	h.Deliver("post:1", msg("3-0", "post.likes"))
+	h.Deliver("post:1", msg("4-0", "post.likes"))
+	time.Sleep(20 * time.Millisecond)
+
+	body := stop()
+	require.Equal(t, "1-0", src.afterID)
+	require.Equal(t, 1, strings.Count(body, "id: 2-0\n"))
+	require.Equal(t, 1, strings.Count(body, "id: 3-0\n"))
+	require.Equal(t, 1, strings.Count(body, "id: 4-0\n"))
+	require.Less(t, strings.Index(body, "id: 2-0"), strings.Index(body, "id: 4-0"))
+}
+
+func TestServeSSE_Heartbeat(t *testing.T) {
+	h := NewHub(nil, 8)
+	stop := serve(t, &fakeSource{Hub: h}, GlobalTopic, "", 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	require.Contains(t, stop(), ": ping\n\n")
+}
+
+func TestServeSSE_Headers(t *testing.T) {
+	h := NewHub(nil, 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rec := httptest.NewRecorder()
+	ServeSSE(ctx, rec, &fakeSource{Hub: h}, GlobalTopic, "", time.Hour)
+
+	require.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	require.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+}
+
+func TestSupervise_RestartsAfterErrorAndPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	run := func(ctx context.Context) error {
+		calls++
+		switch calls {
+		case 1:
+			panic("boom")
+		case 2:
+			return ErrSubscriptionClosed
+		}
+		cancel()
+		<-ctx.Done()
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		supervise(ctx, run, time.Millisecond)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("supervise did not stop after ctx cancel")
+	}
+	require.Equal(t, 3, calls)
+}
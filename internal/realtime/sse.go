@@ -0,0 +1,95 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultHeartbeat = 15 * time.Second
+	historyLimit     = 1000
+	retryMs          = 3000
+)
+
+// Source — то, что нужно SSE от Hub (в тестах подменяется)
+type Source interface {
+	Subscribe(topic string) *Subscription
+	History(ctx context.Context, topic, afterID string, limit int64) ([]Message, error)
+}
+
+// ServeSSE держит соединение до отключения клиента.
+// Сначала подписываемся, потом докачиваем историю после lastEventID —
+// так между ними ничего не теряется, а дубли отсекаем по id.
+func ServeSSE(ctx context.Context, w http.ResponseWriter, src Source, topic, lastEventID string, heartbeat time.Duration) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	sub := src.Subscribe(topic)
+	defer sub.Close()
+
+	fmt.Fprintf(w, "retry: %d\n\n", retryMs)
+
+	last := ""
+	if ValidID(lastEventID) {
+		last = lastEventID
+		missed, err := src.History(ctx, topic, lastEventID, historyLimit)
+		if err != nil {
+			log.Printf("realtime: history %s: %v", topic, err)
+		}
+		for _, m := range missed {
+			if err := writeEvent(w, m); err != nil {
+				return
+			}
+			last = m.ID
+		}
+	}
+	flusher.Flush()
+
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeat
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.Dropped():
+			return
+		case m := <-sub.C:
+			if !after(m.ID, last) {
+				continue
+			}
+			if err := writeEvent(w, m); err != nil {
+				return
+			}
+			last = m.ID
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w io.Writer, m Message) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", m.ID, m.Event, m.Data)
+	return err
+}
@@ -0,0 +1,68 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+)
+
+// AuditSource читает историю из audit_logs по возрастанию (occurred_at, id)
+// пачками; фильтр по типам и времени уходит в SQL
+type AuditSource struct {
+	repo   *repositories.AuditLogRepository
+	filter Filter
+	batch  int
+}
+
+func NewAuditSource(repo *repositories.AuditLogRepository, filter Filter, batch int) *AuditSource {
+	if batch <= 0 {
+		batch = 500
+	}
+	return &AuditSource{repo: repo, filter: filter, batch: batch}
+}
+
+func (s *AuditSource) Stream(ctx context.Context, fn func(events.Envelope) error) error {
+	f := repositories.AuditLogFilter{
+		EventTypes: s.filter.Types,
+		From:       s.filter.From,
+		To:         s.filter.To,
+		Ascending:  true,
+		Limit:      s.batch,
+	}
+
+	for {
+		items, err := s.repo.Query(ctx, f)
+		if err != nil {
+			return err
+		}
+
+		for _, it := range items {
+			if err := fn(auditToEnvelope(it)); err != nil {
+				return err
+			}
+		}
+
+		if len(items) < s.batch {
+			return nil
+		}
+		last := items[len(items)-1]
+		f.AfterOccurredAt = &last.OccurredAt
+		f.AfterID = last.ID
+	}
+}
+
+func auditToEnvelope(l models.AuditLog) events.Envelope {
+	return events.Envelope{
+		EventID:       l.EventID,
+		EventType:     l.EventType,
+		OccurredAt:    l.OccurredAt,
+		AggregateType: l.AggregateType,
+		AggregateID:   l.AggregateID,
+		ActorUserID:   l.ActorUserID,
+		CorrelationID: l.CorrelationID,
+		Version:       l.Version,
+		Payload:       json.RawMessage(l.Payload),
+	}
+}
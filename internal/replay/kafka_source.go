@@ -0,0 +1,143 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_blog/internal/consumer"
+	"go_blog/internal/events"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSource перечитывает топик без consumer group (offset'ы группы не трогаем).
+// Партиции читаются по очереди: порядок сохраняется внутри партиции (т.е. внутри агрегата),
+// но не между ними. Читаем до high watermark на момент старта; пустые партиции пропускаем.
+type KafkaSource struct {
+	Brokers []string
+	Topic   string
+	// StartOffset — с какого offset'а в каждой партиции (kafka.FirstOffset по умолчанию)
+	StartOffset int64
+	// StartTime, если задан, важнее StartOffset
+	StartTime *time.Time
+	// ReadTimeout — сколько ждать брокер; 0 — defaultReadTimeout
+	ReadTimeout time.Duration
+	Decode      consumer.Decoder
+}
+
+const defaultReadTimeout = 30 * time.Second
+
+func (s *KafkaSource) Stream(ctx context.Context, fn func(events.Envelope) error) error {
+	if len(s.Brokers) == 0 {
+		return errors.New("kafka source: no brokers")
+	}
+
+	conn, err := kafka.DialContext(ctx, "tcp", s.Brokers[0])
+	if err != nil {
+		return err
+	}
+	partitions, err := conn.ReadPartitions(s.Topic)
+	conn.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range partitions {
+		if err := s.streamPartition(ctx, p.ID, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *KafkaSource) streamPartition(ctx context.Context, partition int, fn func(events.Envelope) error) error {
+	start, end, err := s.bounds(ctx, partition)
+	if err != nil {
+		return fmt.Errorf("partition %d: %w", partition, err)
+	}
+	if start >= end {
+		return nil // партиция пуста или всё до end уже вычищено retention
+	}
+
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   s.Brokers,
+		Topic:     s.Topic,
+		Partition: partition,
+	})
+	defer r.Close()
+
+	if err := r.SetOffset(start); err != nil {
+		return fmt.Errorf("partition %d: %w", partition, err)
+	}
+
+	decode := s.Decode
+	if decode == nil {
+		decode = consumer.Decode
+	}
+
+	for {
+		// сообщение до end обязано прийти; не пришло — брокер недоступен, а не конец партиции
+		rctx, cancel := context.WithTimeout(ctx, s.readTimeout())
+		msg, err := r.ReadMessage(rctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("partition %d (end %d): %w", partition, end, err)
+		}
+
+		env, err := decode(msg)
+		if err != nil {
+			// то же, что consumer отправил бы в DLQ — здесь просто пропускаем
+			log.Printf("replay: skip undecodable message p%d@%d: %v", msg.Partition, msg.Offset, err)
+		} else if err := fn(env); err != nil {
+			return err
+		}
+
+		if msg.Offset+1 >= end {
+			return nil
+		}
+	}
+}
+
+// bounds — реальные offset'ы [start, end) партиции. kafka.FirstOffset/LastOffset — это
+// метки (-2/-1), а не позиции: по ним нельзя понять, что партиция дочитана.
+func (s *KafkaSource) bounds(ctx context.Context, partition int) (start, end int64, err error) {
+	leader, err := kafka.DialLeader(ctx, "tcp", s.Brokers[0], s.Topic, partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer leader.Close()
+	_ = leader.SetDeadline(time.Now().Add(s.readTimeout()))
+
+	first, end, err := leader.ReadOffsets()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	requested := s.StartOffset
+	if s.StartTime != nil {
+		if requested, err = leader.ReadOffset(*s.StartTime); err != nil {
+			return 0, 0, err
+		}
+	}
+	return clampStart(requested, first, end), end, nil
+}
+
+// clampStart приводит запрошенный offset к [first, end]
+func clampStart(requested, first, end int64) int64 {
+	switch {
+	case requested == kafka.LastOffset, requested > end:
+		return end
+	case requested < first: // FirstOffset и offset'ы, вычищенные retention
+		return first
+	}
+	return requested
+}
+
+func (s *KafkaSource) readTimeout() time.Duration {
+	if s.ReadTimeout > 0 {
+		return s.ReadTimeout
+	}
+	return defaultReadTimeout
+}
@@ -0,0 +1,165 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_blog/internal/consumer"
+	"go_blog/internal/events"
+	"log"
+	"slices"
+	"time"
+)
+
+// errStop — Source должен прекратить чтение без ошибки
+var errStop = errors.New("stop replay")
+
+// Source отдаёт события по порядку, пока fn не вернёт ошибку
+type Source interface {
+	Stream(ctx context.Context, fn func(events.Envelope) error) error
+}
+
+// Filter — пустые поля не фильтруют; To не включается
+type Filter struct {
+	Types []string
+	From  *time.Time
+	To    *time.Time
+}
+
+func (f Filter) Match(e events.Envelope) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.EventType) {
+		return false
+	}
+	if f.From != nil && e.OccurredAt.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && !e.OccurredAt.Before(*f.To) {
+		return false
+	}
+	return true
+}
+
+type Options struct {
+	Filter Filter
+	DryRun bool
+	// Rate — событий в секунду; 0 — без ограничения
+	Rate float64
+	// Limit — сколько событий обработать максимум; 0 — все
+	Limit int
+	// ContinueOnError — логировать ошибку обработчика и идти дальше
+	ContinueOnError bool
+	// Reset — очистить проекцию перед replay (перестройка с нуля); в dry-run не вызывается
+	Reset func(ctx context.Context) error
+}
+
+type Stats struct {
+	Seen    int // прочитано из источника
+	Matched int // прошло фильтр
+	Handled int // успешно обработано (в dry-run — 0)
+	Failed  int
+}
+
+type Replayer struct {
+	src     Source
+	handler consumer.Handler
+	opts    Options
+	limiter *limiter
+}
+
+func New(src Source, handler consumer.Handler, opts Options) *Replayer {
+	return &Replayer{src: src, handler: handler, opts: opts, limiter: newLimiter(opts.Rate)}
+}
+
+func (r *Replayer) Run(ctx context.Context) (Stats, error) {
+	var st Stats
+
+	if r.opts.Reset != nil && !r.opts.DryRun {
+		if err := r.opts.Reset(ctx); err != nil {
+			return st, fmt.Errorf("reset projection: %w", err)
+		}
+	}
+
+	err := r.src.Stream(ctx, func(e events.Envelope) error {
+		st.Seen++
+
+		if !r.opts.Filter.Match(e) {
+			return nil
+		}
+		if r.opts.Limit > 0 && st.Matched >= r.opts.Limit {
+			return errStop
+		}
+		st.Matched++
+
+		e, err := events.Schemas.Upcast(e)
+		if err != nil {
+			return r.fail(&st, e, err)
+		}
+
+		if r.opts.DryRun {
+			log.Printf("[dry-run] %s %s %s/%s at %s", e.EventID, e.EventType, e.AggregateType, e.AggregateID, e.OccurredAt.Format(time.RFC3339))
+			return nil
+		}
+
+		if err := r.limiter.wait(ctx); err != nil {
+			return err
+		}
+
+		if err := r.handler.Handle(ctx, e); err != nil {
+			return r.fail(&st, e, err)
+		}
+		st.Handled++
+		return nil
+	})
+	if errors.Is(err, errStop) {
+		err = nil
+	}
+	return st, err
+}
+
+func (r *Replayer) fail(st *Stats, e events.Envelope, err error) error {
+	st.Failed++
+	err = fmt.Errorf("event %s (%s): %w", e.EventID, e.EventType, err)
+	if r.opts.ContinueOnError {
+		log.Println("replay:", err)
+		return nil
+	}
+	return err
+}
+
+// limiter — равномерный темп: не чаще одного события в interval
+type limiter struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func newLimiter(rate float64) *limiter {
+	if rate <= 0 {
+		return &limiter{}
+	}
+	return &limiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
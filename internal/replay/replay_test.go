@@ -0,0 +1,187 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go_blog/internal/events"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+type sliceSource []events.Envelope
+
+func (s sliceSource) Stream(ctx context.Context, fn func(events.Envelope) error) error {
+	for _, e := range s {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type recorder struct {
+	got     []string
+	failOn  string
+	handled int
+}
+
+func (r *recorder) Handle(ctx context.Context, e events.Envelope) error {
+	r.got = append(r.got, e.EventID)
+	if e.EventID == r.failOn {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+var base = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func ev(id, typ string, at time.Duration) events.Envelope {
+	return events.Envelope{
+		EventID:    id,
+		EventType:  typ,
+		OccurredAt: base.Add(at),
+		Version:    1,
+		Payload:    json.RawMessage(`{}`),
+	}
+}
+
+func testSource() sliceSource {
+	return sliceSource{
+		ev("1", "PostCreated", 0),
+		ev("2", "CommentCreated", time.Hour),
+		ev("3", "PostCreated", 2*time.Hour),
+		ev("4", "PostDeleted", 3*time.Hour),
+	}
+}
+
+func TestReplayer_FeedsAllEvents(t *testing.T) {
+	h := &recorder{}
+	st, err := New(testSource(), h, Options{}).Run(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"1", "2", "3", "4"}, h.got)
+	require.Equal(t, Stats{Seen: 4, Matched: 4, Handled: 4}, st)
+}
+
+func TestReplayer_ResetRunsBeforeEvents(t *testing.T) {
+	h := &recorder{}
+	reset := func(ctx context.Context) error {
+		require.Empty(t, h.got)
+		h.got = append(h.got, "reset")
+		return nil
+	}
+
+	_, err := New(testSource(), h, Options{Reset: reset}).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"reset", "1", "2", "3", "4"}, h.got)
+
+	// dry-run ничего не очищает
+	h = &recorder{}
+	_, err = New(testSource(), h, Options{Reset: reset, DryRun: true}).Run(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, h.got)
+
+	h = &recorder{}
+	_, err = New(testSource(), h, Options{Reset: func(ctx context.Context) error { return errors.New("locked") }}).Run(context.Background())
+	require.ErrorContains(t, err, "reset projection")
+	require.Empty(t, h.got)
+}
+
+func TestReplayer_TypeAndTimeFilters(t *testing.T) {
+	from := base.Add(30 * time.Minute)
+	to := base.Add(3 * time.Hour)
+
+	h := &recorder{}
+	st, err := New(testSource(), h, Options{Filter: Filter{
+		Types: []string{"PostCreated", "PostDeleted"},
+		From:  &from,
+		To:    &to,
+	}}).Run(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"3"}, h.got)
+	require.Equal(t, 4, st.Seen)
+	require.Equal(t, 1, st.Matched)
+}
+
+func TestReplayer_DryRunDoesNotCallHandler(t *testing.T) {
+	h := &recorder{}
+	st, err := New(testSource(), h, Options{DryRun: true}).Run(context.Background())
+	require.NoError(t, err)
+
+	require.Empty(t, h.got)
+	require.Equal(t, 4, st.Matched)
+	require.Zero(t, st.Handled)
+}
+
+func TestReplayer_StopsOnError(t *testing.T) {
+	h := &recorder{failOn: "2"}
+	st, err := New(testSource(), h, Options{}).Run(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "event 2")
+
+	require.Equal(t, []string{"1", "2"}, h.got)
+	require.Equal(t, 1, st.Failed)
+}
+
+func TestReplayer_ContinueOnError(t *testing.T) {
+	h := &recorder{failOn: "2"}
+	st, err := New(testSource(), h, Options{ContinueOnError: true}).Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, h.got, 4)
+	require.Equal(t, 3, st.Handled)
+	require.Equal(t, 1, st.Failed)
+}
+
+func TestReplayer_Limit(t *testing.T) {
+	h := &recorder{}
+	_, err := New(testSource(), h, Options{Limit: 2}).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "2"}, h.got)
+}
+
+func TestReplayer_RateLimit(t *testing.T) {
+	h := &recorder{}
+	start := time.Now()
+	// 4 события при 50/с: первое сразу, дальше по 20ms
+	_, err := New(testSource(), h, Options{Rate: 50}).Run(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 55*time.Millisecond)
+}
+
+func TestReplayer_RateLimitRespectsCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	h := &recorder{}
+	_, err := New(testSource(), h, Options{Rate: 1}).Run(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Len(t, h.got, 1)
+}
+
+func TestClampStart(t *testing.T) {
+	cases := []struct {
+		name                  string
+		requested, first, end int64
+		want                  int64
+	}{
+		{"first offset marker", kafka.FirstOffset, 0, 10, 0},
+		{"zero after retention", 0, 40, 50, 40},
+		{"trimmed offset", 12, 40, 50, 40},
+		{"inside", 45, 40, 50, 45},
+		{"last offset marker", kafka.LastOffset, 40, 50, 50},
+		{"past end", 70, 40, 50, 50},
+		{"empty partition", kafka.FirstOffset, 0, 0, 0},
+		{"fully trimmed", kafka.FirstOffset, 50, 50, 50},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.want, clampStart(c.requested, c.first, c.end))
+		})
+	}
+}
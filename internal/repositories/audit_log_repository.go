@@ -2,8 +2,11 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"go_blog/models"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -27,3 +30,80 @@ func (r *AuditLogRepository) Create(ctx context.Context, log *models.AuditLog) e
 
 	return err
 }
+
+// AuditLogFilter — пустые поля не фильтруют
+type AuditLogFilter struct {
+	EventTypes    []string
+	AggregateType string
+	AggregateID   string
+	ActorUserID   string
+	From          *time.Time
+	To            *time.Time
+	// PayloadContains — JSON-объект, ищем через jsonb @> (GIN-индекс)
+	PayloadContains string
+
+	// keyset-пагинация: строки строго "старше" (occurred_at, id) курсора,
+	// при Ascending — строго "новее"
+	AfterOccurredAt *time.Time
+	AfterID         uint
+	Ascending       bool
+
+	Limit int
+}
+
+// Query отдаёт записи от новых к старым (или наоборот, если Ascending)
+func (r *AuditLogRepository) Query(ctx context.Context, f AuditLogFilter) ([]models.AuditLog, error) {
+	q := r.db.WithContext(ctx).Model(&models.AuditLog{})
+
+	if len(f.EventTypes) > 0 {
+		q = q.Where("event_type IN ?", f.EventTypes)
+	}
+	if f.AggregateType != "" {
+		q = q.Where("aggregate_type = ?", f.AggregateType)
+	}
+	if f.AggregateID != "" {
+		q = q.Where("aggregate_id = ?", f.AggregateID)
+	}
+	if f.ActorUserID != "" {
+		q = q.Where("actor_user_id = ?", f.ActorUserID)
+	}
+	if f.From != nil {
+		q = q.Where("occurred_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("occurred_at < ?", *f.To)
+	}
+	if f.PayloadContains != "" {
+		q = q.Where("payload @> ?::jsonb", f.PayloadContains)
+	}
+
+	return r.page(q, f)
+}
+
+// PostHistory: события самого поста + события, в payload которых есть его post_id (комментарии и т.п.)
+func (r *AuditLogRepository) PostHistory(ctx context.Context, postID string, f AuditLogFilter) ([]models.AuditLog, error) {
+	ref, err := json.Marshal(map[string]string{"post_id": postID})
+	if err != nil {
+		return nil, err
+	}
+
+	q := r.db.WithContext(ctx).Model(&models.AuditLog{}).
+		Where("(aggregate_type = ? AND aggregate_id = ?) OR payload @> ?::jsonb", "post", postID, string(ref))
+
+	return r.page(q, f)
+}
+
+func (r *AuditLogRepository) page(q *gorm.DB, f AuditLogFilter) ([]models.AuditLog, error) {
+	cmp, order := "<", "occurred_at desc, id desc"
+	if f.Ascending {
+		cmp, order = ">", "occurred_at asc, id asc"
+	}
+
+	if f.AfterOccurredAt != nil {
+		q = q.Where("(occurred_at, id) "+cmp+" (?, ?)", *f.AfterOccurredAt, f.AfterID)
+	}
+
+	var items []models.AuditLog
+	err := q.Order(order).Limit(f.Limit).Find(&items).Error
+	return items, err
+}
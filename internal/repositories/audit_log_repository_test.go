@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func seedAudit(t *testing.T, repo *AuditLogRepository, at time.Time, typ, aggType, aggID, actor, payload string) {
+	t.Helper()
+	require.NoError(t, repo.Create(context.Background(), &models.AuditLog{
+		EventID:       uuid.NewString(),
+		EventType:     typ,
+		AggregateType: aggType,
+		AggregateID:   aggID,
+		ActorUserID:   actor,
+		Payload:       payload,
+		OccurredAt:    at,
+	}))
+}
+
+func TestAuditLogRepository_Query_Filters(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := NewAuditLogRepository(tx)
+	ctx := context.Background()
+
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	seedAudit(t, repo, base, "PostCreated", "post", "1", "7", `{"post_id":"1","slug":"a","title":"A"}`)
+	seedAudit(t, repo, base.Add(time.Minute), "PostUpdated", "post", "1", "7", `{"post_id":"1","slug":"a","title":"B"}`)
+	seedAudit(t, repo, base.Add(2*time.Minute), "PostCreated", "post", "2", "8", `{"post_id":"2","slug":"b","title":"C"}`)
+
+	got, err := repo.Query(ctx, AuditLogFilter{EventTypes: []string{"PostCreated"}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, "2", got[0].AggregateID) // новые сверху
+
+	got, err = repo.Query(ctx, AuditLogFilter{ActorUserID: "7", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	from := base.Add(30 * time.Second)
+	to := base.Add(90 * time.Second)
+	got, err = repo.Query(ctx, AuditLogFilter{From: &from, To: &to, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, "PostUpdated", got[0].EventType)
+
+	got, err = repo.Query(ctx, AuditLogFilter{PayloadContains: `{"title":"C"}`, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, "2", got[0].AggregateID)
+}
+
+func TestAuditLogRepository_Query_CursorPagination(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := NewAuditLogRepository(tx)
+	ctx := context.Background()
+
+	// одинаковое время — порядок решает id
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		seedAudit(t, repo, at, "PostCreated", "post", "1", "7", `{}`)
+	}
+
+	var seen []uint
+	f := AuditLogFilter{Limit: 2}
+	for {
+		page, err := repo.Query(ctx, f)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		for _, it := range page {
+			seen = append(seen, it.ID)
+		}
+		last := page[len(page)-1]
+		f.AfterOccurredAt = &last.OccurredAt
+		f.AfterID = last.ID
+	}
+
+	require.Len(t, seen, 5)
+	for i := 1; i < len(seen); i++ {
+		require.Greater(t, seen[i-1], seen[i])
+	}
+}
+
+func TestAuditLogRepository_PostHistory_IncludesRelatedEvents(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := NewAuditLogRepository(tx)
+	ctx := context.Background()
+
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	seedAudit(t, repo, at, "PostCreated", "post", "1", "7", `{"post_id":"1","slug":"a","title":"A"}`)
+	seedAudit(t, repo, at.Add(time.Minute), "CommentCreated", "comment", "10", "8", `{"comment_id":"10","post_id":"1","post_slug":"a","post_author_id":"7"}`)
+	seedAudit(t, repo, at.Add(time.Minute), "PostCreated", "post", "2", "8", `{"post_id":"2","slug":"b","title":"B"}`)
+
+	got, err := repo.PostHistory(ctx, "1", AuditLogFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, "CommentCreated", got[0].EventType)
+	require.Equal(t, "PostCreated", got[1].EventType)
+}
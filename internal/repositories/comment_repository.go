@@ -42,6 +42,38 @@ func (r *CommentRepository) Create(ctx context.Context, postSlug string, userID
 	return comment, nil
 }
 
+// Важно: вызывается ИЗ транзакции (tx)
+func (r *CommentRepository) FindPostBySlugTx(ctx context.Context, tx *gorm.DB, slug string) (*models.Post, error) {
+	var post models.Post
+	if err := tx.WithContext(ctx).Where("slug = ? AND is_active = ?", slug, true).First(&post).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (r *CommentRepository) FindByIDTx(ctx context.Context, tx *gorm.DB, id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := tx.WithContext(ctx).First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *CommentRepository) CreateTx(ctx context.Context, tx *gorm.DB, postID, userID uint, parentID *uint, text string) (*models.Comment, error) {
+	comment := &models.Comment{
+		PostID:   postID,
+		UserID:   userID,
+		ParentID: parentID,
+		Text:     text,
+	}
+
+	if err := tx.WithContext(ctx).Create(comment).Error; err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
 func (r *CommentRepository) DeleteOwnedBy(ctx context.Context, commentID, userID uint) error {
 	var comment models.Comment
 	if err := r.db.WithContext(ctx).Where("id = ?", commentID).First(&comment).Error; err != nil {
@@ -55,6 +87,15 @@ func (r *CommentRepository) DeleteOwnedBy(ctx context.Context, commentID, userID
 	return r.db.WithContext(ctx).Delete(&comment).Error
 }
 
+// Delete — без проверки автора (модерация)
+func (r *CommentRepository) Delete(ctx context.Context, commentID uint) error {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).Where("id = ?", commentID).First(&comment).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Delete(&comment).Error
+}
+
 func (r *CommentRepository) ListByPostSlug(ctx context.Context, postSlug string) ([]models.Comment, error) {
 	postID, err := r.postIDBySlug(ctx, postSlug)
 	if err != nil {
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"go_blog/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrAlreadyFollowing = errors.New("already following")
+
+type FollowRepository struct {
+	db *gorm.DB
+}
+
+func NewFollowRepository(db *gorm.DB) *FollowRepository {
+	return &FollowRepository{db: db}
+}
+
+// Важно: вызывается ИЗ транзакции (tx)
+func (r *FollowRepository) CreateTx(ctx context.Context, tx *gorm.DB, followerID, followeeID uint) error {
+	err := tx.WithContext(ctx).Create(&models.Follow{FollowerID: followerID, FolloweeID: followeeID}).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrAlreadyFollowing
+	}
+	return err
+}
+
+func (r *FollowRepository) Delete(ctx context.Context, followerID, followeeID uint) error {
+	return r.db.WithContext(ctx).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&models.Follow{}).Error
+}
+
+func (r *FollowRepository) CountFollowers(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&models.Follow{}).Where("followee_id = ?", userID).Count(&n).Error
+	return n, err
+}
+
+// DigestRow — новый пост автора, на которого подписан получатель
+type DigestRow struct {
+	RecipientID       uint
+	RecipientEmail    string
+	RecipientNickname string
+	PostID            uint
+	Title             string
+	Slug              string
+	AuthorNickname    string
+	CreatedAt         time.Time
+}
+
+// DigestRows — посты за [from, to) от авторов, на которых подписаны активные пользователи
+// с подтверждённым адресом, без тех, кто выключил категорию optOutType. Отсортировано по получателю, новые первыми.
+func (r *FollowRepository) DigestRows(ctx context.Context, from, to time.Time, optOutType string) ([]DigestRow, error) {
+	var rows []DigestRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT f.follower_id AS recipient_id, rc.email AS recipient_email, rc.nickname AS recipient_nickname,
+		       p.id AS post_id, p.title, p.slug, a.nickname AS author_nickname, p.created_at
+		FROM follows f
+		JOIN users rc ON rc.id = f.follower_id AND rc.deleted_at IS NULL AND rc.is_active
+		     AND rc.email_verified_at IS NOT NULL
+		JOIN posts p ON p.user_id = f.followee_id AND p.deleted_at IS NULL AND p.is_active
+		     AND p.created_at >= ? AND p.created_at < ?
+		JOIN users a ON a.id = p.user_id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM notification_preferences np
+			WHERE np.user_id = f.follower_id AND np.type = ? AND NOT np.enabled
+		)
+		ORDER BY f.follower_id, p.created_at DESC`, from, to, optOutType).
+		Scan(&rows).Error
+	return rows, err
+}
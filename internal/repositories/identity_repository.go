@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"go_blog/models"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+var ErrIdentityExists = errors.New("identity already linked")
+
+type IdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// FindBySubject — gorm.ErrRecordNotFound, если такой вход ещё не привязан
+func (r *IdentityRepository) FindBySubject(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	var id models.Identity
+	if err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&id).Error; err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (r *IdentityRepository) CreateTx(ctx context.Context, tx *gorm.DB, identity *models.Identity) error {
+	if err := tx.WithContext(ctx).Create(identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrIdentityExists
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrIdentityExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *IdentityRepository) ListByUser(ctx context.Context, userID uint) ([]models.Identity, error) {
+	var out []models.Identity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&out).Error
+	return out, err
+}
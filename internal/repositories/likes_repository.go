@@ -64,3 +64,35 @@ func (r *LikeRepository) CountByPostSlug(ctx context.Context, postSlug string) (
 
 	return count, nil
 }
+
+// Важно: вызывается ИЗ транзакции (tx)
+func (r *LikeRepository) FindPostBySlugTx(ctx context.Context, tx *gorm.DB, slug string) (*models.Post, error) {
+	var post models.Post
+	if err := tx.WithContext(ctx).Where("slug = ? AND is_active = ?", slug, true).First(&post).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (r *LikeRepository) LikeTx(ctx context.Context, tx *gorm.DB, postID, userID uint) error {
+	like := models.PostLike{PostID: postID, UserID: userID}
+	if err := tx.WithContext(ctx).Create(&like).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrAlreadyLiked
+		}
+		return err
+	}
+	return nil
+}
+
+// UnlikeTx: false — лайка и не было
+func (r *LikeRepository) UnlikeTx(ctx context.Context, tx *gorm.DB, postID, userID uint) (bool, error) {
+	res := tx.WithContext(ctx).Unscoped().Where("post_id = ? AND user_id = ?", postID, userID).Delete(&models.PostLike{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *LikeRepository) CountTx(ctx context.Context, tx *gorm.DB, postID uint) (int64, error) {
+	var count int64
+	err := tx.WithContext(ctx).Model(&models.PostLike{}).Where("post_id = ?", postID).Count(&count).Error
+	return count, err
+}
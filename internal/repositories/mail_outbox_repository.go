@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"go_blog/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MailOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewMailOutboxRepository(db *gorm.DB) *MailOutboxRepository {
+	return &MailOutboxRepository{db: db}
+}
+
+// EnqueueTx ставит письмо в очередь; письмо с тем же DedupKey уже есть — ничего не делаем
+func (r *MailOutboxRepository) EnqueueTx(ctx context.Context, tx *gorm.DB, m *models.MailOutbox) error {
+	if m.Status == "" {
+		m.Status = models.MailPending
+	}
+	if m.NextAttemptAt.IsZero() {
+		m.NextAttemptAt = time.Now().UTC()
+	}
+	if m.Headers == "" {
+		m.Headers = "{}"
+	}
+	return tx.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "dedup_key"}},
+		DoNothing: true,
+	}).Create(m).Error
+}
+
+func (r *MailOutboxRepository) Enqueue(ctx context.Context, m *models.MailOutbox) error {
+	return r.EnqueueTx(ctx, r.db, m)
+}
+
+// ClaimDue — как у вебхуков: берём пачку и сдвигаем next_attempt_at на lease
+func (r *MailOutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.MailOutbox, error) {
+	var items []models.MailOutbox
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		if err := tx.
+			Where("status = ? AND next_attempt_at <= ?", models.MailPending, now).
+			Order("next_attempt_at asc").
+			Limit(limit).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Find(&items).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(items))
+		for _, it := range items {
+			ids = append(ids, it.ID)
+		}
+		return tx.Model(&models.MailOutbox{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+
+	return items, err
+}
+
+// scrubbedBody: в телах и заголовках одноразовые ссылки (сброс пароля, подтверждения, отписка) —
+// после отправки или окончательной неудачи они в БД не нужны
+var scrubbedBody = map[string]any{
+	"text_body": "",
+	"html_body": "",
+	"headers":   "{}",
+}
+
+func (r *MailOutboxRepository) MarkSent(ctx context.Context, id uint, attempts int) error {
+	now := time.Now().UTC()
+	updates := map[string]any{
+		"status":     models.MailSent,
+		"attempts":   attempts,
+		"last_error": "",
+		"sent_at":    &now,
+	}
+	for k, v := range scrubbedBody {
+		updates[k] = v
+	}
+	return r.db.WithContext(ctx).Model(&models.MailOutbox{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// MarkFailed: nextAttemptAt == nil — попытки кончились
+func (r *MailOutboxRepository) MarkFailed(ctx context.Context, id uint, attempts int, lastErr string, nextAttemptAt *time.Time) error {
+	updates := map[string]any{
+		"attempts":   attempts,
+		"last_error": lastErr,
+	}
+	if nextAttemptAt == nil {
+		updates["status"] = models.MailFailed
+		for k, v := range scrubbedBody {
+			updates[k] = v
+		}
+	} else {
+		updates["next_attempt_at"] = *nextAttemptAt
+	}
+	return r.db.WithContext(ctx).Model(&models.MailOutbox{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ScrubFinished чистит тела уже отправленных/проваленных писем (строки до появления очистки в MarkSent)
+func (r *MailOutboxRepository) ScrubFinished(ctx context.Context) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&models.MailOutbox{}).
+		Where("status IN ? AND (text_body <> '' OR html_body <> '')", []models.MailStatus{models.MailSent, models.MailFailed}).
+		Updates(scrubbedBody)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailOutboxRepository_FinishedMailIsScrubbed(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := NewMailOutboxRepository(tx)
+	ctx := context.Background()
+
+	sent := &models.MailOutbox{DedupKey: "reset:1", Template: "password_reset", To: "a@example.com", Subject: "Reset",
+		TextBody: "https://app/reset-password?token=secret", HTMLBody: "<a href=\"https://app/reset-password?token=secret\">", Headers: `{"X":"1"}`}
+	failed := &models.MailOutbox{DedupKey: "verify:1", Template: "verify_email", To: "a@example.com", Subject: "Verify",
+		TextBody: "https://app/verify-email?token=secret", HTMLBody: "<a>secret</a>"}
+	require.NoError(t, repo.Enqueue(ctx, sent))
+	require.NoError(t, repo.Enqueue(ctx, failed))
+
+	require.NoError(t, repo.MarkSent(ctx, sent.ID, 1))
+	require.NoError(t, repo.MarkFailed(ctx, failed.ID, 8, "smtp down", nil))
+
+	var rows []models.MailOutbox
+	require.NoError(t, tx.Order("id").Find(&rows).Error)
+	require.Len(t, rows, 2)
+	for _, row := range rows {
+		require.Empty(t, row.TextBody)
+		require.Empty(t, row.HTMLBody)
+		require.Equal(t, "{}", row.Headers)
+	}
+	require.Equal(t, models.MailSent, rows[0].Status)
+	require.Equal(t, models.MailFailed, rows[1].Status)
+}
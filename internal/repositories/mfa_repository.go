@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"go_blog/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// Find — gorm.ErrRecordNotFound, если TOTP не настраивался
+func (r *MFARepository) Find(ctx context.Context, userID uint) (*models.UserMFA, error) {
+	var m models.UserMFA
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// SavePending — новый неподтверждённый секрет; прежний неподтверждённый заменяется
+func (r *MFARepository) SavePending(ctx context.Context, userID uint, secretEnc string) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{"secret_enc": secretEnc, "last_used_step": 0, "updated_at": time.Now()}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "user_mfa.confirmed_at IS NULL"}}},
+	}).Create(&models.UserMFA{UserID: userID, SecretEnc: secretEnc}).Error
+}
+
+// ConfirmTx включает 2FA; false — уже включена (гонка двух подтверждений)
+func (r *MFARepository) ConfirmTx(ctx context.Context, tx *gorm.DB, userID uint, step int64) (bool, error) {
+	res := tx.WithContext(ctx).Model(&models.UserMFA{}).
+		Where("user_id = ? AND confirmed_at IS NULL", userID).
+		Updates(map[string]any{"confirmed_at": time.Now().UTC(), "last_used_step": step})
+	return res.RowsAffected > 0, res.Error
+}
+
+// UseStep — принять шаг TOTP, только если он новее последнего принятого (код одноразовый)
+func (r *MFARepository) UseStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return res.RowsAffected > 0, res.Error
+}
+
+// ReplaceRecoveryCodesTx — старые коды перестают действовать
+func (r *MFARepository) ReplaceRecoveryCodesTx(ctx context.Context, tx *gorm.DB, userID uint, hashes []string) error {
+	if err := tx.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]models.MFARecoveryCode, len(hashes))
+	for i, h := range hashes {
+		codes[i] = models.MFARecoveryCode{UserID: userID, CodeHash: h}
+	}
+	return tx.WithContext(ctx).Create(&codes).Error
+}
+
+// UseRecoveryCode — false, если кода нет или он уже использован
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now().UTC())
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *MFARepository) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).Count(&n).Error
+	return n, err
+}
+
+// DeleteTx выключает 2FA: секрет и коды восстановления
+func (r *MFARepository) DeleteTx(ctx context.Context, tx *gorm.DB, userID uint) error {
+	if err := tx.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return tx.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error
+}
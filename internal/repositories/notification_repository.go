@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"go_blog/models"
+	"go_blog/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// UpsertTx: без GroupKey — просто вставка; с GroupKey — если есть непрочитанное
+// уведомление той же группы, обновляем последнего actor'а; счётчик растёт только
+// на нового actor'а (ActorCount — сколько разных людей, а не сколько действий)
+func (r *NotificationRepository) UpsertTx(ctx context.Context, tx *gorm.DB, n *models.Notification) error {
+	if n.ActorCount == 0 {
+		n.ActorCount = 1
+	}
+	if n.Data == "" {
+		n.Data = "{}"
+	}
+
+	q := tx.WithContext(ctx)
+	if n.GroupKey == "" {
+		return q.Create(n).Error
+	}
+
+	// xmax = 0 — строка только что вставлена, иначе обновлена существующая группа
+	now := time.Now()
+	var row struct {
+		ID       uint
+		Inserted bool
+	}
+	err := q.Raw(`
+		INSERT INTO notifications (user_id, type, group_key, actor_user_id, actor_count, post_id, comment_id, data, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, group_key) WHERE read_at IS NULL AND group_key <> ''
+		DO UPDATE SET actor_user_id = excluded.actor_user_id, data = excluded.data, updated_at = excluded.updated_at
+		RETURNING id, xmax = 0 AS inserted`,
+		n.UserID, n.Type, n.GroupKey, n.ActorUserID, n.ActorCount, n.PostID, n.CommentID, n.Data, now, now).
+		Scan(&row).Error
+	if err != nil {
+		return err
+	}
+	n.ID, n.CreatedAt, n.UpdatedAt = row.ID, now, now
+
+	res := q.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.NotificationActor{NotificationID: n.ID, ActorUserID: n.ActorUserID})
+	if res.Error != nil || res.RowsAffected == 0 || row.Inserted {
+		return res.Error
+	}
+	// группы, созданные до учёта actor'ов, сохраняют накопленный счётчик
+	return q.Model(&models.Notification{}).Where("id = ?", n.ID).
+		Update("actor_count", gorm.Expr("actor_count + 1")).Error
+}
+
+func (r *NotificationRepository) ListForUser(ctx context.Context, userID uint, unreadOnly bool, page, limit int) ([]models.Notification, int64, error) {
+	q := r.db.WithContext(ctx).Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		q = q.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var items []models.Notification
+	err := q.Order("updated_at desc, id desc").
+		Offset(utils.Offset(page, limit)).
+		Limit(limit).
+		Find(&items).Error
+	return items, total, err
+}
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&n).Error
+	return n, err
+}
+
+// MarkRead: gorm.ErrRecordNotFound, если уведомления нет или оно чужое
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id uint) error {
+	var n models.Notification
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&n).Error; err != nil {
+		return err
+	}
+	if n.ReadAt != nil {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&n).Update("read_at", time.Now().UTC()).Error
+}
+
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now().UTC())
+	return res.RowsAffected, res.Error
+}
+
+func (r *NotificationRepository) IsEnabledTx(ctx context.Context, tx *gorm.DB, userID uint, typ string) (bool, error) {
+	var pref models.NotificationPreference
+	err := tx.WithContext(ctx).Where("user_id = ? AND type = ?", userID, typ).Limit(1).Find(&pref).Error
+	if err != nil {
+		return false, err
+	}
+	if pref.UserID == 0 {
+		return true, nil
+	}
+	return pref.Enabled, nil
+}
+
+// Preferences — только явно заданные; остальные типы включены
+func (r *NotificationRepository) Preferences(ctx context.Context, userID uint) (map[string]bool, error) {
+	var prefs []models.NotificationPreference
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool, len(prefs))
+	for _, p := range prefs {
+		out[p.Type] = p.Enabled
+	}
+	return out, nil
+}
+
+func (r *NotificationRepository) SetPreferences(ctx context.Context, userID uint, prefs map[string]bool) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	rows := make([]models.NotificationPreference, 0, len(prefs))
+	for typ, enabled := range prefs {
+		rows = append(rows, models.NotificationPreference{UserID: userID, Type: typ, Enabled: enabled})
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+	}).Create(&rows).Error
+}
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"context"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func like(userID, actorID uint) *models.Notification {
+	postID := uint(1)
+	return &models.Notification{
+		UserID:      userID,
+		Type:        "post.liked",
+		GroupKey:    "post.liked:1",
+		ActorUserID: actorID,
+		PostID:      &postID,
+	}
+}
+
+func TestNotificationRepository_Upsert_AggregatesUnreadGroup(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := NewNotificationRepository(tx)
+	ctx := context.Background()
+
+	for actor := uint(10); actor < 60; actor++ {
+		require.NoError(t, repo.UpsertTx(ctx, tx, like(1, actor)))
+	}
+
+	items, total, err := repo.ListForUser(ctx, 1, false, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	require.Equal(t, 50, items[0].ActorCount)
+	require.Equal(t, uint(59), items[0].ActorUserID)
+}
+
+func TestNotificationRepository_ReadGroupStartsNewOne(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := NewNotificationRepository(tx)
+	ctx := context.Background()
+
+	require.NoError(t, repo.UpsertTx(ctx, tx, like(1, 10)))
+	n, err := repo.MarkAllRead(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	require.NoError(t, repo.UpsertTx(ctx, tx, like(1, 11)))
+
+	_, total, err := repo.ListForUser(ctx, 1, false, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), total)
+
+	unread, _, err := repo.ListForUser(ctx, 1, true, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, unread, 1)
+	require.Equal(t, 1, unread[0].ActorCount)
+}
+
+func TestNotificationRepository_UngroupedAreSeparate(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := NewNotificationRepository(tx)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repo.UpsertTx(ctx, tx, &models.Notification{UserID: 1, Type: "comment.created", ActorUserID: 2}))
+	}
+
+	count, err := repo.CountUnread(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+}
+
+func TestNotificationRepository_MarkRead_OnlyOwn(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := NewNotificationRepository(tx)
+	ctx := context.Background()
+
+	n := like(1, 10)
+	require.NoError(t, repo.UpsertTx(ctx, tx, n))
+
+	err := repo.MarkRead(ctx, 2, n.ID)
+	require.True(t, IsNotFound(err))
+
+	require.NoError(t, repo.MarkRead(ctx, 1, n.ID))
+	count, err := repo.CountUnread(ctx, 1)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+func TestNotificationRepository_Preferences(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := NewNotificationRepository(tx)
+	ctx := context.Background()
+
+	enabled, err := repo.IsEnabledTx(ctx, tx, 1, "post.liked")
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	require.NoError(t, repo.SetPreferences(ctx, 1, map[string]bool{"post.liked": false}))
+	enabled, err = repo.IsEnabledTx(ctx, tx, 1, "post.liked")
+	require.NoError(t, err)
+	require.False(t, enabled)
+
+	require.NoError(t, repo.SetPreferences(ctx, 1, map[string]bool{"post.liked": true}))
+	prefs, err := repo.Preferences(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"post.liked": true}, prefs)
+}
+
+func TestNotificationRepository_Upsert_CountsDistinctActors(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	repo := NewNotificationRepository(tx)
+	ctx := context.Background()
+
+	// лайк, снятие, снова лайк от того же пользователя — один actor
+	require.NoError(t, repo.UpsertTx(ctx, tx, like(1, 10)))
+	require.NoError(t, repo.UpsertTx(ctx, tx, like(1, 10)))
+
+	items, _, err := repo.ListForUser(ctx, 1, true, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, 1, items[0].ActorCount)
+
+	require.NoError(t, repo.UpsertTx(ctx, tx, like(1, 11)))
+	require.NoError(t, repo.UpsertTx(ctx, tx, like(1, 10)))
+
+	items, _, err = repo.ListForUser(ctx, 1, true, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, 2, items[0].ActorCount)
+	require.Equal(t, uint(10), items[0].ActorUserID)
+}
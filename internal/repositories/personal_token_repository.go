@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"go_blog/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lastUsedGranularity — last_used_at пишется не чаще: токен CI дёргают на каждый запрос
+const lastUsedGranularity = time.Minute
+
+type PersonalTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalTokenRepository(db *gorm.DB) *PersonalTokenRepository {
+	return &PersonalTokenRepository{db: db}
+}
+
+func (r *PersonalTokenRepository) Create(ctx context.Context, t *models.PersonalAccessToken) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *PersonalTokenRepository) ListByUser(ctx context.Context, userID uint) ([]models.PersonalAccessToken, error) {
+	var out []models.PersonalAccessToken
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&out).Error
+	return out, err
+}
+
+func (r *PersonalTokenRepository) CountByUser(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&models.PersonalAccessToken{}).Where("user_id = ?", userID).Count(&n).Error
+	return n, err
+}
+
+// Delete — false, если такого токена у пользователя нет
+func (r *PersonalTokenRepository) Delete(ctx context.Context, userID, id uint) (bool, error) {
+	res := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.PersonalAccessToken{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// DeleteByUser — все токены пользователя (смена и сброс пароля)
+func (r *PersonalTokenRepository) DeleteByUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.PersonalAccessToken{}).Error
+}
+
+// FindActiveByHash — действующий токен активного пользователя вместе с пользователем (роль);
+// просроченный — gorm.ErrRecordNotFound
+func (r *PersonalTokenRepository) FindActiveByHash(ctx context.Context, hash string, now time.Time) (*models.PersonalAccessToken, error) {
+	var t models.PersonalAccessToken
+	err := r.db.WithContext(ctx).
+		Joins("User").
+		Where("personal_access_tokens.token_hash = ?", hash).
+		Where("(personal_access_tokens.expires_at IS NULL OR personal_access_tokens.expires_at > ?)", now).
+		Where(`"User".is_active = ?`, true).
+		First(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *PersonalTokenRepository) TouchLastUsed(ctx context.Context, id uint, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-lastUsedGranularity)).
+		Update("last_used_at", now).Error
+}
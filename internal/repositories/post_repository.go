@@ -244,6 +244,86 @@ func (r *PostRepository) UpdateOwnedBy(ctx context.Context, slug string, uid uin
 	return &post, nil
 }
 
+// UpdateOwnedByTx — как UpdateOwnedBy, но в чужой транзакции; кэш чистит вызывающий после commit (Invalidate)
+func (r *PostRepository) UpdateOwnedByTx(ctx context.Context, tx *gorm.DB, slug string, uid uint, updates map[string]any) (*models.Post, error) {
+	return r.updateTx(ctx, tx, slug, uid, updates)
+}
+
+// UpdateAnyTx — правка чужого поста (модерация): без проверки автора
+func (r *PostRepository) UpdateAnyTx(ctx context.Context, tx *gorm.DB, slug string, updates map[string]any) (*models.Post, error) {
+	return r.updateTx(ctx, tx, slug, 0, updates)
+}
+
+func (r *PostRepository) updateTx(ctx context.Context, tx *gorm.DB, slug string, uid uint, updates map[string]any) (*models.Post, error) {
+	post, err := findActivePostTx(ctx, tx, slug, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.WithContext(ctx).Model(post).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.WithContext(ctx).First(post, post.ID).Error; err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+func (r *PostRepository) DeleteOwnedByTx(ctx context.Context, tx *gorm.DB, slug string, uid uint) (*models.Post, error) {
+	return r.deleteTx(ctx, tx, slug, uid)
+}
+
+// DeleteAnyTx — удаление чужого поста (модерация): без проверки автора
+func (r *PostRepository) DeleteAnyTx(ctx context.Context, tx *gorm.DB, slug string) (*models.Post, error) {
+	return r.deleteTx(ctx, tx, slug, 0)
+}
+
+func (r *PostRepository) deleteTx(ctx context.Context, tx *gorm.DB, slug string, uid uint) (*models.Post, error) {
+	post, err := findActivePostTx(ctx, tx, slug, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.WithContext(ctx).Delete(post).Error; err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// findActivePostTx: uid == 0 — любого автора
+func findActivePostTx(ctx context.Context, tx *gorm.DB, slug string, uid uint) (*models.Post, error) {
+	q := tx.WithContext(ctx).Where("slug = ? AND is_active = ?", slug, true)
+	if uid != 0 {
+		q = q.Where("user_id = ?", uid)
+	}
+
+	var post models.Post
+	if err := q.First(&post).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// GetBySlugUnscoped — включая удалённые (для истории поста), без кэша
+func (r *PostRepository) GetBySlugUnscoped(ctx context.Context, slug string) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).Unscoped().Where("slug = ?", slug).First(&post).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// Invalidate сбрасывает кэш поста и списков
+func (r *PostRepository) Invalidate(ctx context.Context, slug string) {
+	if r.rdb != nil {
+		_ = r.rdb.Del(ctx, postBySlugKey(slug)).Err()
+	}
+	r.bumpListVersion(ctx)
+}
+
 func (r *PostRepository) DeleteOwnedBy(ctx context.Context, slug string, uid uint) error {
 	var post models.Post
 	if err := r.db.WithContext(ctx).Where("slug = ? AND user_id = ? AND is_active = ?", slug, uid, true).First(&post).Error; err != nil {
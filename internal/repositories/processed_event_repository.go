@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"go_blog/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProcessedEventRepository struct {
+	db *gorm.DB
+}
+
+func NewProcessedEventRepository(db *gorm.DB) *ProcessedEventRepository {
+	return &ProcessedEventRepository{db: db}
+}
+
+// MarkProcessedTx возвращает false, если событие уже было обработано этим consumer'ом.
+// Важно: вызывается ИЗ транзакции обработчика — при откате отметка тоже откатится.
+func (r *ProcessedEventRepository) MarkProcessedTx(ctx context.Context, tx *gorm.DB, consumerName, eventID string) (bool, error) {
+	res := tx.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.ProcessedEvent{
+			ConsumerName: consumerName,
+			EventID:      eventID,
+			ProcessedAt:  time.Now().UTC(),
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *ProcessedEventRepository) IsProcessed(ctx context.Context, consumerName, eventID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.ProcessedEvent{}).
+		Where("consumer_name = ? AND event_id = ?", consumerName, eventID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// DeleteByConsumerTx снимает все отметки consumer'а — перед перестройкой его проекции
+func (r *ProcessedEventRepository) DeleteByConsumerTx(ctx context.Context, tx *gorm.DB, consumerName string) error {
+	return tx.WithContext(ctx).
+		Where("consumer_name = ?", consumerName).
+		Delete(&models.ProcessedEvent{}).Error
+}
@@ -5,7 +5,17 @@ import (
 	"errors"
 	"github.com/jackc/pgx/v5/pgconn"
 	"go_blog/models"
+	"go_blog/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+// Фильтр статуса в Search
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
 )
 
 var ErrUserExists = errors.New("user already exists")
@@ -19,7 +29,11 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 }
 
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
-	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+	return r.CreateTx(ctx, r.db, user)
+}
+
+func (r *UserRepository) CreateTx(ctx context.Context, tx *gorm.DB, user *models.User) error {
+	if err := tx.WithContext(ctx).Create(user).Error; err != nil {
 		if errors.Is(err, gorm.ErrDuplicatedKey) {
 			return ErrUserExists
 		}
@@ -47,3 +61,107 @@ func (r *UserRepository) FindByID(ctx context.Context, id uint) (*models.User, e
 	}
 	return &user, nil
 }
+
+// MarkEmailVerified — только если адрес не сменился с момента выдачи ссылки.
+// false — уже подтверждён или email другой.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id uint, email string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND email = ? AND email_verified_at IS NULL", id, email).
+		Update("email_verified_at", time.Now().UTC())
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, id uint, hash string) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("password", hash).Error
+}
+
+// UpdatePasswordTx — вызывается ИЗ транзакции (вместе с событием в outbox)
+func (r *UserRepository) UpdatePasswordTx(ctx context.Context, tx *gorm.DB, id uint, hash string) error {
+	return tx.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("password", hash).Error
+}
+
+// UpdateRoleTx — вызывается ИЗ транзакции (вместе с событием в outbox)
+func (r *UserRepository) UpdateRoleTx(ctx context.Context, tx *gorm.DB, id uint, role string) error {
+	return tx.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+// EmailTaken — с учётом удалённых: уникальный индекс их тоже видит
+func (r *UserRepository) EmailTaken(ctx context.Context, email string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("email = ?", email).Count(&n).Error
+	return n > 0, err
+}
+
+// ChangeEmailTx меняет адрес, только если текущий всё ещё oldEmail; новый адрес сразу подтверждён.
+// false — адрес уже сменился.
+func (r *UserRepository) ChangeEmailTx(ctx context.Context, tx *gorm.DB, id uint, oldEmail, newEmail string) (bool, error) {
+	res := tx.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND email = ?", id, oldEmail).
+		Updates(map[string]any{"email": newEmail, "email_verified_at": time.Now().UTC()})
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrDuplicatedKey) {
+			return false, ErrUserExists
+		}
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// Search — для админки: включая заблокированных; q — подстрока ника или адреса
+func (r *UserRepository) Search(ctx context.Context, q, status string, page, limit int) ([]models.User, int64, error) {
+	db := r.db.WithContext(ctx).Model(&models.User{})
+
+	if qNorm := strings.TrimSpace(q); qNorm != "" {
+		db = db.Where("(nickname ILIKE ? OR email ILIKE ?)", "%"+qNorm+"%", "%"+qNorm+"%")
+	}
+	switch status {
+	case UserStatusActive:
+		db = db.Where("is_active = ?", true)
+	case UserStatusSuspended:
+		db = db.Where("is_active = ?", false)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	if err := db.Order("id desc").Limit(limit).Offset(utils.Offset(page, limit)).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// SuspendTx — повторная блокировка заменяет причину и срок
+func (r *UserRepository) SuspendTx(ctx context.Context, tx *gorm.DB, id uint, reason string, until *time.Time, now time.Time) error {
+	return tx.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(map[string]any{
+		"is_active":         false,
+		"suspended_at":      now,
+		"suspended_until":   until,
+		"suspension_reason": reason,
+	}).Error
+}
+
+// ReactivateTx — false, если пользователь не заблокирован
+func (r *UserRepository) ReactivateTx(ctx context.Context, tx *gorm.DB, id uint) (bool, error) {
+	res := tx.WithContext(ctx).Model(&models.User{}).Where("id = ? AND is_active = ?", id, false).Updates(map[string]any{
+		"is_active":         true,
+		"suspended_at":      nil,
+		"suspended_until":   nil,
+		"suspension_reason": "",
+	})
+	return res.RowsAffected > 0, res.Error
+}
+
+// LockExpiredSuspensionsTx — блокировки со сроком, истёкшим к now; строки заняты до конца транзакции,
+// параллельный экземпляр их пропустит
+func (r *UserRepository) LockExpiredSuspensionsTx(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := tx.WithContext(ctx).Model(&models.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("is_active = ? AND suspended_until IS NOT NULL AND suspended_until <= ?", false, now).
+		Order("id").Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,209 @@
+package repositories
+
+import (
+	"context"
+	"go_blog/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, sub *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(sub).Error
+}
+
+func (r *WebhookRepository) FindOwnedBy(ctx context.Context, id, userID uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&sub).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *WebhookRepository) ListByUser(ctx context.Context, userID uint) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id asc").Find(&subs).Error
+	return subs, err
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, sub *models.WebhookSubscription, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(sub).Updates(updates).Error
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, sub *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Delete(sub).Error
+}
+
+// ActiveForEvent — активные подписки на тип события (или на все события "*")
+func (r *WebhookRepository) ActiveForEvent(ctx context.Context, eventType string) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := r.db.WithContext(ctx).
+		Where("is_active = ?", true).
+		Where("(',' || event_types || ',') LIKE ? OR event_types = '*'", "%,"+eventType+",%").
+		Find(&subs).Error
+	return subs, err
+}
+
+// CreateDeliveries идемпотентен: (subscription_id, event_id) уникальны
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, items []models.WebhookDelivery) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&items).Error
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID uint, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	db := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var items []models.WebhookDelivery
+	err := db.
+		Preload("AttemptsLog", func(db *gorm.DB) *gorm.DB { return db.Order("attempt_no asc") }).
+		Order("id desc").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&items).Error
+	return items, total, err
+}
+
+// ClaimDue берёт пачку доставок, которым пора уходить, и сдвигает им next_attempt_at на lease,
+// чтобы параллельный воркер их не взял. Упадём посреди отправки — через lease доставка вернётся.
+func (r *WebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var items []models.WebhookDelivery
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		if err := tx.
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookPending, now).
+			Order("next_attempt_at asc").
+			Limit(limit).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Find(&items).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(items))
+		for _, it := range items {
+			ids = append(ids, it.ID)
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	subIDs := make([]uint, 0, len(items))
+	for _, it := range items {
+		subIDs = append(subIDs, it.SubscriptionID)
+	}
+	var subs []models.WebhookSubscription
+	if err := r.db.WithContext(ctx).Unscoped().Where("id IN ?", subIDs).Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.WebhookSubscription, len(subs))
+	for _, s := range subs {
+		byID[s.ID] = s
+	}
+	for i := range items {
+		items[i].Subscription = byID[items[i].SubscriptionID]
+	}
+
+	return items, nil
+}
+
+func (r *WebhookRepository) MarkSucceeded(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if err := tx.Model(&models.WebhookDelivery{}).Where("id = ?", d.ID).Updates(map[string]any{
+			"status":           models.WebhookSucceeded,
+			"attempts":         attempt.AttemptNo,
+			"last_status_code": attempt.StatusCode,
+			"last_error":       "",
+			"delivered_at":     &now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.WebhookSubscription{}).Where("id = ?", d.SubscriptionID).
+			Update("failure_count", 0).Error
+	})
+}
+
+// MarkFailed: nextAttemptAt == nil — попытки кончились. Возвращает true, если подписку
+// пришлось выключить: disableAfter неудач подряд.
+func (r *WebhookRepository) MarkFailed(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt, nextAttemptAt *time.Time, disableAfter int) (bool, error) {
+	disabled := false
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]any{
+			"attempts":         attempt.AttemptNo,
+			"last_status_code": attempt.StatusCode,
+			"last_error":       attempt.Error,
+		}
+		if nextAttemptAt == nil {
+			updates["status"] = models.WebhookFailed
+		} else {
+			updates["next_attempt_at"] = *nextAttemptAt
+		}
+		if err := tx.Model(&models.WebhookDelivery{}).Where("id = ?", d.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		var sub models.WebhookSubscription
+		if err := tx.Model(&sub).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "failure_count"}, {Name: "is_active"}}}).
+			Where("id = ?", d.SubscriptionID).
+			Update("failure_count", gorm.Expr("failure_count + 1")).Error; err != nil {
+			return err
+		}
+
+		if sub.IsActive && disableAfter > 0 && sub.FailureCount >= disableAfter {
+			now := time.Now().UTC()
+			disabled = true
+			return tx.Model(&models.WebhookSubscription{}).Where("id = ?", d.SubscriptionID).Updates(map[string]any{
+				"is_active":       false,
+				"disabled_at":     &now,
+				"disabled_reason": "too many consecutive delivery failures",
+			}).Error
+		}
+		return nil
+	})
+
+	return disabled, err
+}
+
+// FailPending — подписку выключили/удалили, оставшиеся доставки больше не шлём
+func (r *WebhookRepository) FailPending(ctx context.Context, subscriptionID uint, reason string) error {
+	return r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("subscription_id = ? AND status = ?", subscriptionID, models.WebhookPending).
+		Updates(map[string]any{
+			"status":     models.WebhookFailed,
+			"last_error": reason,
+		}).Error
+}
@@ -0,0 +1,32 @@
+package requestid
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+const Header = "X-Request-ID"
+
+type ctxKey struct{}
+
+// принимаем чужой id только если он короткий и без мусора (он уходит в логи и БД)
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+func New() string {
+	return uuid.NewString()
+}
+
+func IsValid(id string) bool {
+	return validID.MatchString(id)
+}
+
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
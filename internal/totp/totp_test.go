@@ -0,0 +1,58 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238, приложение B (SHA1, 8 цифр — сравниваем последние 6)
+func TestCode_RFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	for unix, want := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		got, err := Code(secret, Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, want, got, unix)
+	}
+}
+
+func TestValidate_WindowAndStep(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	now := time.Unix(1_700_000_000, 0)
+
+	prev, err := Code(secret, Step(now)-1)
+	require.NoError(t, err)
+	step, ok := Validate(secret, prev, now)
+	require.True(t, ok)
+	require.Equal(t, Step(now)-1, step)
+
+	old, err := Code(secret, Step(now)-2)
+	require.NoError(t, err)
+	_, ok = Validate(secret, old, now)
+	require.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now)
+	require.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("go blog", "ann@test.com", "ABC"))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", u.Scheme)
+	require.Equal(t, "totp", u.Host)
+	require.Equal(t, "/go blog:ann@test.com", u.Path)
+	require.Equal(t, "ABC", u.Query().Get("secret"))
+	require.Equal(t, "go blog", u.Query().Get("issuer"))
+}
@@ -0,0 +1,125 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"go_blog/models"
+	"log"
+	"strconv"
+	"time"
+)
+
+type Store interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	MarkSucceeded(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt) error
+	MarkFailed(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt, nextAttemptAt *time.Time, disableAfter int) (bool, error)
+	FailPending(ctx context.Context, subscriptionID uint, reason string) error
+}
+
+type Config struct {
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	DisableAfter int // неудачных попыток подряд, после которых подписка выключается
+	Lease        time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		BatchSize:    20,
+		MaxAttempts:  8,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   6 * time.Hour,
+		DisableAfter: 20,
+		Lease:        time.Minute,
+	}
+}
+
+type Dispatcher struct {
+	store  Store
+	sender *Sender
+	cfg    Config
+	now    func() time.Time
+}
+
+func NewDispatcher(store Store, sender *Sender, cfg Config) *Dispatcher {
+	return &Dispatcher{store: store, sender: sender, cfg: cfg, now: time.Now}
+}
+
+// RunOnce отправляет одну пачку; возвращает, сколько доставок было взято
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	items, err := d.store.ClaimDue(ctx, d.cfg.BatchSize, d.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range items {
+		if err := d.deliver(ctx, &items[i]); err != nil {
+			log.Printf("webhook delivery %d: %v", items[i].ID, err)
+		}
+	}
+	return len(items), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, item *models.WebhookDelivery) error {
+	sub := item.Subscription
+	if !sub.IsActive || sub.DeletedAt.Valid {
+		return d.store.FailPending(ctx, item.SubscriptionID, "subscription disabled")
+	}
+
+	attemptNo := item.Attempts + 1
+
+	res := d.sender.Send(ctx, Request{
+		URL:        sub.URL,
+		Secret:     sub.Secret,
+		DeliveryID: strconv.FormatUint(uint64(item.ID), 10),
+		EventType:  item.EventType,
+		Body:       []byte(item.Payload),
+	})
+
+	attempt := &models.WebhookAttempt{
+		DeliveryID: item.ID,
+		AttemptNo:  attemptNo,
+		StatusCode: res.StatusCode,
+		DurationMs: res.Duration.Milliseconds(),
+	}
+
+	if res.OK() {
+		return d.store.MarkSucceeded(ctx, item, attempt)
+	}
+
+	if res.Err != nil {
+		attempt.Error = res.Err.Error()
+	} else {
+		attempt.Error = fmt.Sprintf("unexpected status %d", res.StatusCode)
+	}
+
+	var next *time.Time
+	if attemptNo < d.cfg.MaxAttempts {
+		t := d.now().UTC().Add(d.backoff(attemptNo))
+		next = &t
+	}
+
+	disabled, err := d.store.MarkFailed(ctx, item, attempt, next, d.cfg.DisableAfter)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		log.Printf("webhook subscription %d disabled after repeated failures", item.SubscriptionID)
+		return d.store.FailPending(ctx, item.SubscriptionID, "subscription disabled after repeated failures")
+	}
+	return nil
+}
+
+// backoff: base * 2^(attempt-1), не больше MaxBackoff
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	b := d.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		b *= 2
+		if b >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+	return b
+}
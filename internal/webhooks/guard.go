@@ -0,0 +1,100 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress — адрес внутри нашей сети: вебхук туда не ходит (SSRF)
+var ErrForbiddenAddress = errors.New("webhook target address is not public")
+
+// blockedPrefixes — сети, которые netip не считает приватными, но наружу они не ведут
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // «эта сеть»: 0.x.x.x у Linux уходит на локальный хост
+	netip.MustParsePrefix("100.64.0.0/10"),  // CGNAT, внутренний у многих облаков
+	netip.MustParsePrefix("198.18.0.0/15"),  // стенды для тестов производительности
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64: внутри IPv4-адрес, в том числе приватный
+	netip.MustParsePrefix("64:ff9b:1::/48"), // NAT64 для локального использования
+}
+
+// IsPublicAddr — только публичные unicast-адреса
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!slices.ContainsFunc(blockedPrefixes, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// CheckURL — при создании подписки: все адреса хоста должны быть публичными.
+// Окончательная проверка — при соединении (guardedControl), DNS мог смениться.
+func CheckURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("%w: invalid url", ErrForbiddenAddress)
+	}
+
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil {
+		if !IsPublicAddr(addr) {
+			return ErrForbiddenAddress
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: cannot resolve %s", ErrForbiddenAddress, u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !IsPublicAddr(addr) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// guardedControl проверяет уже разрешённый адрес перед connect — защищает и от DNS rebinding
+func guardedControl(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !IsPublicAddr(ap.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ap.Addr())
+	}
+	return nil
+}
+
+// noRedirects — редирект мог бы увести запрос во внутреннюю сеть; 3xx считается неудачей
+func noRedirects(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// newGuardedClient — клиент по умолчанию для Sender: только публичные адреса, без прокси и редиректов
+func newGuardedClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: guardedControl}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        20,
+			IdleConnTimeout:     30 * time.Second,
+		},
+		CheckRedirect: noRedirects,
+	}
+}
@@ -0,0 +1,74 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxDrainBody — сколько ответа дочитываем, чтобы соединение вернулось в пул; тело не сохраняется
+const maxDrainBody = 1024
+
+type Result struct {
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+func (r Result) OK() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender: nil — клиент только для публичных адресов и без редиректов (см. guard.go)
+func NewSender(client *http.Client) *Sender {
+	if client == nil {
+		client = newGuardedClient(10 * time.Second)
+	}
+	return &Sender{client: client}
+}
+
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryID string
+	EventType  string
+	Body       []byte
+}
+
+func (s *Sender) Send(ctx context.Context, req Request) Result {
+	start := time.Now()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return Result{Err: err}
+	}
+
+	ts := time.Now().Unix()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "go_blog-webhooks/1.0")
+	httpReq.Header.Set(HeaderEvent, req.EventType)
+	httpReq.Header.Set(HeaderDelivery, req.DeliveryID)
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, ts, req.Body))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return Result{Err: err, Duration: time.Since(start)}
+	}
+	defer resp.Body.Close()
+
+	// тело ответа не храним и не показываем: иначе вебхук читал бы чужие ответы
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBody))
+
+	return Result{
+		StatusCode: resp.StatusCode,
+		Duration:   time.Since(start),
+	}
+}
@@ -0,0 +1,284 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"go_blog/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	mu        sync.Mutex
+	due       []models.WebhookDelivery
+	succeeded []uint
+	failed    []failedCall
+	attempts  []models.WebhookAttempt
+	failCount map[uint]int
+	pending   []uint
+}
+
+type failedCall struct {
+	deliveryID uint
+	next       *time.Time
+}
+
+func (s *fakeStore) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.due
+	s.due = nil
+	return out, nil
+}
+
+func (s *fakeStore) MarkSucceeded(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.succeeded = append(s.succeeded, d.ID)
+	s.attempts = append(s.attempts, *attempt)
+	delete(s.failCount, d.SubscriptionID)
+	return nil
+}
+
+func (s *fakeStore) MarkFailed(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt, next *time.Time, disableAfter int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = append(s.failed, failedCall{deliveryID: d.ID, next: next})
+	s.attempts = append(s.attempts, *attempt)
+	if s.failCount == nil {
+		s.failCount = map[uint]int{}
+	}
+	s.failCount[d.SubscriptionID]++
+	return disableAfter > 0 && s.failCount[d.SubscriptionID] >= disableAfter, nil
+}
+
+func (s *fakeStore) FailPending(ctx context.Context, subscriptionID uint, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, subscriptionID)
+	return nil
+}
+
+func delivery(id uint, sub models.WebhookSubscription, attempts int) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:             id,
+		SubscriptionID: sub.ID,
+		Subscription:   sub,
+		EventID:        "evt-1",
+		EventType:      "CommentCreated",
+		Payload:        `{"id":"evt-1"}`,
+		Status:         models.WebhookPending,
+		Attempts:       attempts,
+	}
+}
+
+func subscription(url string) models.WebhookSubscription {
+	sub := models.WebhookSubscription{URL: url, Secret: "whsec_test", IsActive: true}
+	sub.ID = 7
+	return sub
+}
+
+func newTestDispatcher(store Store, cfg Config) *Dispatcher {
+	// httptest слушает 127.0.0.1 — обычный клиент без SSRF-защиты
+	d := NewDispatcher(store, NewSender(&http.Client{Timeout: 5 * time.Second}), cfg)
+	d.now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+	return d
+}
+
+func TestSign_Verify(t *testing.T) {
+	body := []byte(`{"a":1}`)
+	ts := time.Now().Unix()
+	sig := Sign("secret", ts, body)
+
+	require.True(t, Verify("secret", sig, strconv.FormatInt(ts, 10), body, time.Minute))
+	require.False(t, Verify("other", sig, strconv.FormatInt(ts, 10), body, time.Minute))
+	require.False(t, Verify("secret", sig, strconv.FormatInt(ts, 10), []byte(`{"a":2}`), time.Minute))
+	require.False(t, Verify("secret", sig, strconv.FormatInt(ts-3600, 10), body, time.Minute))
+}
+
+func TestDispatcher_SignedDeliverySucceeds(t *testing.T) {
+	var gotEvent, gotDelivery string
+	var verified bool
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified = Verify("whsec_test", r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp), body, 5*time.Minute)
+		gotEvent = r.Header.Get(HeaderEvent)
+		gotDelivery = r.Header.Get(HeaderDelivery)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	store := &fakeStore{due: []models.WebhookDelivery{delivery(42, subscription(srv.URL), 0)}}
+	d := newTestDispatcher(store, DefaultConfig())
+
+	n, err := d.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	require.True(t, verified)
+	require.Equal(t, "CommentCreated", gotEvent)
+	require.Equal(t, "42", gotDelivery)
+	require.Equal(t, []uint{42}, store.succeeded)
+	require.Len(t, store.attempts, 1)
+	require.Equal(t, 1, store.attempts[0].AttemptNo)
+	require.Equal(t, http.StatusNoContent, store.attempts[0].StatusCode)
+}
+
+func TestDispatcher_FailureSchedulesRetryWithBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("boom"))
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig()
+	store := &fakeStore{due: []models.WebhookDelivery{delivery(1, subscription(srv.URL), 2)}}
+	d := newTestDispatcher(store, cfg)
+
+	_, err := d.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, store.failed, 1)
+	require.NotNil(t, store.failed[0].next)
+	// третья попытка: base * 2^2
+	require.Equal(t, d.now().Add(4*cfg.BaseBackoff), *store.failed[0].next)
+
+	require.Equal(t, 3, store.attempts[0].AttemptNo)
+	require.Contains(t, store.attempts[0].Error, "500")
+}
+
+func TestDispatcher_LastAttemptGivesUp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig()
+	store := &fakeStore{due: []models.WebhookDelivery{delivery(1, subscription(srv.URL), cfg.MaxAttempts-1)}}
+	d := newTestDispatcher(store, cfg)
+
+	_, err := d.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, store.failed, 1)
+	require.Nil(t, store.failed[0].next)
+}
+
+func TestDispatcher_DisablesSubscriptionAfterRepeatedFailures(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DisableAfter = 2
+
+	// соединение закрыто — ответа нет вообще
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	sub := subscription(url)
+	store := &fakeStore{due: []models.WebhookDelivery{delivery(1, sub, 0), delivery(2, sub, 0)}}
+	d := newTestDispatcher(store, cfg)
+
+	_, err := d.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, store.failed, 2)
+	require.Equal(t, 0, store.attempts[0].StatusCode)
+	require.NotEmpty(t, store.attempts[0].Error)
+	require.Equal(t, []uint{sub.ID}, store.pending)
+}
+
+func TestDispatcher_InactiveSubscriptionIsNotCalled(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	sub := subscription(srv.URL)
+	sub.IsActive = false
+	store := &fakeStore{due: []models.WebhookDelivery{delivery(1, sub, 0)}}
+	d := newTestDispatcher(store, DefaultConfig())
+
+	_, err := d.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	require.False(t, called)
+	require.Empty(t, store.attempts)
+	require.Equal(t, []uint{sub.ID}, store.pending)
+}
+
+func TestCheckURL_RejectsInternalAddresses(t *testing.T) {
+	for _, raw := range []string{
+		"http://127.0.0.1/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]:8080/hook",
+		"http://0.0.0.0/hook",
+		"http://100.64.0.1/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+	} {
+		err := CheckURL(context.Background(), raw)
+		require.ErrorIs(t, err, ErrForbiddenAddress, raw)
+	}
+	require.NoError(t, CheckURL(context.Background(), "https://93.184.216.34/hook"))
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	for _, tc := range []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.0.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"100.128.0.1", true},
+		{"198.18.0.1", false},
+		{"198.19.255.254", false},
+		{"198.20.0.1", true},
+		{"224.0.0.1", false},
+		{"::1", false},
+		{"::", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+		{"64:ff9b::5db8:d822", false},
+		{"64:ff9b:1::1", false},
+	} {
+		require.Equal(t, tc.public, IsPublicAddr(netip.MustParseAddr(tc.addr)), tc.addr)
+	}
+}
+
+func TestSender_DefaultClientRefusesLoopback(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	res := NewSender(nil).Send(context.Background(), Request{URL: srv.URL, Secret: "secret", DeliveryID: "1", EventType: "post.created", Body: []byte(`{}`)})
+	require.Error(t, res.Err)
+	require.True(t, errors.Is(res.Err, ErrForbiddenAddress), res.Err.Error())
+	require.False(t, called)
+}
+
+func TestSender_DoesNotFollowRedirects(t *testing.T) {
+	require.ErrorIs(t, noRedirects(nil, nil), http.ErrUseLastResponse)
+}
@@ -0,0 +1,236 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"go_blog/internal/realtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxMessageSize = 4096
+	// как часто перепроверять, что токен не отозван (logout, «выйти везде», блокировка)
+	defaultRecheck = 30 * time.Second
+)
+
+// Session — чем авторизовано соединение: сокет живёт не дольше токена
+// и закрывается, как только Check сообщит об отзыве
+type Session struct {
+	ExpiresAt time.Time
+	Check     func(ctx context.Context) error
+	// Recheck — период проверки; 0 — defaultRecheck
+	Recheck time.Duration
+}
+
+// ClientMessage — команды от клиента
+//
+//	{"op":"subscribe","types":["comment.reply"]}
+//	{"op":"unsubscribe","types":["post.liked"]}
+//	{"op":"ping"}
+type ClientMessage struct {
+	Op    string   `json:"op"`
+	Types []string `json:"types"`
+}
+
+// ServerMessage — всё, что уходит клиенту
+type ServerMessage struct {
+	Op    string          `json:"op"` // notification | subscribed | pong | error
+	Type  string          `json:"type,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Types []string        `json:"types,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// filter — на какие типы уведомлений подписан клиент; по умолчанию на все
+type filter struct {
+	mu    sync.RWMutex
+	types map[string]struct{}
+}
+
+func newFilter() *filter {
+	f := &filter{types: make(map[string]struct{})}
+	for _, t := range realtime.NotificationTypes {
+		f.types[t] = struct{}{}
+	}
+	return f
+}
+
+func (f *filter) allows(t string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	_, ok := f.types[t]
+	return ok
+}
+
+func (f *filter) apply(op string, types []string) ([]string, bool) {
+	for _, t := range types {
+		if !slices.Contains(realtime.NotificationTypes, t) {
+			return nil, false
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range types {
+		if op == "subscribe" {
+			f.types[t] = struct{}{}
+		} else {
+			delete(f.types, t)
+		}
+	}
+
+	out := make([]string, 0, len(f.types))
+	for _, t := range realtime.NotificationTypes {
+		if _, ok := f.types[t]; ok {
+			out = append(out, t)
+		}
+	}
+	return out, true
+}
+
+// Serve обслуживает соединение до разрыва. sub — подписка на топик пользователя;
+// если hub отключает её из-за переполнения буфера (клиент не успевает читать),
+// закрываем соединение с 1013 — клиент переподключится. Истёкший или отозванный
+// токен закрывает соединение с 1008.
+func Serve(conn *websocket.Conn, sub *realtime.Subscription, sess Session) {
+	defer sub.Close()
+
+	f := newFilter()
+	// ответы на команды идут через writer, чтобы писал в conn только один goroutine
+	replies := make(chan ServerMessage, 8)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		readLoop(conn, f, replies)
+	}()
+
+	writeLoop(conn, sub, sess, f, replies, done)
+	conn.Close()
+	<-done
+}
+
+func readLoop(conn *websocket.Conn, f *filter, replies chan<- ServerMessage) {
+	conn.SetReadLimit(maxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var reply ServerMessage
+		var msg ClientMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			reply = ServerMessage{Op: "error", Error: "invalid json"}
+		} else {
+			switch msg.Op {
+			case "subscribe", "unsubscribe":
+				if types, ok := f.apply(msg.Op, msg.Types); ok {
+					reply = ServerMessage{Op: "subscribed", Types: types}
+				} else {
+					reply = ServerMessage{Op: "error", Error: "unknown notification type"}
+				}
+			case "ping":
+				reply = ServerMessage{Op: "pong"}
+			default:
+				reply = ServerMessage{Op: "error", Error: "unknown op"}
+			}
+		}
+
+		select {
+		case replies <- reply:
+		default:
+			// клиент шлёт команды быстрее, чем читает ответы
+			return
+		}
+	}
+}
+
+func writeLoop(conn *websocket.Conn, sub *realtime.Subscription, sess Session, f *filter, replies <-chan ServerMessage, done <-chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	// без срока — nil-канал, ветка никогда не сработает
+	var expired <-chan time.Time
+	if !sess.ExpiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(sess.ExpiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var recheck <-chan time.Time
+	if sess.Check != nil {
+		every := sess.Recheck
+		if every <= 0 {
+			every = defaultRecheck
+		}
+		rt := time.NewTicker(every)
+		defer rt.Stop()
+		recheck = rt.C
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case <-expired:
+			closeWith(conn, websocket.ClosePolicyViolation, "token expired")
+			return
+
+		case <-recheck:
+			ctx, cancel := context.WithTimeout(context.Background(), writeWait)
+			err := sess.Check(ctx)
+			cancel()
+			if err != nil {
+				closeWith(conn, websocket.ClosePolicyViolation, "access revoked")
+				return
+			}
+
+		case <-sub.Dropped():
+			closeWith(conn, websocket.CloseTryAgainLater, "slow consumer")
+			return
+
+		case m := <-sub.C:
+			if !f.allows(m.Event) {
+				continue
+			}
+			if err := write(conn, ServerMessage{Op: "notification", Type: m.Event, Data: m.Data}); err != nil {
+				return
+			}
+
+		case r := <-replies:
+			if err := write(conn, r); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func closeWith(conn *websocket.Conn, code int, reason string) {
+	_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+}
+
+func write(conn *websocket.Conn, m ServerMessage) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return conn.WriteJSON(m)
+}
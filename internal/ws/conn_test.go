@@ -0,0 +1,116 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"go_blog/internal/realtime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe_SlowConsumerIsClosed(t *testing.T) {
+	hub := realtime.NewHub(nil, 1)
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub := hub.Subscribe("user:1")
+		// буфер на 1 — второе сообщение переполняет его, hub отключает подписку
+		hub.Deliver("user:1", realtime.Message{Event: realtime.NotifyPostLiked, Data: []byte(`{}`)})
+		hub.Deliver("user:1", realtime.Message{Event: realtime.NotifyPostLiked, Data: []byte(`{}`)})
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		Serve(conn, sub, Session{})
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue // успел уйти буферизованный
+		}
+		require.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater), err)
+		return
+	}
+}
+
+func serveWith(t *testing.T, sess Session) *websocket.Conn {
+	t.Helper()
+	hub := realtime.NewHub(nil, 8)
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub := hub.Subscribe("user:1")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			sub.Close()
+			return
+		}
+		Serve(conn, sub, sess)
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	return conn
+}
+
+func TestServe_ClosedWhenTokenExpires(t *testing.T) {
+	conn := serveWith(t, Session{ExpiresAt: time.Now().Add(100 * time.Millisecond)})
+
+	_, _, err := conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err)
+}
+
+func TestServe_ClosedWhenAccessRevoked(t *testing.T) {
+	var revoked atomic.Bool
+	conn := serveWith(t, Session{
+		ExpiresAt: time.Now().Add(time.Hour),
+		Recheck:   20 * time.Millisecond,
+		Check: func(ctx context.Context) error {
+			if revoked.Load() {
+				return errors.New("revoked")
+			}
+			return nil
+		},
+	})
+
+	// пока не отозван — соединение живо и отвечает
+	require.NoError(t, conn.WriteJSON(ClientMessage{Op: "ping"}))
+	var reply ServerMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+	require.Equal(t, "pong", reply.Op)
+
+	revoked.Store(true)
+	_, _, err := conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err)
+}
+
+func TestFilter(t *testing.T) {
+	f := newFilter()
+	require.True(t, f.allows(realtime.NotifyPostLiked))
+
+	types, ok := f.apply("unsubscribe", []string{realtime.NotifyPostLiked})
+	require.True(t, ok)
+	require.Equal(t, []string{realtime.NotifyCommentCreated, realtime.NotifyCommentReply, realtime.NotifyUserFollowed}, types)
+	require.False(t, f.allows(realtime.NotifyPostLiked))
+
+	_, ok = f.apply("subscribe", []string{"unknown"})
+	require.False(t, ok)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"go_blog/config"
+	"go_blog/internal/adapters/mailer/file"
+	"go_blog/internal/adapters/mailer/smtp"
+	"go_blog/internal/mail"
+	"go_blog/internal/ports"
+	"go_blog/internal/repositories"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	digest := flag.Bool("digest", false, "enqueue the weekly digest and exit (run from cron once a week)")
+	flag.Parse()
+
+	config.ConnectDB()
+
+	cfg := mail.ConfigFromEnv()
+	outbox := repositories.NewMailOutboxRepository(config.DB)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *digest {
+		composer, err := mail.NewComposer(cfg)
+		if err != nil {
+			log.Fatalf("mail composer: %v", err)
+		}
+		n, err := mail.NewDigest(repositories.NewFollowRepository(config.DB), outbox, composer).Run(ctx, time.Now())
+		if err != nil {
+			log.Fatalf("digest: %v", err)
+		}
+		log.Printf("weekly digest: %d emails queued", n)
+		return
+	}
+
+	if n, err := outbox.ScrubFinished(ctx); err != nil {
+		log.Println("mail outbox scrub:", err)
+	} else if n > 0 {
+		log.Printf("mail outbox: scrubbed %d finished emails", n)
+	}
+
+	worker := mail.NewWorker(outbox, newMailer(cfg), mail.DefaultWorkerConfig())
+
+	log.Println("mail worker started")
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("mail worker stopped")
+			return
+		case <-ticker.C:
+			// пока есть работа — не ждём следующий тик
+			for {
+				n, err := worker.RunOnce(ctx)
+				if err != nil {
+					log.Println("mail error:", err)
+					break
+				}
+				if n == 0 || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+func newMailer(cfg mail.Config) ports.Mailer {
+	switch cfg.Driver {
+	case "smtp":
+		return smtp.New(smtp.Config{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUser,
+			Password: cfg.SMTPPass,
+			From:     cfg.From,
+		})
+	case "file":
+		m, err := file.New(cfg.FileDir, cfg.From)
+		if err != nil {
+			log.Fatalf("mail dir: %v", err)
+		}
+		return m
+	default:
+		log.Fatalf("unknown MAIL_DRIVER %q (smtp, file)", cfg.Driver)
+		return nil
+	}
+}
@@ -1,19 +1,60 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"go_blog/config"
+	"go_blog/internal/migrations"
+	"go_blog/internal/realtime"
+	"go_blog/internal/repositories"
 	"go_blog/models"
 	"go_blog/routes"
+	"go_blog/services"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-
 	config.ConnectDB()
 	config.InitRedis()
-	config.DB.AutoMigrate(&models.User{}, &models.Post{}, &models.RefreshToken{}, &models.PostLike{}, &models.Comment{}, &models.AuditLog{}, &models.OutboxEvent{})
+	config.DB.AutoMigrate(&models.User{}, &models.Post{}, &models.PostLike{}, &models.Comment{}, &models.AuditLog{}, &models.OutboxEvent{}, &models.ProcessedEvent{}, &models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.WebhookAttempt{}, &models.Follow{}, &models.Notification{}, &models.NotificationActor{}, &models.NotificationPreference{}, &models.MailOutbox{}, &models.UserMFA{}, &models.MFARecoveryCode{}, &models.Identity{}, &models.PersonalAccessToken{})
+
+	if err := migrations.Run(context.Background(), config.DB, migrations.All); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// SSE: одна подписка на Redis pub/sub на инстанс, после сбоя поднимается заново
+	hub := realtime.NewHub(config.RDB, 64)
+	go hub.Supervise(ctx, time.Second)
 
-	r := routes.SetupRoutes()
+	// блокировки аккаунтов со сроком снимаются фоном
+	expiry := services.NewSuspensionExpiry(config.DB, repositories.NewUserRepository(config.DB), repositories.NewOutboxRepository(config.DB))
+	go expiry.Run(ctx, time.Minute)
 
-	r.Run(":8080")
+	srv := &http.Server{
+		Addr:    ":8080",
+		Handler: routes.SetupRoutes(hub),
+		// SSE-стримы живут на ctx запроса — при остановке они должны закрыться сами
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
 
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("server shutdown:", err)
+	}
 }
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"go_blog/internal/clientinfo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientInfo кладёт User-Agent и IP клиента в context запроса (метаданные сессий)
+func ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		info := clientinfo.New(c.Request.UserAgent(), c.ClientIP())
+		c.Request = c.Request.WithContext(clientinfo.WithContext(c.Request.Context(), info))
+		c.Next()
+	}
+}
@@ -0,0 +1,94 @@
+package middleware_test
+
+import (
+	"go_blog/config"
+	"go_blog/middleware"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"go_blog/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func setupScopedApp() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) }
+	r.GET("/session-only", middleware.RequireAuth(), ok)
+	r.POST("/posts", middleware.RequireAuth(models.ScopePostsWrite), ok)
+	r.GET("/me", middleware.RequireAuth(models.ScopeRead), ok)
+
+	return r
+}
+
+func TestRequireAuth_SessionJWTIgnoresScopes(t *testing.T) {
+	app := setupScopedApp()
+
+	token, err := utils.GenerateAccessJWT(1, models.RoleUser)
+	require.NoError(t, err)
+
+	for _, path := range []string{"/session-only", "/me"} {
+		resp := testhelpers.DoRequest(app, testhelpers.NewAuthRequest("GET", path, token))
+		require.Equal(t, http.StatusOK, resp.Code, path)
+	}
+	resp := testhelpers.DoRequest(app, testhelpers.NewAuthRequest("POST", "/posts", token))
+	require.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestRequireAuth_PersonalTokenScopes(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	prev := config.DB
+	config.DB = tx
+	t.Cleanup(func() { config.DB = prev })
+
+	user := &models.User{Nickname: "ci", Email: "ci@test.com", Password: "x", IsActive: true, Role: models.RoleUser}
+	require.NoError(t, tx.Create(user).Error)
+
+	newToken := func(scopes string, expiresAt *time.Time) string {
+		plain, hash, err := utils.NewPersonalToken()
+		require.NoError(t, err)
+		require.NoError(t, tx.Create(&models.PersonalAccessToken{
+			UserID: user.ID, Name: "ci", TokenHash: hash, Prefix: plain[:8], Scopes: scopes, ExpiresAt: expiresAt,
+		}).Error)
+		return plain
+	}
+
+	app := setupScopedApp()
+	read := newToken(models.ScopeRead, nil)
+
+	resp := testhelpers.DoRequest(app, testhelpers.NewAuthRequest("GET", "/me", read))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var touched models.PersonalAccessToken
+	require.NoError(t, tx.Where("token_hash = ?", utils.HashRefresh(read)).First(&touched).Error)
+	require.NotNil(t, touched.LastUsedAt)
+
+	// без scope маршрута и на маршрутах только для сессий — 403
+	resp = testhelpers.DoRequest(app, testhelpers.NewAuthRequest("POST", "/posts", read))
+	require.Equal(t, http.StatusForbidden, resp.Code)
+	resp = testhelpers.DoRequest(app, testhelpers.NewAuthRequest("GET", "/session-only", read))
+	require.Equal(t, http.StatusForbidden, resp.Code)
+
+	writer := newToken(models.ScopePostsWrite+","+models.ScopeRead, nil)
+	resp = testhelpers.DoRequest(app, testhelpers.NewAuthRequest("POST", "/posts", writer))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	past := time.Now().Add(-time.Hour)
+	expired := newToken(models.ScopeRead, &past)
+	resp = testhelpers.DoRequest(app, testhelpers.NewAuthRequest("GET", "/me", expired))
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	resp = testhelpers.DoRequest(app, testhelpers.NewAuthRequest("GET", "/me", utils.PersonalTokenPrefix+"unknown"))
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	// деактивированный пользователь теряет и токены доступа
+	require.NoError(t, tx.Model(user).Update("is_active", false).Error)
+	resp = testhelpers.DoRequest(app, testhelpers.NewAuthRequest("GET", "/me", read))
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"go_blog/internal/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID берёт X-Request-ID клиента (или генерирует свой), кладёт его в context
+// запроса и отдаёт обратно в ответе — дальше он уходит в outbox и audit log
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.IsValid(id) {
+			id = requestid.New()
+		}
+
+		c.Set("requestID", id)
+		c.Request = c.Request.WithContext(requestid.WithContext(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,61 @@
+package middleware_test
+
+import (
+	"go_blog/internal/requestid"
+	"go_blog/middleware"
+	"go_blog/testhelpers"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRequestIDApp() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(middleware.RequestID())
+
+	r.GET("/id", func(c *gin.Context) {
+		c.String(http.StatusOK, requestid.FromContext(c.Request.Context()))
+	})
+
+	return r
+}
+
+func TestRequestID_KeepsClientHeader(t *testing.T) {
+	app := setupRequestIDApp()
+
+	req := testhelpers.NewAuthRequest("GET", "/id", "")
+	req.Header.Set("X-Request-ID", "client-req-42")
+	resp := testhelpers.DoRequest(app, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "client-req-42", resp.Body.String())
+	require.Equal(t, "client-req-42", resp.Header().Get("X-Request-ID"))
+}
+
+func TestRequestID_GeneratesWhenMissing(t *testing.T) {
+	app := setupRequestIDApp()
+
+	resp := testhelpers.DoRequest(app, testhelpers.NewAuthRequest("GET", "/id", ""))
+
+	id := resp.Header().Get("X-Request-ID")
+	require.NotEmpty(t, id)
+	require.Equal(t, id, resp.Body.String())
+}
+
+func TestRequestID_ReplacesGarbage(t *testing.T) {
+	app := setupRequestIDApp()
+
+	req := testhelpers.NewAuthRequest("GET", "/id", "")
+	req.Header.Set("X-Request-ID", "bad id\n"+strings.Repeat("x", 100))
+	resp := testhelpers.DoRequest(app, req)
+
+	id := resp.Header().Get("X-Request-ID")
+	require.NotContains(t, id, " ")
+	require.LessOrEqual(t, len(id), 64)
+	require.Equal(t, id, resp.Body.String())
+}
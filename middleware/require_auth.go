@@ -1,14 +1,139 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"go_blog/config"
+	"go_blog/internal/repositories"
+	"go_blog/stores"
 	"go_blog/utils"
+	"log"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func RequireAuth() gin.HandlerFunc {
+var (
+	ErrInvalidToken   = errors.New("invalid token")
+	ErrInvalidSubject = errors.New("invalid subject")
+	ErrTokenRevoked   = errors.New("token revoked")
+	ErrScopeDenied    = errors.New("token scope does not allow this action")
+)
+
+// Principal — кто стоит за access-токеном
+type Principal struct {
+	UserID    uint
+	Role      string
+	SessionID string
+	TokenID   string // jti
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// токен доступа (gbp_…): ID и права; у JWT сессии пусто — ограничений нет
+	PersonalTokenID uint
+	Scopes          []string
+}
+
+// Allows — JWT сессии можно всё, токену доступа — только с одним из scopes маршрута;
+// маршрут без scopes токены доступа не принимает
+func (p Principal) Allows(scopes []string) bool {
+	if p.PersonalTokenID == 0 {
+		return true
+	}
+	for _, sc := range scopes {
+		if slices.Contains(p.Scopes, sc) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate — проверка access JWT без привязки к HTTP (используется и для /ws)
+func Authenticate(tokenStr string) (Principal, error) {
+	token, claims, err := utils.ParseAccessJWT(tokenStr)
+	if err != nil || !token.Valid {
+		return Principal{}, ErrInvalidToken
+	}
+	uid, ok := claims["sub"].(float64)
+	if !ok || uid <= 0 {
+		return Principal{}, ErrInvalidSubject
+	}
+
+	jti, _ := claims["jti"].(string)
+	iat, err := claims.GetIssuedAt()
+	if jti == "" || err != nil || iat == nil {
+		return Principal{}, ErrInvalidToken
+	}
+	exp, _ := claims.GetExpirationTime() // обязателен, проверен при разборе
+
+	role, _ := claims["role"].(string)
+	sid, _ := claims["sid"].(string)
+	return Principal{
+		UserID:    uint(uid),
+		Role:      role,
+		SessionID: sid,
+		TokenID:   jti,
+		IssuedAt:  iat.Time,
+		ExpiresAt: exp.Time,
+	}, nil
+}
+
+// AuthenticatePersonalToken — токен доступа по hash в БД: удалённый, истёкший
+// или токен неактивного пользователя не проходит. last_used_at обновляется попутно.
+func AuthenticatePersonalToken(ctx context.Context, plain string) (Principal, error) {
+	if config.DB == nil {
+		return Principal{}, ErrInvalidToken
+	}
+
+	repo := repositories.NewPersonalTokenRepository(config.DB)
+	now := time.Now().UTC()
+	t, err := repo.FindActiveByHash(ctx, utils.HashRefresh(plain), now)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Principal{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+
+	if err := repo.TouchLastUsed(ctx, t.ID, now); err != nil {
+		log.Printf("personal token %d: touch last_used_at: %v", t.ID, err)
+	}
+
+	return Principal{
+		UserID:          t.UserID,
+		Role:            t.User.Role,
+		PersonalTokenID: t.ID,
+		Scopes:          t.ScopeList(),
+	}, nil
+}
+
+// CheckRevoked — denylist по jti, отозванная сессия и метка пользователя за одну поездку в Redis.
+// Без Redis проверка выключена, как и RateLimit.
+func CheckRevoked(ctx context.Context, p Principal) error {
+	if config.RDB == nil {
+		return nil
+	}
+
+	revoked, err := stores.NewAccessRevocationRedisStore(config.RDB).IsRevoked(ctx, stores.AccessToken{
+		ID:        p.TokenID,
+		UserID:    p.UserID,
+		SessionID: p.SessionID,
+		IssuedAt:  p.IssuedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// RequireAuth принимает JWT сессии и токены доступа; scopes — какие токены доступа пускать на маршрут
+func RequireAuth(scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		header := c.GetHeader("Authorization")
 		if !strings.HasPrefix(header, "Bearer ") {
@@ -17,23 +142,36 @@ func RequireAuth() gin.HandlerFunc {
 			return
 		}
 		tokenStr := strings.TrimPrefix(header, "Bearer ")
-		token, claims, err := utils.ParseAccessJWT(tokenStr)
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error": "invalid token"})
+
+		var p Principal
+		var err error
+		if utils.IsPersonalToken(tokenStr) {
+			// токен отзывается удалением из БД — denylist не нужен
+			p, err = AuthenticatePersonalToken(c.Request.Context(), tokenStr)
+		} else if p, err = Authenticate(tokenStr); err == nil {
+			err = CheckRevoked(c.Request.Context(), p)
+		}
+		if err != nil {
+			if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrInvalidSubject) || errors.Is(err, ErrTokenRevoked) {
+				c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error": err.Error()})
+			} else {
+				// отзыв нельзя проверить — не пускаем
+				c.JSON(http.StatusServiceUnavailable, gin.H{"ok": false, "error": "auth temporarily unavailable"})
+			}
 			c.Abort()
 			return
 		}
-		uid, ok := claims["sub"].(float64)
-		if !ok || uid <= 0 {
-			c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error": "invalid subject"})
+
+		if !p.Allows(scopes) {
+			c.JSON(http.StatusForbidden, gin.H{"ok": false, "error": ErrScopeDenied.Error()})
 			c.Abort()
 			return
 		}
 
-		role, _ := claims["role"].(string)
-
-		c.Set("userID", uint(uid))
-		c.Set("role", role)
+		c.Set("userID", p.UserID)
+		c.Set("role", p.Role)
+		c.Set("sessionID", p.SessionID)
+		c.Set("personalTokenID", p.PersonalTokenID)
 		c.Next()
 	}
 }
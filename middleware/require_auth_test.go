@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -59,20 +60,70 @@ func TestRequireAuth_InvalidToken(t *testing.T) {
 func TestRequireAuth_ExpiredToken(t *testing.T) {
 	app := setupProtectedApp()
 
-	req := testhelpers.NewAuthRequest("GET", "/protected", expiredToken())
+	req := testhelpers.NewAuthRequest("GET", "/protected", expiredToken(t))
 	resp := testhelpers.DoRequest(app, req)
 
 	require.Equal(t, http.StatusUnauthorized, resp.Code)
 }
 
-func expiredToken() string {
+func TestRequireAuth_WrongIssuerOrAudience(t *testing.T) {
+	app := setupProtectedApp()
+
+	for _, claims := range []jwt.MapClaims{
+		accessClaims(jwt.MapClaims{"iss": "someone-else"}),
+		accessClaims(jwt.MapClaims{"aud": "another-service"}),
+		accessClaims(jwt.MapClaims{"aud": nil}),
+	} {
+		resp := testhelpers.DoRequest(app, testhelpers.NewAuthRequest("GET", "/protected", signAccess(t, claims)))
+		require.Equal(t, http.StatusUnauthorized, resp.Code, claims)
+	}
+}
+
+func TestRequireAuth_RejectsSymmetricToken(t *testing.T) {
+	app := setupProtectedApp()
+
+	// прежний формат: HS256 на общем секрете
+	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims(nil))
+	tok.Header["kid"] = "ephemeral"
+	token, err := tok.SignedString([]byte("asd@123#"))
+	require.NoError(t, err)
+
+	resp := testhelpers.DoRequest(app, testhelpers.NewAuthRequest("GET", "/protected", token))
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func accessClaims(override jwt.MapClaims) jwt.MapClaims {
 	claims := jwt.MapClaims{
+		"iss":  utils.JWTIssuer(),
+		"aud":  utils.JWTAudience(),
+		"jti":  uuid.NewString(),
 		"sub":  1,
 		"role": "user",
-		"iat":  time.Now().Add(-2 * time.Hour).Unix(),
-		"exp":  time.Now().Add(-1 * time.Hour).Unix(),
+		"iat":  time.Now().Unix(),
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range override {
+		if v == nil {
+			delete(claims, k)
+			continue
+		}
+		claims[k] = v
 	}
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token, _ := t.SignedString([]byte("asd@123#"))
+	return claims
+}
+
+func signAccess(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	kr, err := utils.AccessKeyring()
+	require.NoError(t, err)
+	token, err := kr.Sign(claims)
+	require.NoError(t, err)
 	return token
 }
+
+func expiredToken(t *testing.T) string {
+	return signAccess(t, accessClaims(jwt.MapClaims{
+		"iat": time.Now().Add(-2 * time.Hour).Unix(),
+		"exp": time.Now().Add(-1 * time.Hour).Unix(),
+	}))
+}
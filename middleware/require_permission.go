@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"go_blog/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission ставится после RequireAuth: роль из токена должна давать все перечисленные права
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, perm := range perms {
+			if !models.HasPermission(role, perm) {
+				c.JSON(http.StatusForbidden, gin.H{"ok": false, "error": "forbidden"})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
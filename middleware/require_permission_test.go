@@ -0,0 +1,44 @@
+package middleware_test
+
+import (
+	"go_blog/middleware"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"go_blog/utils"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(middleware.RequireAuth())
+	r.DELETE("/posts/x", middleware.RequirePermission(models.PermPostDeleteAny), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	r.PUT("/admin/role", middleware.RequirePermission(models.PermUserRoleAssign), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	for _, tc := range []struct {
+		role, method, path string
+		want               int
+	}{
+		{models.RoleUser, "DELETE", "/posts/x", http.StatusForbidden},
+		{models.RoleModerator, "DELETE", "/posts/x", http.StatusOK},
+		{models.RoleAdmin, "DELETE", "/posts/x", http.StatusOK},
+		{models.RoleModerator, "PUT", "/admin/role", http.StatusForbidden},
+		{models.RoleAdmin, "PUT", "/admin/role", http.StatusOK},
+		{"root", "PUT", "/admin/role", http.StatusForbidden},
+	} {
+		token, err := utils.GenerateAccessJWT(1, tc.role)
+		require.NoError(t, err)
+
+		resp := testhelpers.DoRequest(r, testhelpers.NewAuthRequest(tc.method, tc.path, token))
+		require.Equal(t, tc.want, resp.Code, "%s %s %s", tc.role, tc.method, tc.path)
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail ставится после RequireAuth: пускает только с подтверждённым email
+func RequireVerifiedEmail(isVerified func(ctx context.Context, uid uint) (bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := c.GetUint("userID")
+
+		ok, err := isVerified(c.Request.Context(), uid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": "failed to check email verification"})
+			c.Abort()
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"ok": false, "error": "email not verified"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
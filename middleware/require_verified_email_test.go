@@ -0,0 +1,44 @@
+package middleware_test
+
+import (
+	"context"
+	"go_blog/middleware"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"go_blog/utils"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func setupVerifiedApp(verified map[uint]bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(middleware.RequireAuth(), middleware.RequireVerifiedEmail(func(ctx context.Context, uid uint) (bool, error) {
+		return verified[uid], nil
+	}))
+
+	r.POST("/posts", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	return r
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	app := setupVerifiedApp(map[uint]bool{1: true})
+
+	verified, err := utils.GenerateAccessJWT(1, models.RoleUser)
+	require.NoError(t, err)
+	resp := testhelpers.DoRequest(app, testhelpers.NewAuthRequest("POST", "/posts", verified))
+	require.Equal(t, http.StatusCreated, resp.Code)
+
+	unverified, err := utils.GenerateAccessJWT(2, models.RoleUser)
+	require.NoError(t, err)
+	resp = testhelpers.DoRequest(app, testhelpers.NewAuthRequest("POST", "/posts", unverified))
+	require.Equal(t, http.StatusForbidden, resp.Code)
+	require.Contains(t, resp.Body.String(), "email not verified")
+}
@@ -5,11 +5,13 @@ import "time"
 type AuditLog struct {
 	ID            uint      `gorm:"primaryKey"`
 	EventID       string    `gorm:"size:36;uniqueIndex;not null"`
-	EventType     string    `gorm:"size:50;not null"`
-	AggregateType string    `gorm:"size:50;not null"`
-	AggregateID   string    `gorm:"size:50;not null"`
-	ActorUserID   string    `gorm:"size:50"`
-	Payload       string    `gorm:"type:jsonb;not null"`
-	OccurredAt    time.Time `gorm:"not null"`
+	EventType     string    `gorm:"size:50;not null;index"`
+	AggregateType string    `gorm:"size:50;not null;index:idx_audit_aggregate"`
+	AggregateID   string    `gorm:"size:50;not null;index:idx_audit_aggregate"`
+	ActorUserID   string    `gorm:"size:50;index"`
+	CorrelationID string    `gorm:"size:64;index"`
+	Version       int       `gorm:"not null;default:1"` // версия схемы payload
+	Payload       string    `gorm:"type:jsonb;not null;index:idx_audit_payload,type:gin"`
+	OccurredAt    time.Time `gorm:"not null;index"`
 	CreatedAt     time.Time
 }
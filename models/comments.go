@@ -4,7 +4,8 @@ import "gorm.io/gorm"
 
 type Comment struct {
 	gorm.Model
-	PostID uint   `gorm:"index"`
-	UserID uint   `gorm:"index"`
-	Text   string `gorm:"type:text"`
+	PostID   uint   `gorm:"index"`
+	UserID   uint   `gorm:"index"`
+	ParentID *uint  `gorm:"index"` // ответ на комментарий
+	Text     string `gorm:"type:text"`
 }
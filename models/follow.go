@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type Follow struct {
+	FollowerID uint `gorm:"primaryKey"`
+	FolloweeID uint `gorm:"primaryKey;index"`
+	CreatedAt  time.Time
+}
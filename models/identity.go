@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Identity — вход через внешнего OIDC-провайдера: subject провайдера привязан к пользователю.
+// Email — каким он был у провайдера в момент привязки, для справки.
+type Identity struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	Provider  string `gorm:"size:50;not null;uniqueIndex:idx_identities_provider_subject"`
+	Subject   string `gorm:"size:255;not null;uniqueIndex:idx_identities_provider_subject"`
+	Email     string `gorm:"size:255"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+type MailStatus string
+
+const (
+	MailPending MailStatus = "PENDING"
+	MailSent    MailStatus = "SENT"
+	MailFailed  MailStatus = "FAILED"
+)
+
+// MailOutbox — письмо в очереди на отправку. Рендерится при постановке в очередь,
+// воркер только отправляет. DedupKey защищает от дублей при повторной доставке события.
+type MailOutbox struct {
+	ID            uint       `gorm:"primaryKey"`
+	UserID        *uint      `gorm:"index"`
+	DedupKey      string     `gorm:"size:150;not null;uniqueIndex"`
+	Template      string     `gorm:"size:50;not null"`
+	To            string     `gorm:"column:to_addr;size:255;not null"`
+	Subject       string     `gorm:"size:255;not null"`
+	TextBody      string     `gorm:"type:text;not null"`
+	HTMLBody      string     `gorm:"type:text;not null"`
+	Headers       string     `gorm:"type:jsonb;not null;default:'{}'"`
+	Status        MailStatus `gorm:"size:10;not null;index:idx_mail_outbox_due"`
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_mail_outbox_due"`
+	LastError     string     `gorm:"type:text"`
+	SentAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (MailOutbox) TableName() string {
+	return "mail_outbox"
+}
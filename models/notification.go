@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Notification — входящее уведомление пользователя.
+// Уведомления с одинаковым GroupKey схлопываются в одно, пока оно не прочитано
+// (50 лайков поста → одна запись с ActorCount=50).
+type Notification struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null;index:idx_notifications_user;uniqueIndex:idx_notifications_group,where:read_at IS NULL AND group_key <> ''"`
+	Type        string `gorm:"size:30;not null"`
+	GroupKey    string `gorm:"size:100;not null;default:'';uniqueIndex:idx_notifications_group,where:read_at IS NULL AND group_key <> ''"`
+	ActorUserID uint   // последний, кто сделал действие
+	ActorCount  int    `gorm:"not null;default:1"`
+	PostID      *uint
+	CommentID   *uint
+	Data        string `gorm:"type:jsonb;not null;default:'{}'"`
+	ReadAt      *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time `gorm:"index:idx_notifications_user"`
+}
+
+// NotificationActor — кто уже учтён в ActorCount группы: повторное действие
+// того же пользователя (лайк после снятия лайка) счётчик не увеличивает
+type NotificationActor struct {
+	NotificationID uint         `gorm:"primaryKey"`
+	ActorUserID    uint         `gorm:"primaryKey"`
+	Notification   Notification `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// NotificationPreference: нет записи — тип включён
+type NotificationPreference struct {
+	UserID  uint   `gorm:"primaryKey"`
+	Type    string `gorm:"primaryKey;size:30"`
+	Enabled bool   `gorm:"not null"`
+}
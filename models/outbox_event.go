@@ -17,6 +17,8 @@ type OutboxEvent struct {
 	AggregateType string       `gorm:"size:50;not null"`    // post
 	AggregateID   string       `gorm:"size:50;not null"`    // postID
 	ActorUserID   string       `gorm:"size:50"`             // кто сделал
+	CorrelationID string       `gorm:"size:64"`             // X-Request-ID запроса
+	Version       int          `gorm:"not null;default:1"`  // версия схемы payload
 	Payload       string       `gorm:"type:jsonb;not null"` // JSON строкой
 	OccurredAt    time.Time    `gorm:"not null"`
 	Status        OutboxStatus `gorm:"size:10;not null;index"` //NEW SENT
@@ -0,0 +1,41 @@
+package models
+
+import "slices"
+
+// Права: маршруты и сервисы проверяют право, а не роль
+const (
+	PermPostEditAny       = "post.edit.any"
+	PermPostDeleteAny     = "post.delete.any"
+	PermCommentModerate   = "comment.moderate"
+	PermUserView          = "user.view"
+	PermUserBan           = "user.ban"
+	PermUserPasswordReset = "user.password.reset"
+	PermUserRoleAssign    = "user.role.assign"
+	PermAuditRead         = "audit.read"
+)
+
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+// rolePermissions — сверх обычного пользователя; свои посты и комментарии может править любой
+var rolePermissions = map[string][]string{
+	RoleUser:      nil,
+	RoleModerator: {PermPostEditAny, PermPostDeleteAny, PermCommentModerate},
+	RoleAdmin: {
+		PermPostEditAny, PermPostDeleteAny, PermCommentModerate,
+		PermUserView, PermUserBan, PermUserPasswordReset, PermUserRoleAssign, PermAuditRead,
+	},
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission — неизвестная роль не даёт ничего
+func HasPermission(role, perm string) bool {
+	return slices.Contains(rolePermissions[role], perm)
+}
+
+func RolePermissions(role string) []string {
+	return slices.Clone(rolePermissions[role])
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Права токенов доступа; JWT интерактивной сессии ими не ограничен
+const (
+	ScopeRead          = "read"
+	ScopePostsWrite    = "posts:write"
+	ScopeCommentsWrite = "comments:write"
+)
+
+var Scopes = []string{ScopeRead, ScopePostsWrite, ScopeCommentsWrite}
+
+// PersonalAccessToken — именованный токен для скриптов и CI. Хранится только sha256 от токена;
+// Prefix — начало токена, чтобы пользователь узнал его в списке.
+type PersonalAccessToken struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"not null;index"`
+	User       User   `gorm:"foreignKey:UserID"`
+	Name       string `gorm:"size:100;not null"`
+	TokenHash  string `gorm:"size:64;not null;uniqueIndex"`
+	Prefix     string `gorm:"size:16;not null"`
+	Scopes     string `gorm:"size:255;not null"` // через запятую, как EventTypes у вебхуков
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Split(t.Scopes, ",")
+}
+
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	return slices.Contains(t.ScopeList(), scope)
+}
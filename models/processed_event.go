@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ProcessedEvent — отметка, что consumer уже применил событие.
+// Пишется в той же транзакции, что и побочный эффект обработчика.
+type ProcessedEvent struct {
+	ConsumerName string    `gorm:"primaryKey;size:100"`
+	EventID      string    `gorm:"primaryKey;size:36"`
+	ProcessedAt  time.Time `gorm:"not null"`
+}
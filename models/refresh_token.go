@@ -1,16 +0,0 @@
-package models
-
-import (
-	"time"
-
-	"gorm.io/gorm"
-)
-
-type RefreshToken struct {
-	gorm.Model
-	UserID    uint      `gorm:"index"`
-	TokenHash string    `gorm:"uniqueIndex;size:64;not null"`
-	ExpiresAt time.Time `gorm:"index"`
-	UserAgent string    `gorm:"size:255"`
-	IP        string    `gorm:"size:45"`
-}
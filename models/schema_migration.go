@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// SchemaMigration — разовая миграция данных уже применена; AutoMigrate такие шаги не умеет
+type SchemaMigration struct {
+	ID        string    `gorm:"primaryKey;size:100"`
+	AppliedAt time.Time `gorm:"not null"`
+}
@@ -1,6 +1,16 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
 
 type User struct {
 	gorm.Model
@@ -11,4 +21,10 @@ type User struct {
 	Comments []Comment `gorm:"foreignKey:UserID"`
 	IsActive bool      `gorm:"default:true"`
 	Role     string    `gorm:"size:20;default:'user'"`
+	// nil — адрес не подтверждён
+	EmailVerifiedAt *time.Time
+	// блокировка администратором: пока действует, IsActive = false; SuspendedUntil nil — бессрочно
+	SuspendedAt      *time.Time
+	SuspendedUntil   *time.Time `gorm:"index"`
+	SuspensionReason string     `gorm:"size:500"`
 }
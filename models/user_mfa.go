@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// UserMFA — TOTP пользователя. Пока ConfirmedAt == nil, 2FA не включена:
+// секрет выдан, но пользователь ещё не ввёл первый код.
+type UserMFA struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;uniqueIndex"`
+	SecretEnc string `gorm:"size:255;not null"`
+	// последний принятый шаг TOTP: код нельзя использовать дважды
+	LastUsedStep int64 `gorm:"not null;default:0"`
+	ConfirmedAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (UserMFA) TableName() string { return "user_mfa" }
+
+// MFARecoveryCode — одноразовый код восстановления, хранится только хэш
+type MFARecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"size:64;not null;uniqueIndex"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookSubscription struct {
+	gorm.Model
+	UserID     uint   `gorm:"not null;index"`
+	URL        string `gorm:"size:2048;not null"`
+	Secret     string `gorm:"size:100;not null"`  // нужен в открытом виде для HMAC-подписи
+	EventTypes string `gorm:"type:text;not null"` // через запятую: PostCreated,CommentCreated или *
+	IsActive   bool   `gorm:"default:true;index"`
+	// неудачные попытки подряд, сбрасывается при успешной доставке
+	FailureCount   int `gorm:"not null;default:0"`
+	DisabledAt     *time.Time
+	DisabledReason string `gorm:"size:255"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "PENDING"
+	WebhookSucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookFailed    WebhookDeliveryStatus = "FAILED"
+)
+
+// WebhookDelivery — одно событие для одной подписки
+type WebhookDelivery struct {
+	ID             uint                  `gorm:"primaryKey"`
+	SubscriptionID uint                  `gorm:"not null;uniqueIndex:idx_webhook_delivery_event"`
+	Subscription   WebhookSubscription   `gorm:"constraint:OnDelete:CASCADE;"`
+	EventID        string                `gorm:"size:36;not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventType      string                `gorm:"size:50;not null"`
+	Payload        string                `gorm:"type:jsonb;not null"` // тело запроса (CloudEvent JSON)
+	Status         WebhookDeliveryStatus `gorm:"size:10;not null;index"`
+	Attempts       int                   `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `gorm:"not null;index"`
+	LastStatusCode int
+	LastError      string `gorm:"type:text"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	AttemptsLog    []WebhookAttempt `gorm:"foreignKey:DeliveryID"`
+}
+
+// WebhookAttempt — каждый HTTP-запрос к подписчику
+type WebhookAttempt struct {
+	ID         uint   `gorm:"primaryKey"`
+	DeliveryID uint   `gorm:"not null;index"`
+	AttemptNo  int    `gorm:"not null"`
+	StatusCode int    // 0 — ответа не было (таймаут, DNS, ...)
+	Error      string `gorm:"type:text"`
+	DurationMs int64
+	CreatedAt  time.Time
+}
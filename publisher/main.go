@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"go_blog/config"
+	kafkabus "go_blog/internal/adapters/eventbus/kafka"
 	"go_blog/internal/events"
 	"go_blog/internal/repositories"
 	"log"
@@ -20,6 +21,12 @@ func main() {
 
 	outboxRepo := repositories.NewOutboxRepository(config.DB)
 
+	// envelope | cloudevents-structured | cloudevents-binary
+	format, err := kafkabus.ParseFormat(os.Getenv("EVENT_FORMAT"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP("localhost:9092"),
 		Topic:        "blog.events",
@@ -32,7 +39,7 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	log.Println("outbox publisher started")
+	log.Printf("outbox publisher started (format=%s)", format)
 
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
@@ -60,21 +67,23 @@ func main() {
 					AggregateType: it.AggregateType,
 					AggregateID:   it.AggregateID,
 					ActorUserID:   it.ActorUserID,
-					Version:       1,
+					CorrelationID: it.CorrelationID,
+					Version:       it.Version,
 					Payload:       json.RawMessage(it.Payload),
 				}
 
-				value, err := json.Marshal(env)
+				if err := events.Schemas.Validate(env); err != nil {
+					_ = outboxRepo.MarkFailed(ctx, it.ID, "invalid event: "+err.Error())
+					continue
+				}
+
+				msg, err := kafkabus.Encode(env, format)
 				if err != nil {
-					_ = outboxRepo.MarkFailed(ctx, it.ID, "marshal envelope: "+err.Error())
+					_ = outboxRepo.MarkFailed(ctx, it.ID, "encode event: "+err.Error())
 					continue
 				}
 
-				err = writer.WriteMessages(ctx, kafka.Message{
-					Key:   []byte(it.AggregateID),
-					Value: value,
-					Time:  time.Now(),
-				})
+				err = writer.WriteMessages(ctx, msg)
 				if err != nil {
 					_ = outboxRepo.MarkFailed(ctx, it.ID, "kafka publish: "+err.Error())
 					continue
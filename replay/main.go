@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"go_blog/config"
+	"go_blog/internal/handlers"
+	"go_blog/internal/mail"
+	"go_blog/internal/replay"
+	"go_blog/internal/repositories"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Примеры:
+//
+//	go run ./replay -handler webhook-fanout -types CommentCreated -from 2025-01-01T00:00:00Z -dry-run
+//	go run ./replay -source kafka -since 2025-01-01T00:00:00Z -handler audit-log -rate 200
+//	go run ./replay -handler notifications -rebuild -rate 0
+func main() {
+	source := flag.String("source", "audit", "audit | kafka")
+	handlerName := flag.String("handler", "", "handler name (required)")
+	types := flag.String("types", "", "comma-separated event types")
+	from := flag.String("from", "", "occurred_at >= (RFC3339)")
+	to := flag.String("to", "", "occurred_at < (RFC3339)")
+	dryRun := flag.Bool("dry-run", false, "only print matching events")
+	rate := flag.Float64("rate", 100, "events per second, 0 = unlimited")
+	limit := flag.Int("limit", 0, "max events to replay, 0 = all")
+	batch := flag.Int("batch", 500, "audit_logs batch size")
+	continueOnError := flag.Bool("continue-on-error", false, "log handler errors and keep going")
+	rebuild := flag.Bool("rebuild", false, "clear the handler's projection and processed marks, then replay the full history")
+	brokers := flag.String("brokers", "localhost:9092", "kafka brokers, comma-separated")
+	topic := flag.String("topic", "blog.events", "kafka topic")
+	offset := flag.Int64("offset", kafka.FirstOffset, "kafka start offset in every partition")
+	since := flag.String("since", "", "kafka start timestamp (RFC3339), overrides -offset")
+	flag.Parse()
+
+	config.ConnectDB()
+
+	composer, err := mail.NewComposer(mail.ConfigFromEnv())
+	if err != nil {
+		log.Fatalf("mail composer: %v", err)
+	}
+
+	named := handlers.Named(config.DB, composer)
+	h, ok := named[*handlerName]
+	if !ok {
+		log.Fatalf("unknown handler %q, available: %s", *handlerName, strings.Join(handlers.Names(named), ", "))
+	}
+
+	// без -rebuild уже обработанные события пропускаются (processed_events)
+	var reset func(ctx context.Context) error
+	if *rebuild {
+		resets := handlers.Resets(config.DB)
+		if reset, ok = resets[*handlerName]; !ok {
+			log.Fatalf("handler %q cannot be rebuilt, rebuildable: %s", *handlerName, strings.Join(handlers.Names(resets), ", "))
+		}
+		// частичная история после очистки дала бы неполную проекцию
+		if *types != "" || *from != "" || *to != "" || *limit != 0 {
+			log.Fatal("-rebuild replays the full history: -types, -from, -to and -limit are not allowed")
+		}
+	}
+
+	filter := replay.Filter{
+		Types: splitList(*types),
+		From:  mustTime("from", *from),
+		To:    mustTime("to", *to),
+	}
+
+	var src replay.Source
+	switch *source {
+	case "audit":
+		src = replay.NewAuditSource(repositories.NewAuditLogRepository(config.DB), filter, *batch)
+	case "kafka":
+		src = &replay.KafkaSource{
+			Brokers:     splitList(*brokers),
+			Topic:       *topic,
+			StartOffset: *offset,
+			StartTime:   mustTime("since", *since),
+		}
+	default:
+		log.Fatalf("unknown source %q", *source)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r := replay.New(src, h, replay.Options{
+		Filter:          filter,
+		DryRun:          *dryRun,
+		Rate:            *rate,
+		Limit:           *limit,
+		ContinueOnError: *continueOnError,
+		Reset:           reset,
+	})
+
+	log.Printf("replay started: source=%s handler=%s dry-run=%v rebuild=%v", *source, *handlerName, *dryRun, *rebuild)
+
+	stats, err := r.Run(ctx)
+	log.Printf("replay finished: seen=%d matched=%d handled=%d failed=%d", stats.Seen, stats.Matched, stats.Handled, stats.Failed)
+	if err != nil {
+		log.Fatalf("replay error: %v", err)
+	}
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func mustTime(name, v string) *time.Time {
+	if v == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		log.Fatalf("-%s: %v", name, err)
+	}
+	return &t
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"go_blog/controllers"
+	"go_blog/middleware"
+	"go_blog/models"
+	"go_blog/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminRoutes(r *gin.Engine, auditService *services.AuditService, roleService *services.RoleService, adminUsers *services.AdminUserService) {
+	// доступ — по правам роли, а не по имени роли
+	admin := r.Group("/admin")
+	admin.Use(middleware.RequireAuth())
+
+	admin.GET("/audit", middleware.RequirePermission(models.PermAuditRead), controllers.ListAuditLogs(auditService))
+
+	admin.GET("/users", middleware.RequirePermission(models.PermUserView), controllers.ListUsers(adminUsers))
+	admin.GET("/users/:id", middleware.RequirePermission(models.PermUserView), controllers.GetUser(adminUsers))
+	admin.POST("/users/:id/suspend", middleware.RequirePermission(models.PermUserBan), controllers.SuspendUser(adminUsers))
+	admin.POST("/users/:id/reactivate", middleware.RequirePermission(models.PermUserBan), controllers.ReactivateUser(adminUsers))
+	admin.POST("/users/:id/unlock", middleware.RequirePermission(models.PermUserBan), controllers.UnlockUser(adminUsers))
+	admin.POST("/users/:id/password-reset", middleware.RequirePermission(models.PermUserPasswordReset), controllers.ForcePasswordReset(adminUsers))
+
+	admin.GET("/roles", middleware.RequirePermission(models.PermUserRoleAssign), controllers.ListRoles())
+	admin.PUT("/users/:id/role", middleware.RequirePermission(models.PermUserRoleAssign), controllers.AssignRole(roleService))
+}
@@ -9,12 +9,31 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterAuthRoutes(r *gin.Engine, auth *services.AuthService) {
+func RegisterAuthRoutes(r *gin.Engine,
+	auth *services.AuthService,
+	sessions *services.SessionService,
+	verification *services.EmailVerificationService,
+	resets *services.PasswordResetService,
+	mfa *services.MFAService,
+	oidc *services.OIDCService,
+	oidcFrontendURL string) {
 	group := r.Group("/auth")
 	{
 		group.POST("/register", controllers.Register(auth))
 		group.POST("/login", middleware.RateLimit(5, time.Minute), controllers.Login(auth))
+		group.POST("/mfa/verify", middleware.RateLimit(10, time.Minute), controllers.VerifyMFA(mfa))
 		group.POST("/refresh", controllers.Refresh(auth))
-		group.POST("/logout", controllers.Logout(auth))
+		group.POST("/logout", controllers.Logout(auth, sessions))
+
+		group.GET("/oidc/providers", controllers.ListOIDCProviders(oidc))
+		group.GET("/oidc/:provider/start", middleware.RateLimit(20, time.Minute), controllers.OIDCStart(oidc))
+		group.GET("/oidc/:provider/callback", middleware.RateLimit(20, time.Minute), controllers.OIDCCallback(oidc, oidcFrontendURL))
+		group.POST("/oidc/exchange", middleware.RateLimit(20, time.Minute), controllers.OIDCExchange(oidc))
+
+		group.POST("/verify-email", middleware.RateLimit(10, time.Minute), controllers.VerifyEmail(verification))
+		group.POST("/verify-email/resend", middleware.RequireAuth(), middleware.RateLimit(5, time.Minute), controllers.ResendVerificationEmail(verification))
+
+		group.POST("/password/forgot", middleware.RateLimit(5, time.Minute), controllers.ForgotPassword(resets))
+		group.POST("/password/reset", middleware.RateLimit(10, time.Minute), controllers.ResetPassword(resets))
 	}
 }
@@ -2,8 +2,8 @@ package routes
 
 import (
 	"go_blog/controllers"
-	"go_blog/internal/repositories"
 	"go_blog/middleware"
+	"go_blog/models"
 	"go_blog/services"
 
 	"github.com/gin-gonic/gin"
@@ -11,25 +11,40 @@ import (
 
 func RegisterPostRoutes(r *gin.Engine,
 	postService *services.PostService,
-	commentRepo *repositories.CommentRepository,
-	likeRepo *repositories.LikeRepository) {
+	commentService *services.CommentService,
+	auditService *services.AuditService,
+	likeService *services.LikeService,
+	verification *services.EmailVerificationService) {
 	r.GET("/posts", controllers.ListPosts(postService))
 	r.GET("/posts/:slug", controllers.GetPost(postService))
 
-	r.GET("/posts/:slug/comments", controllers.ListCommentsForPost(commentRepo))
+	r.GET("/posts/:slug/comments", controllers.ListCommentsForPost(commentService))
 
-	r.GET("/posts/:slug/likes", controllers.GetPostLikes(likeRepo))
+	r.GET("/posts/:slug/likes", controllers.GetPostLikes(likeService))
 
+	// авторизация на каждом маршруте: токены доступа пускаем только туда, где указан их scope
 	auth := r.Group("/posts")
-	auth.Use(middleware.RequireAuth())
+	postsWrite := middleware.RequireAuth(models.ScopePostsWrite)
+	commentsWrite := middleware.RequireAuth(models.ScopeCommentsWrite)
 
-	auth.POST("", controllers.CreatePost(postService))
-	auth.PUT("/:slug", controllers.UpdatePost(postService))
-	auth.DELETE("/:slug", controllers.DeletePost(postService))
+	// политика EMAIL_VERIFICATION_REQUIRED: без подтверждённого email не пускаем
+	postGate, commentGate := verifiedGate(verification, services.ActionPost), verifiedGate(verification, services.ActionComment)
 
-	auth.POST("/:slug/like", controllers.LikePost(likeRepo))
-	auth.DELETE("/:slug/like", controllers.UnlikePost(likeRepo))
+	auth.POST("", postsWrite, postGate, controllers.CreatePost(postService))
+	auth.PUT("/:slug", postsWrite, controllers.UpdatePost(postService))
+	auth.DELETE("/:slug", postsWrite, controllers.DeletePost(postService))
+	auth.GET("/:slug/history", middleware.RequireAuth(models.ScopeRead), controllers.GetPostHistory(auditService))
 
-	auth.POST("/:slug/comments", controllers.CreateComment(commentRepo))
-	auth.DELETE("/comments/:id", controllers.DeleteComment(commentRepo))
+	auth.POST("/:slug/like", middleware.RequireAuth(), controllers.LikePost(likeService))
+	auth.DELETE("/:slug/like", middleware.RequireAuth(), controllers.UnlikePost(likeService))
+
+	auth.POST("/:slug/comments", commentsWrite, commentGate, controllers.CreateComment(commentService))
+	auth.DELETE("/comments/:id", commentsWrite, controllers.DeleteComment(commentService))
+}
+
+func verifiedGate(verification *services.EmailVerificationService, action string) gin.HandlerFunc {
+	if !verification.Requires(action) {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middleware.RequireVerifiedEmail(verification.IsVerified)
 }
@@ -3,33 +3,98 @@ package routes
 import (
 	"go_blog/config"
 
+	"go_blog/internal/mail"
+	"go_blog/internal/oidc"
+	"go_blog/internal/realtime"
 	"go_blog/internal/repositories"
+	"go_blog/middleware"
 	"go_blog/services"
 	"go_blog/stores"
+	"go_blog/utils"
+	"log"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes() *gin.Engine {
+func SetupRoutes(hub *realtime.Hub) *gin.Engine {
 	r := gin.Default()
+	r.Use(middleware.RequestID(), middleware.ClientInfo())
 
 	postRepo := repositories.NewPostRepository(config.DB, config.RDB)
 	commentRepo := repositories.NewCommentRepository(config.DB)
 	likeRepo := repositories.NewLikeRepository(config.DB)
 	userRepo := repositories.NewUserRepository(config.DB)
 	outboxRepo := repositories.NewOutboxRepository(config.DB)
+	webhookRepo := repositories.NewWebhookRepository(config.DB)
+	auditRepo := repositories.NewAuditLogRepository(config.DB)
+	followRepo := repositories.NewFollowRepository(config.DB)
+	notificationRepo := repositories.NewNotificationRepository(config.DB)
+
+	//ключи access-токенов: падаем на старте, а не на первом логине
+	if err := utils.CheckJWTConfig(); err != nil {
+		log.Fatalf("jwt config: %v", err)
+	}
+	keyring, err := utils.AccessKeyring()
+	if err != nil {
+		log.Fatalf("jwt keys: %v", err)
+	}
 
 	//stores
 	refreshStore := stores.NewRefreshRedisStore(config.RDB)
+	oneTimeStore := stores.NewOneTimeRedisStore(config.RDB)
+	counterStore := stores.NewCounterRedisStore(config.RDB)
+	accessRevocations := stores.NewAccessRevocationRedisStore(config.RDB)
+
+	//mail: письма только ставятся в очередь, шлёт команда mailer
+	mailCfg := mail.ConfigFromEnv()
+	composer, err := mail.NewComposer(mailCfg)
+	if err != nil {
+		log.Fatalf("mail composer: %v", err)
+	}
+	mailQueue := mail.NewQueue(composer, repositories.NewMailOutboxRepository(config.DB))
 
 	//services
-	authService := services.NewAuthService(userRepo, refreshStore)
-	userService := services.NewUserService(userRepo)
+	verificationService := services.NewEmailVerificationService(userRepo, mailQueue, oneTimeStore, counterStore, services.VerificationPolicyFromEnv())
+	securityEvents := services.NewSecurityEventService(config.DB, outboxRepo)
+	loginThrottle := services.NewLoginThrottleService(stores.NewLoginAttemptRedisStore(config.RDB), userRepo, mailQueue, services.DefaultLoginThrottlePolicy())
+	mfaService := services.NewMFAService(config.DB, repositories.NewMFARepository(config.DB), userRepo, outboxRepo, refreshStore, oneTimeStore, counterStore, loginThrottle)
+	authService := services.NewAuthService(userRepo, refreshStore, services.AuthOptions{
+		Verifier: verificationService,
+		Security: securityEvents,
+		MFA:      mfaService,
+		Guard:    loginThrottle,
+		Access:   accessRevocations,
+	})
+	oidcProviders := map[string]services.OIDCProvider{}
+	for _, cfg := range oidc.ConfigsFromEnv() {
+		oidcProviders[cfg.Name] = oidc.NewClient(cfg, nil)
+	}
+	oidcService := services.NewOIDCService(config.DB, oidcProviders, stores.NewOIDCStateRedisStore(config.RDB),
+		repositories.NewIdentityRepository(config.DB), userRepo, outboxRepo, authService)
+	personalTokenRepo := repositories.NewPersonalTokenRepository(config.DB)
+	passwordResetService := services.NewPasswordResetService(userRepo, stores.NewPasswordResetRedisStore(config.RDB), refreshStore, counterStore, mailQueue, accessRevocations, personalTokenRepo)
+	userService := services.NewUserService(config.DB, userRepo, outboxRepo, refreshStore, oneTimeStore, mailQueue, accessRevocations, personalTokenRepo)
+	sessionService := services.NewSessionService(refreshStore, accessRevocations)
 	postService := services.NewPostService(config.DB, postRepo, outboxRepo)
+	commentService := services.NewCommentService(config.DB, commentRepo, outboxRepo)
+	likeService := services.NewLikeService(config.DB, likeRepo, outboxRepo)
+	webhookService := services.NewWebhookService(webhookRepo)
+	auditService := services.NewAuditService(auditRepo, postRepo)
+	followService := services.NewFollowService(config.DB, followRepo, outboxRepo)
+	notificationService := services.NewNotificationService(notificationRepo, mail.NewSigner(mailCfg.Secret))
+	roleService := services.NewRoleService(config.DB, userRepo, outboxRepo, accessRevocations)
+	adminUserService := services.NewAdminUserService(config.DB, userRepo, repositories.NewMFARepository(config.DB),
+		repositories.NewIdentityRepository(config.DB), outboxRepo, refreshStore, accessRevocations, personalTokenRepo, passwordResetService, loginThrottle)
+	personalTokenService := services.NewPersonalTokenService(personalTokenRepo)
+	wsTicketService := services.NewWSTicketService(stores.NewWSTicketRedisStore(config.RDB))
 
-	RegisterAuthRoutes(r, authService)
-	RegisterUserRoutes(r, userService)
-	RegisterPostRoutes(r, postService, commentRepo, likeRepo)
+	RegisterWellKnownRoutes(r, keyring)
+	RegisterAuthRoutes(r, authService, sessionService, verificationService, passwordResetService, mfaService, oidcService, oidc.FrontendCallbackURL())
+	RegisterUserRoutes(r, userService, sessionService, mfaService, oidcService, personalTokenService, followService, notificationService)
+	RegisterPostRoutes(r, postService, commentService, auditService, likeService, verificationService)
+	RegisterWebhookRoutes(r, webhookService)
+	RegisterAdminRoutes(r, auditService, roleService, adminUserService)
+	RegisterStreamRoutes(r, hub, postService, wsTicketService)
 
 	return r
 }
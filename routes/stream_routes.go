@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"go_blog/controllers"
+	"go_blog/internal/realtime"
+	"go_blog/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterStreamRoutes(r *gin.Engine, hub *realtime.Hub, postService *services.PostService, wsTickets *services.WSTicketService) {
+	r.GET("/stream", controllers.StreamGlobal(hub))
+	r.GET("/posts/:slug/stream", controllers.StreamPost(hub, postService))
+	r.POST("/ws/ticket", controllers.IssueWSTicket(wsTickets))
+	r.GET("/ws", controllers.Notifications(hub, wsTickets))
+}
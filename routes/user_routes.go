@@ -3,14 +3,63 @@ package routes
 import (
 	"go_blog/controllers"
 	"go_blog/middleware"
+	"go_blog/models"
 	"go_blog/services"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterUserRoutes(r *gin.Engine, userService *services.UserService) {
+func RegisterUserRoutes(r *gin.Engine,
+	userService *services.UserService,
+	sessionService *services.SessionService,
+	mfaService *services.MFAService,
+	oidcService *services.OIDCService,
+	tokenService *services.PersonalTokenService,
+	followService *services.FollowService,
+	notificationService *services.NotificationService) {
+	// чтение профиля доступно и токенам доступа со scope read
+	readable := r.Group("/user")
+	readable.Use(middleware.RequireAuth(models.ScopeRead))
+
+	readable.GET("/me", controllers.GetCurrentUser(userService))
+	readable.GET("/me/notifications", controllers.ListNotifications(notificationService))
+
 	protected := r.Group("/user")
 	protected.Use(middleware.RequireAuth())
 
-	protected.GET("/me", controllers.GetCurrentUser(userService))
+	protected.PUT("/me/password", middleware.RateLimit(5, time.Minute), controllers.ChangePassword(userService))
+	protected.PUT("/me/email", middleware.RateLimit(5, time.Minute), controllers.ChangeEmail(userService))
+	protected.POST("/me/email/confirm", controllers.ConfirmEmailChange(userService))
+
+	protected.GET("/me/sessions", controllers.ListSessions(sessionService))
+	protected.DELETE("/me/sessions", controllers.RevokeAllSessions(sessionService))
+	protected.DELETE("/me/sessions/:id", controllers.RevokeSession(sessionService))
+
+	protected.POST("/me/mfa/enroll", controllers.EnrollMFA(mfaService))
+	protected.POST("/me/mfa/confirm", middleware.RateLimit(10, time.Minute), controllers.ConfirmMFA(mfaService))
+	protected.POST("/me/mfa/disable", middleware.RateLimit(5, time.Minute), controllers.DisableMFA(mfaService))
+	protected.POST("/me/mfa/recovery-codes", middleware.RateLimit(5, time.Minute), controllers.RegenerateRecoveryCodes(mfaService))
+
+	protected.POST("/me/identities", middleware.RateLimit(10, time.Minute), controllers.LinkIdentity(oidcService))
+
+	// управлять токенами доступа можно только из интерактивной сессии
+	protected.GET("/me/tokens", controllers.ListPersonalTokens(tokenService))
+	protected.POST("/me/tokens", middleware.RateLimit(10, time.Minute), controllers.CreatePersonalToken(tokenService))
+	protected.DELETE("/me/tokens/:id", controllers.RevokePersonalToken(tokenService))
+
+	protected.POST("/me/notifications/read-all", controllers.MarkAllNotificationsRead(notificationService))
+	protected.POST("/me/notifications/:id/read", controllers.MarkNotificationRead(notificationService))
+	protected.GET("/me/notification-preferences", controllers.GetNotificationPreferences(notificationService))
+	protected.PUT("/me/notification-preferences", controllers.UpdateNotificationPreferences(notificationService))
+
+	// ссылки из писем — без авторизации, подпись в токене; GET только показывает подтверждение
+	r.GET("/unsubscribe", controllers.UnsubscribePage(notificationService))
+	r.POST("/unsubscribe", controllers.Unsubscribe(notificationService))
+
+	users := r.Group("/users")
+	users.Use(middleware.RequireAuth())
+
+	users.POST("/:id/follow", controllers.FollowUser(followService))
+	users.DELETE("/:id/follow", controllers.UnfollowUser(followService))
 }
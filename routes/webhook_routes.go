@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"go_blog/controllers"
+	"go_blog/middleware"
+	"go_blog/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWebhookRoutes(r *gin.Engine, webhookService *services.WebhookService) {
+	wh := r.Group("/webhooks")
+	wh.Use(middleware.RequireAuth())
+
+	wh.POST("", controllers.CreateWebhook(webhookService))
+	wh.GET("", controllers.ListWebhooks(webhookService))
+	wh.GET("/:id", controllers.GetWebhook(webhookService))
+	wh.PUT("/:id", controllers.UpdateWebhook(webhookService))
+	wh.DELETE("/:id", controllers.DeleteWebhook(webhookService))
+	wh.GET("/:id/deliveries", controllers.ListWebhookDeliveries(webhookService))
+}
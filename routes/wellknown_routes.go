@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"go_blog/controllers"
+	"go_blog/internal/jwtkeys"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWellKnownRoutes(r *gin.Engine, keyring *jwtkeys.Keyring) {
+	r.GET("/.well-known/jwks.json", controllers.JWKS(keyring))
+}
@@ -0,0 +1,19 @@
+#!/usr/bin/env bash
+# Переносит позиции чтения blog.events со старой consumer group на новую.
+# Новая группа без коммитов начала бы с начала топика: realtime-обработчики
+# разослали бы клиентам всю историю. consumer делает это сам на первом старте
+# (consumer.SeedGroupOffsets); скрипт — для ручного переноса или другого имени группы.
+# Запускать при остановленном consumer.
+set -e
+
+BROKER="${BROKER:-localhost:9092}"
+FROM_GROUP="${FROM_GROUP:-audit-log-consumer}"
+TO_GROUP="${TO_GROUP:-go_blog-consumer}"
+
+docker exec -i go_blog_kafka bash -lc "\
+/opt/kafka/bin/kafka-consumer-groups.sh --bootstrap-server $BROKER --describe --group $FROM_GROUP \
+  | awk '\$2 == \"blog.events\" && \$4 ~ /^[0-9]+\$/ { print \$2 \",\" \$3 \",\" \$4 }' > /tmp/offsets.csv && \
+cat /tmp/offsets.csv && \
+/opt/kafka/bin/kafka-consumer-groups.sh --bootstrap-server $BROKER --group $TO_GROUP --reset-offsets \
+  --from-file /tmp/offsets.csv --all-topics --execute \
+"
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go_blog/dto"
+	"go_blog/internal/events"
+	"go_blog/internal/oidc"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/stores"
+	"go_blog/utils"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AdminUserService — управление аккаунтами из админки; каждое действие пишется в outbox (аудит)
+type AdminUserService struct {
+	db         *gorm.DB
+	users      *repositories.UserRepository
+	mfa        *repositories.MFARepository
+	identities *repositories.IdentityRepository
+	outbox     *repositories.OutboxRepository
+	refresh    stores.RefreshStore
+	access     stores.AccessRevocationStore
+	pats       PersonalTokenRevoker
+	resets     *PasswordResetService
+	throttle   *LoginThrottleService
+}
+
+func NewAdminUserService(db *gorm.DB, users *repositories.UserRepository, mfa *repositories.MFARepository, identities *repositories.IdentityRepository, outbox *repositories.OutboxRepository, refresh stores.RefreshStore, access stores.AccessRevocationStore, pats PersonalTokenRevoker, resets *PasswordResetService, throttle *LoginThrottleService) *AdminUserService {
+	return &AdminUserService{db: db, users: users, mfa: mfa, identities: identities, outbox: outbox, refresh: refresh, access: access, pats: pats, resets: resets, throttle: throttle}
+}
+
+func (s *AdminUserService) List(ctx context.Context, q, status string, page, limit int) ([]dto.AdminUserResponse, int64, error) {
+	users, total, err := s.users.Search(ctx, q, status, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]dto.AdminUserResponse, 0, len(users))
+	for _, u := range users {
+		out = append(out, adminUserToResp(u))
+	}
+	return out, total, nil
+}
+
+func (s *AdminUserService) Get(ctx context.Context, uid uint) (dto.AdminUserDetailResponse, error) {
+	user, err := s.find(ctx, uid)
+	if err != nil {
+		return dto.AdminUserDetailResponse{}, err
+	}
+
+	out := dto.AdminUserDetailResponse{
+		AdminUserResponse: adminUserToResp(*user),
+		Permissions:       models.RolePermissions(user.Role),
+		Identities:        []string{},
+	}
+
+	m, err := s.mfa.Find(ctx, uid)
+	switch {
+	case err == nil:
+		out.MFAEnabled = m.ConfirmedAt != nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return dto.AdminUserDetailResponse{}, err
+	}
+
+	identities, err := s.identities.ListByUser(ctx, uid)
+	if err != nil {
+		return dto.AdminUserDetailResponse{}, err
+	}
+	for _, i := range identities {
+		out.Identities = append(out.Identities, i.Provider)
+	}
+	return out, nil
+}
+
+// Suspend: доступ пропадает сразу — refresh-сессии удаляются, выданные access-токены отзываются.
+// Токены доступа (gbp_…) не удаляются: их не пускает проверка is_active, после Reactivate они снова работают
+func (s *AdminUserService) Suspend(ctx context.Context, actorID, uid uint, reason string, until *time.Time) (dto.AdminUserResponse, error) {
+	if actorID == uid {
+		return dto.AdminUserResponse{}, ErrCannotSuspendSelf
+	}
+	now := time.Now().UTC()
+	if until != nil {
+		if !until.After(now) {
+			return dto.AdminUserResponse{}, ErrInvalidSuspensionExpiry
+		}
+		u := until.UTC()
+		until = &u
+	}
+	reason = strings.TrimSpace(reason)
+
+	if _, err := s.find(ctx, uid); err != nil {
+		return dto.AdminUserResponse{}, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.users.SuspendTx(ctx, tx, uid, reason, until, now); err != nil {
+			return err
+		}
+
+		env, err := newEvent(ctx, events.UserSuspended, "user", uintToString(uid), uintToString(actorID), events.UserSuspendedPayload{
+			UserID: uintToString(uid),
+			Reason: reason,
+			Until:  until,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+	})
+	if err != nil {
+		return dto.AdminUserResponse{}, err
+	}
+
+	if err := revokeEverywhere(ctx, s.refresh, s.access, uid); err != nil {
+		return dto.AdminUserResponse{}, err
+	}
+	return s.view(ctx, uid)
+}
+
+func (s *AdminUserService) Reactivate(ctx context.Context, actorID, uid uint) (dto.AdminUserResponse, error) {
+	if _, err := s.find(ctx, uid); err != nil {
+		return dto.AdminUserResponse{}, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ok, err := reactivateTx(ctx, tx, s.users, s.outbox, uid, uintToString(actorID), false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrUserNotSuspended
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.AdminUserResponse{}, err
+	}
+	return s.view(ctx, uid)
+}
+
+// Unlock снимает блокировку входа после серии неудачных попыток. Событие и сброс счётчика
+// в одной транзакции: не удалось сбросить — в аудите не будет разблокировки, которой не было.
+func (s *AdminUserService) Unlock(ctx context.Context, actorID, uid uint) error {
+	if _, err := s.find(ctx, uid); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		env, err := newEvent(ctx, events.UserLoginUnlocked, "user", uintToString(uid), uintToString(actorID), events.UserLoginUnlockedPayload{
+			UserID: uintToString(uid),
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.outbox.CreateTx(ctx, tx, newOutboxEvent(env)); err != nil {
+			return err
+		}
+		return s.throttle.Unlock(ctx, uid)
+	})
+}
+
+// ForcePasswordReset: прежний пароль перестаёт подходить, все сессии закрываются,
+// на адрес пользователя уходит ссылка для нового пароля
+func (s *AdminUserService) ForcePasswordReset(ctx context.Context, actorID, uid uint) error {
+	user, err := s.find(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	// случайный пароль, который никто не знает
+	secret, err := oidc.RandomString()
+	if err != nil {
+		return err
+	}
+	hash, err := utils.HashPassword(secret)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.users.UpdatePasswordTx(ctx, tx, uid, hash); err != nil {
+			return err
+		}
+
+		env, err := newEvent(ctx, events.UserPasswordResetForced, "user", uintToString(uid), uintToString(actorID), events.UserPasswordResetForcedPayload{
+			UserID: uintToString(uid),
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := revokeCompromised(ctx, s.refresh, s.access, s.pats, uid); err != nil {
+		return err
+	}
+	return s.resets.SendLink(ctx, user)
+}
+
+func (s *AdminUserService) find(ctx context.Context, uid uint) (*models.User, error) {
+	user, err := s.users.FindByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AdminUserService) view(ctx context.Context, uid uint) (dto.AdminUserResponse, error) {
+	user, err := s.find(ctx, uid)
+	if err != nil {
+		return dto.AdminUserResponse{}, err
+	}
+	return adminUserToResp(*user), nil
+}
+
+// SuspensionExpiry снимает блокировки, срок которых истёк; запускается фоном в каждом экземпляре API
+type SuspensionExpiry struct {
+	db     *gorm.DB
+	users  *repositories.UserRepository
+	outbox *repositories.OutboxRepository
+}
+
+func NewSuspensionExpiry(db *gorm.DB, users *repositories.UserRepository, outbox *repositories.OutboxRepository) *SuspensionExpiry {
+	return &SuspensionExpiry{db: db, users: users, outbox: outbox}
+}
+
+// RunOnce — сколько аккаунтов разблокировано
+func (e *SuspensionExpiry) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	n := 0
+	err := e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids, err := e.users.LockExpiredSuspensionsTx(ctx, tx, now, 100)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			ok, err := reactivateTx(ctx, tx, e.users, e.outbox, id, "", true)
+			if err != nil {
+				return err
+			}
+			if ok {
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (e *SuspensionExpiry) Run(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := e.RunOnce(ctx, now.UTC())
+			if err != nil {
+				log.Println("suspension expiry:", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("suspension expiry: %d accounts reactivated", n)
+			}
+		}
+	}
+}
+
+func reactivateTx(ctx context.Context, tx *gorm.DB, users *repositories.UserRepository, outbox *repositories.OutboxRepository, uid uint, actor string, expired bool) (bool, error) {
+	ok, err := users.ReactivateTx(ctx, tx, uid)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	env, err := newEvent(ctx, events.UserReactivated, "user", uintToString(uid), actor, events.UserReactivatedPayload{
+		UserID:  uintToString(uid),
+		Expired: expired,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+}
+
+func adminUserToResp(u models.User) dto.AdminUserResponse {
+	out := dto.AdminUserResponse{
+		ID:            u.ID,
+		Nickname:      u.Nickname,
+		Email:         u.Email,
+		Role:          u.Role,
+		IsActive:      u.IsActive,
+		EmailVerified: u.EmailVerifiedAt != nil,
+		CreatedAt:     u.CreatedAt,
+	}
+	if !u.IsActive && u.SuspendedAt != nil {
+		out.Suspension = &dto.SuspensionResponse{At: *u.SuspendedAt, Until: u.SuspendedUntil, Reason: u.SuspensionReason}
+	}
+	return out
+}
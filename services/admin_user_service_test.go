@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"go_blog/dto"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"go_blog/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAdminUserService_SuspendAndReactivate(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	hash, err := utils.HashPassword("user-pass")
+	require.NoError(t, err)
+	admin := &models.User{Nickname: "admin", Email: "admin@test.com", Password: hash, IsActive: true, Role: models.RoleAdmin}
+	user := &models.User{Nickname: "spammer", Email: "spammer@test.com", Password: hash, IsActive: true, Role: models.RoleUser}
+	require.NoError(t, tx.Create(admin).Error)
+	require.NoError(t, tx.Create(user).Error)
+
+	users := repositories.NewUserRepository(tx)
+	refresh := newFakeRefreshStore()
+	access := newFakeAccessRevocations()
+	queue := &fakeMailQueue{}
+	tokenRepo := repositories.NewPersonalTokenRepository(tx)
+	resets := NewPasswordResetService(users, &fakeResetStore{byHash: map[string]uint{}, byUser: map[uint]string{}},
+		refresh, &fakeCounter{n: map[string]int64{}}, queue, access, tokenRepo)
+	throttle := NewLoginThrottleService(newFakeLoginAttempts(), users, queue, DefaultLoginThrottlePolicy())
+	svc := NewAdminUserService(tx, users, repositories.NewMFARepository(tx), repositories.NewIdentityRepository(tx),
+		repositories.NewOutboxRepository(tx), refresh, access, tokenRepo, resets, throttle)
+	ctx := context.Background()
+	refresh.hashToUser["spammer-session"] = user.ID
+	pats := NewPersonalTokenService(tokenRepo)
+	_, err = pats.Create(ctx, user.ID, dto.CreatePersonalTokenRequest{Name: "ci", Scopes: []string{models.ScopeRead}})
+	require.NoError(t, err)
+
+	_, err = svc.Suspend(ctx, admin.ID, admin.ID, "oops", nil)
+	require.ErrorIs(t, err, ErrCannotSuspendSelf)
+	past := time.Now().Add(-time.Hour)
+	_, err = svc.Suspend(ctx, admin.ID, user.ID, "spam", &past)
+	require.ErrorIs(t, err, ErrInvalidSuspensionExpiry)
+	_, err = svc.Reactivate(ctx, admin.ID, user.ID)
+	require.ErrorIs(t, err, ErrUserNotSuspended)
+
+	out, err := svc.Suspend(ctx, admin.ID, user.ID, " spam ", nil)
+	require.NoError(t, err)
+	require.False(t, out.IsActive)
+	require.NotNil(t, out.Suspension)
+	require.Equal(t, "spam", out.Suspension.Reason)
+	require.Nil(t, out.Suspension.Until)
+
+	// доступ пропадает сразу: сессии, access-токены, вход по паролю
+	require.NotContains(t, refresh.hashToUser, "spammer-session")
+	require.Contains(t, access.users, user.ID)
+	_, err = repositories.NewUserRepository(tx).FindByEmail(ctx, user.Email)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	list, total, err := svc.List(ctx, "spam", repositories.UserStatusSuspended, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	require.Equal(t, user.ID, list[0].ID)
+
+	out, err = svc.Reactivate(ctx, admin.ID, user.ID)
+	require.NoError(t, err)
+	require.True(t, out.IsActive)
+	require.Nil(t, out.Suspension)
+
+	// токены доступа переживают блокировку: пока она действует, их не пускает is_active
+	left, err := pats.List(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, left, 1)
+
+	require.Equal(t, []string{"UserSuspended", "UserReactivated"}, outboxTypes(t, tx))
+}
+
+func TestSuspensionExpiry_ReactivatesExpired(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	hash, err := utils.HashPassword("user-pass")
+	require.NoError(t, err)
+	admin := &models.User{Nickname: "admin", Email: "admin@test.com", Password: hash, IsActive: true, Role: models.RoleAdmin}
+	user := &models.User{Nickname: "spammer", Email: "spammer@test.com", Password: hash, IsActive: true, Role: models.RoleUser}
+	require.NoError(t, tx.Create(admin).Error)
+	require.NoError(t, tx.Create(user).Error)
+
+	users := repositories.NewUserRepository(tx)
+	refresh := newFakeRefreshStore()
+	queue := &fakeMailQueue{}
+	tokenRepo := repositories.NewPersonalTokenRepository(tx)
+	resets := NewPasswordResetService(users, &fakeResetStore{byHash: map[string]uint{}, byUser: map[uint]string{}},
+		refresh, &fakeCounter{n: map[string]int64{}}, queue, newFakeAccessRevocations(), tokenRepo)
+	throttle := NewLoginThrottleService(newFakeLoginAttempts(), users, queue, DefaultLoginThrottlePolicy())
+	svc := NewAdminUserService(tx, users, repositories.NewMFARepository(tx), repositories.NewIdentityRepository(tx),
+		repositories.NewOutboxRepository(tx), refresh, newFakeAccessRevocations(), tokenRepo, resets, throttle)
+	ctx := context.Background()
+
+	until := time.Now().Add(time.Hour)
+	_, err = svc.Suspend(ctx, admin.ID, user.ID, "cool down", &until)
+	require.NoError(t, err)
+
+	expiry := NewSuspensionExpiry(tx, repositories.NewUserRepository(tx), repositories.NewOutboxRepository(tx))
+	n, err := expiry.RunOnce(ctx, time.Now())
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	n, err = expiry.RunOnce(ctx, until.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	got, err := svc.Get(ctx, user.ID)
+	require.NoError(t, err)
+	require.True(t, got.IsActive)
+	require.Equal(t, []string{"UserSuspended", "UserReactivated"}, outboxTypes(t, tx))
+}
+
+func TestAdminUserService_UnlockIsAudited(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	hash, err := utils.HashPassword("user-pass")
+	require.NoError(t, err)
+	admin := &models.User{Nickname: "admin", Email: "admin@test.com", Password: hash, IsActive: true, Role: models.RoleAdmin}
+	user := &models.User{Nickname: "spammer", Email: "spammer@test.com", Password: hash, IsActive: true, Role: models.RoleUser}
+	require.NoError(t, tx.Create(admin).Error)
+	require.NoError(t, tx.Create(user).Error)
+
+	users := repositories.NewUserRepository(tx)
+	refresh := newFakeRefreshStore()
+	attempts := newFakeLoginAttempts()
+	queue := &fakeMailQueue{}
+	tokenRepo := repositories.NewPersonalTokenRepository(tx)
+	resets := NewPasswordResetService(users, &fakeResetStore{byHash: map[string]uint{}, byUser: map[uint]string{}},
+		refresh, &fakeCounter{n: map[string]int64{}}, queue, newFakeAccessRevocations(), tokenRepo)
+	throttle := NewLoginThrottleService(attempts, users, queue, DefaultLoginThrottlePolicy())
+	svc := NewAdminUserService(tx, users, repositories.NewMFARepository(tx), repositories.NewIdentityRepository(tx),
+		repositories.NewOutboxRepository(tx), refresh, newFakeAccessRevocations(), tokenRepo, resets, throttle)
+	ctx := context.Background()
+	attempts.fails["spammer@test.com"] = 10
+	attempts.blocked["spammer@test.com"] = time.Now().Add(time.Hour)
+
+	require.ErrorIs(t, svc.Unlock(ctx, admin.ID, user.ID+100), ErrUserNotFound)
+	require.NoError(t, svc.Unlock(ctx, admin.ID, user.ID))
+
+	require.Empty(t, attempts.fails)
+	require.Empty(t, attempts.blocked)
+
+	var row models.OutboxEvent
+	require.NoError(t, tx.Where("event_type = ?", "UserLoginUnlocked").First(&row).Error)
+	require.Equal(t, uintToString(user.ID), row.AggregateID)
+	require.Equal(t, uintToString(admin.ID), row.ActorUserID)
+	require.Equal(t, []string{"UserLoginUnlocked"}, outboxTypes(t, tx))
+}
+
+func TestAdminUserService_ForcePasswordReset(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	hash, err := utils.HashPassword("user-pass")
+	require.NoError(t, err)
+	admin := &models.User{Nickname: "admin", Email: "admin@test.com", Password: hash, IsActive: true, Role: models.RoleAdmin}
+	user := &models.User{Nickname: "spammer", Email: "spammer@test.com", Password: hash, IsActive: true, Role: models.RoleUser}
+	require.NoError(t, tx.Create(admin).Error)
+	require.NoError(t, tx.Create(user).Error)
+
+	users := repositories.NewUserRepository(tx)
+	refresh := newFakeRefreshStore()
+	queue := &fakeMailQueue{}
+	tokenRepo := repositories.NewPersonalTokenRepository(tx)
+	resets := NewPasswordResetService(users, &fakeResetStore{byHash: map[string]uint{}, byUser: map[uint]string{}},
+		refresh, &fakeCounter{n: map[string]int64{}}, queue, newFakeAccessRevocations(), tokenRepo)
+	throttle := NewLoginThrottleService(newFakeLoginAttempts(), users, queue, DefaultLoginThrottlePolicy())
+	svc := NewAdminUserService(tx, users, repositories.NewMFARepository(tx), repositories.NewIdentityRepository(tx),
+		repositories.NewOutboxRepository(tx), refresh, newFakeAccessRevocations(), tokenRepo, resets, throttle)
+	ctx := context.Background()
+	refresh.hashToUser["spammer-session"] = user.ID
+	pats := NewPersonalTokenService(tokenRepo)
+	_, err = pats.Create(ctx, user.ID, dto.CreatePersonalTokenRequest{Name: "ci", Scopes: []string{models.ScopeRead}})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, svc.ForcePasswordReset(ctx, admin.ID, user.ID+100), ErrUserNotFound)
+	require.NoError(t, svc.ForcePasswordReset(ctx, admin.ID, user.ID))
+
+	// токены доступа отзываются вместе с сессиями
+	left, err := pats.List(ctx, user.ID)
+	require.NoError(t, err)
+	require.Empty(t, left)
+
+	var stored models.User
+	require.NoError(t, tx.First(&stored, user.ID).Error)
+	require.False(t, utils.CheckPasswordHash(stored.Password, "user-pass"))
+	require.NotContains(t, refresh.hashToUser, "spammer-session")
+	require.NotEmpty(t, resetToken(t, queue))
+	require.Equal(t, []string{"UserPasswordResetForced"}, outboxTypes(t, tx))
+
+	got, err := svc.Get(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, dto.AdminUserResponse{
+		ID: user.ID, Nickname: "spammer", Email: "spammer@test.com", Role: models.RoleUser,
+		IsActive: true, CreatedAt: got.CreatedAt,
+	}, got.AdminUserResponse)
+	require.Empty(t, got.Identities)
+	require.False(t, got.MFAEnabled)
+}
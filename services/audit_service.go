@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_blog/dto"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	auditDefaultLimit = 50
+	auditMaxLimit     = 200
+)
+
+type AuditService struct {
+	repo  *repositories.AuditLogRepository
+	posts *repositories.PostRepository
+}
+
+func NewAuditService(repo *repositories.AuditLogRepository, posts *repositories.PostRepository) *AuditService {
+	return &AuditService{repo: repo, posts: posts}
+}
+
+func (s *AuditService) Query(ctx context.Context, q dto.AuditQuery) (dto.AuditLogListResponse, error) {
+	f, err := auditPage(q.Cursor, q.Limit)
+	if err != nil {
+		return dto.AuditLogListResponse{}, err
+	}
+
+	if q.EventType != "" {
+		for _, t := range strings.Split(q.EventType, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				f.EventTypes = append(f.EventTypes, t)
+			}
+		}
+	}
+	f.AggregateType = q.AggregateType
+	f.AggregateID = q.AggregateID
+	f.ActorUserID = q.Actor
+
+	if f.From, err = parseAuditTime("from", q.From); err != nil {
+		return dto.AuditLogListResponse{}, err
+	}
+	if f.To, err = parseAuditTime("to", q.To); err != nil {
+		return dto.AuditLogListResponse{}, err
+	}
+
+	if q.Payload != "" {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(q.Payload), &obj); err != nil {
+			return dto.AuditLogListResponse{}, fmt.Errorf("%w: payload must be a JSON object", ErrInvalidAuditQuery)
+		}
+		f.PayloadContains = q.Payload
+	}
+
+	items, err := s.repo.Query(ctx, f)
+	if err != nil {
+		return dto.AuditLogListResponse{}, err
+	}
+	return auditList(items, f.Limit), nil
+}
+
+// PostHistory доступна автору поста и ролям с правом читать аудит; удалённые посты тоже видны
+func (s *AuditService) PostHistory(ctx context.Context, slug string, uid uint, role string, cursor string, limit int) (dto.AuditLogListResponse, error) {
+	post, err := s.posts.GetBySlugUnscoped(ctx, slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.AuditLogListResponse{}, ErrPostNotFound
+		}
+		return dto.AuditLogListResponse{}, err
+	}
+	if post.UserID != uid && !models.HasPermission(role, models.PermAuditRead) {
+		return dto.AuditLogListResponse{}, repositories.ErrForbidden
+	}
+
+	f, err := auditPage(cursor, limit)
+	if err != nil {
+		return dto.AuditLogListResponse{}, err
+	}
+
+	items, err := s.repo.PostHistory(ctx, uintToString(post.ID), f)
+	if err != nil {
+		return dto.AuditLogListResponse{}, err
+	}
+	return auditList(items, f.Limit), nil
+}
+
+func auditPage(cursor string, limit int) (repositories.AuditLogFilter, error) {
+	var f repositories.AuditLogFilter
+
+	switch {
+	case limit <= 0:
+		f.Limit = auditDefaultLimit
+	case limit > auditMaxLimit:
+		f.Limit = auditMaxLimit
+	default:
+		f.Limit = limit
+	}
+
+	if cursor != "" {
+		at, id, err := decodeAuditCursor(cursor)
+		if err != nil {
+			return f, err
+		}
+		f.AfterOccurredAt = &at
+		f.AfterID = id
+	}
+	return f, nil
+}
+
+func auditList(items []models.AuditLog, limit int) dto.AuditLogListResponse {
+	out := dto.AuditLogListResponse{Ok: true, Items: make([]dto.AuditLogResponse, 0, len(items))}
+	for _, it := range items {
+		out.Items = append(out.Items, dto.AuditLogResponse{
+			ID:            it.ID,
+			EventID:       it.EventID,
+			EventType:     it.EventType,
+			AggregateType: it.AggregateType,
+			AggregateID:   it.AggregateID,
+			ActorUserID:   it.ActorUserID,
+			CorrelationID: it.CorrelationID,
+			Payload:       json.RawMessage(it.Payload),
+			OccurredAt:    it.OccurredAt,
+		})
+	}
+
+	// полная страница — возможно, есть ещё
+	if len(items) == limit {
+		last := items[len(items)-1]
+		out.NextCursor = encodeAuditCursor(last.OccurredAt, last.ID)
+	}
+	return out
+}
+
+func parseAuditTime(name, v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be RFC3339", ErrInvalidAuditQuery, name)
+	}
+	return &t, nil
+}
+
+// курсор непрозрачный для клиента: base64("<unix nano>:<id>")
+func encodeAuditCursor(at time.Time, id uint) string {
+	raw := strconv.FormatInt(at.UnixNano(), 10) + ":" + strconv.FormatUint(uint64(id), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeAuditCursor(cursor string) (time.Time, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%w: bad cursor", ErrInvalidAuditQuery)
+	}
+	ts, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("%w: bad cursor", ErrInvalidAuditQuery)
+	}
+	nanos, err1 := strconv.ParseInt(ts, 10, 64)
+	n, err2 := strconv.ParseUint(id, 10, 64)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, 0, fmt.Errorf("%w: bad cursor", ErrInvalidAuditQuery)
+	}
+	return time.Unix(0, nanos).UTC(), uint(n), nil
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"go_blog/dto"
+	"go_blog/internal/clientinfo"
 	"go_blog/models"
 	"go_blog/stores"
 	"go_blog/utils"
+	"log"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
@@ -17,13 +20,53 @@ type UserRepo interface {
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindByID(ctx context.Context, id uint) (*models.User, error)
 }
+
+// EmailVerifier отправляет ссылку подтверждения после регистрации; nil — не отправляем
+type EmailVerifier interface {
+	SendVerification(ctx context.Context, user *models.User) error
+}
+
+// SecurityEvents фиксирует подозрительные действия; nil — только лог
+type SecurityEvents interface {
+	RefreshTokenReused(ctx context.Context, uid uint, sessionID string, device clientinfo.Info) error
+}
+
+// MFAGate — вторая ступень входа; nil — вход только по паролю
+type MFAGate interface {
+	Enabled(ctx context.Context, uid uint) (bool, error)
+	Challenge(ctx context.Context, uid uint) (string, error)
+}
+
+// LoginGuard — учёт неудачных входов по аккаунту; nil — без ограничений
+type LoginGuard interface {
+	Allow(ctx context.Context, email string) error
+	// Failed: user == nil, если адреса нет — учёт тот же
+	Failed(ctx context.Context, email string, user *models.User) error
+	Succeeded(ctx context.Context, email string) error
+}
+
 type AuthService struct {
-	users  UserRepo
-	tokens stores.RefreshStore
+	users    UserRepo
+	tokens   stores.RefreshStore
+	verifier EmailVerifier
+	security SecurityEvents
+	mfa      MFAGate
+	guard    LoginGuard
+	access   stores.AccessRevocationStore
+}
+
+// AuthOptions — необязательные ступени входа и регистрации; пустое поле ступень отключает
+type AuthOptions struct {
+	Verifier EmailVerifier
+	Security SecurityEvents
+	MFA      MFAGate
+	Guard    LoginGuard
+	// Access — отзыв уже выданных access-токенов сессии при повторе refresh-токена
+	Access stores.AccessRevocationStore
 }
 
-func NewAuthService(users UserRepo, tokens stores.RefreshStore) *AuthService {
-	return &AuthService{users: users, tokens: tokens}
+func NewAuthService(users UserRepo, tokens stores.RefreshStore, opts AuthOptions) *AuthService {
+	return &AuthService{users: users, tokens: tokens, verifier: opts.Verifier, security: opts.Security, mfa: opts.MFA, guard: opts.Guard, access: opts.Access}
 }
 
 func (s *AuthService) Register(ctx context.Context, req dto.RegisterRequest) (dto.RegisterResponse, error) {
@@ -35,42 +78,109 @@ func (s *AuthService) Register(ctx context.Context, req dto.RegisterRequest) (dt
 	user := &models.User{
 		Nickname: req.Nickname,
 		Email:    req.Email,
-		Password: hash}
+		Password: hash,
+		IsActive: true}
 
 	if err := s.users.Create(ctx, user); err != nil {
 		return dto.RegisterResponse{}, err
 	}
 
-	return dto.RegisterResponse{ID: user.ID, Nickname: user.Nickname, Email: user.Email}, nil
+	// письмо не критично для регистрации: не ушло — пользователь запросит повторно
+	if s.verifier != nil {
+		if err := s.verifier.SendVerification(ctx, user); err != nil {
+			log.Printf("register: verification email for user %d: %v", user.ID, err)
+		}
+	}
+
+	return dto.RegisterResponse{ID: user.ID, Nickname: user.Nickname, Email: user.Email, EmailVerified: false}, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, req dto.LoginRequest) (dto.TokenPairResponse, error) {
+// dummyPasswordHash — сверка для несуществующего адреса, чтобы ответ не отличался по времени
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := utils.HashPassword("go_blog-no-such-user")
+	return hash
+})
+
+// Login: при включённой 2FA вместо пары токенов — MFA-challenge для /auth/mfa/verify.
+// Неизвестный адрес и неверный пароль неотличимы: та же ошибка, тот же учёт неудач, та же работа bcrypt.
+func (s *AuthService) Login(ctx context.Context, req dto.LoginRequest) (dto.LoginResponse, error) {
+	if s.guard != nil {
+		if err := s.guard.Allow(ctx, req.Email); err != nil {
+			return dto.LoginResponse{}, err
+		}
+	}
+
 	user, err := s.users.FindByEmail(ctx, req.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.LoginResponse{}, err
+	}
+
+	if user == nil || !utils.CheckPasswordHash(user.Password, req.Password) {
+		if user == nil {
+			utils.CheckPasswordHash(dummyPasswordHash(), req.Password)
+		}
+		if s.guard != nil {
+			if err := s.guard.Failed(ctx, req.Email, user); err != nil {
+				log.Printf("login: record failed attempt: %v", err)
+			}
+		}
+		return dto.LoginResponse{}, ErrInvalidCredentials
+	}
+
+	out, err := s.LoginVerified(ctx, user)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return dto.TokenPairResponse{}, ErrInvalidCredentials
+		return dto.LoginResponse{}, err
+	}
+	// с 2FA вход ещё не завершён: неудачи сбрасывает MFAService.Verify после верного кода
+	if !out.MFARequired && s.guard != nil {
+		if err := s.guard.Succeeded(ctx, req.Email); err != nil {
+			log.Printf("login: reset failed attempts for user %d: %v", user.ID, err)
 		}
-		return dto.TokenPairResponse{}, err
 	}
+	return out, nil
+}
 
-	if !utils.CheckPasswordHash(user.Password, req.Password) {
-		return dto.TokenPairResponse{}, ErrInvalidCredentials
+// LoginVerified — пользователь уже подтвердил личность (пароль или внешний провайдер):
+// та же 2FA и та же пара токенов, что и при обычном входе
+func (s *AuthService) LoginVerified(ctx context.Context, user *models.User) (dto.LoginResponse, error) {
+	if s.mfa != nil {
+		enabled, err := s.mfa.Enabled(ctx, user.ID)
+		if err != nil {
+			return dto.LoginResponse{}, err
+		}
+		if enabled {
+			challenge, err := s.mfa.Challenge(ctx, user.ID)
+			if err != nil {
+				return dto.LoginResponse{}, err
+			}
+			return dto.LoginResponse{MFARequired: true, MFAToken: challenge}, nil
+		}
 	}
 
-	access, err := utils.GenerateAccessJWT(user.ID, user.Role)
+	pair, err := issueTokens(ctx, s.tokens, user)
 	if err != nil {
-		return dto.TokenPairResponse{}, ErrToken
+		return dto.LoginResponse{}, err
 	}
+	return dto.LoginResponse{AccessToken: pair.AccessToken, RefreshToken: pair.RefreshToken}, nil
+}
 
+// issueTokens — новая сессия: access + refresh; устройство берётся из context запроса
+func issueTokens(ctx context.Context, tokens stores.RefreshStore, user *models.User) (dto.TokenPairResponse, error) {
 	plain, hash, exp, err := utils.NewRefreshToken()
 	if err != nil {
 		return dto.TokenPairResponse{}, ErrToken
 	}
 
-	if err := s.tokens.Save(ctx, user.ID, hash, time.Until(exp)); err != nil {
+	sid, err := tokens.Save(ctx, user.ID, hash, time.Until(exp), clientinfo.FromContext(ctx))
+	if err != nil {
 		return dto.TokenPairResponse{}, err
 	}
 
+	access, err := utils.GenerateSessionAccessJWT(user.ID, user.Role, sid)
+	if err != nil {
+		return dto.TokenPairResponse{}, ErrToken
+	}
+
 	return dto.TokenPairResponse{
 		AccessToken:  access,
 		RefreshToken: plain,
@@ -78,10 +188,20 @@ func (s *AuthService) Login(ctx context.Context, req dto.LoginRequest) (dto.Toke
 }
 
 func (s *AuthService) Refresh(ctx context.Context, refreshPlain string) (dto.TokenPairResponse, error) {
-	oldHash := utils.HashRefresh(refreshPlain)
+	plain, newHash, exp, err := utils.NewRefreshToken()
+	if err != nil {
+		return dto.TokenPairResponse{}, ErrToken
+	}
 
-	userID, err := s.tokens.GetUserIDByHash(ctx, oldHash)
+	// ротация атомарна: из параллельных refresh с одним токеном успешен один,
+	// остальные считаются повторным использованием и закрывают сессию
+	device := clientinfo.FromContext(ctx)
+	userID, sid, err := s.tokens.Rotate(ctx, utils.HashRefresh(refreshPlain), newHash, time.Until(exp), device)
 	if err != nil {
+		if errors.Is(err, stores.ErrRefreshReused) {
+			s.reportReuse(ctx, userID, sid, device)
+			return dto.TokenPairResponse{}, ErrInvalidRefresh
+		}
 		if errors.Is(err, stores.ErrInvalidRefresh) {
 			return dto.TokenPairResponse{}, ErrInvalidRefresh
 		}
@@ -92,37 +212,43 @@ func (s *AuthService) Refresh(ctx context.Context, refreshPlain string) (dto.Tok
 	if err != nil {
 		return dto.TokenPairResponse{}, err
 	}
-
-	access, err := utils.GenerateAccessJWT(user.ID, user.Role)
-	if err != nil {
-		return dto.TokenPairResponse{}, ErrToken
+	// заблокированный: сессии удаляются при блокировке, это — на случай гонки с ротацией
+	if !user.IsActive {
+		if err := s.tokens.RevokeAll(ctx, user.ID); err != nil {
+			log.Printf("refresh: revoke sessions of inactive user %d: %v", user.ID, err)
+		}
+		return dto.TokenPairResponse{}, ErrInvalidRefresh
 	}
 
-	plain, newHash, exp, err := utils.NewRefreshToken()
+	access, err := utils.GenerateSessionAccessJWT(user.ID, user.Role, sid)
 	if err != nil {
 		return dto.TokenPairResponse{}, ErrToken
 	}
 
-	if err := s.tokens.Rotate(ctx, oldHash, user.ID, newHash, time.Until(exp)); err != nil {
-		return dto.TokenPairResponse{}, err
-	}
-
 	return dto.TokenPairResponse{
 		AccessToken:  access,
 		RefreshToken: plain,
 	}, nil
 }
 
-func (s *AuthService) Logout(ctx context.Context, refreshPlain string) error {
-	hash := utils.HashRefresh(refreshPlain)
-
-	userID, err := s.tokens.GetUserIDByHash(ctx, hash)
-	if err != nil {
-		if errors.Is(err, stores.ErrInvalidRefresh) {
-			return nil
+// reportReuse — refresh-сессия уже отозвана стором; access-токены, выданные ей раньше,
+// гасим здесь: иначе украденный access живёт до exp. Ошибки не влияют на ответ клиенту.
+func (s *AuthService) reportReuse(ctx context.Context, uid uint, sid string, device clientinfo.Info) {
+	log.Printf("refresh: reused token for user %d, session %s revoked (ip=%s)", uid, sid, device.IP)
+	if s.access != nil {
+		if err := s.access.RevokeSession(ctx, sid); err != nil {
+			log.Printf("refresh: revoke access tokens of session %s: %v", sid, err)
 		}
-		return err
 	}
+	if s.security == nil {
+		return
+	}
+	if err := s.security.RefreshTokenReused(ctx, uid, sid, device); err != nil {
+		log.Printf("refresh: security event for user %d: %v", uid, err)
+	}
+}
 
-	return s.tokens.Delete(ctx, hash, userID)
+// Logout закрывает только сессию этого refresh-токена; неизвестный токен — не ошибка
+func (s *AuthService) Logout(ctx context.Context, refreshPlain string) error {
+	return s.tokens.Delete(ctx, utils.HashRefresh(refreshPlain))
 }
@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"go_blog/dto"
+	"go_blog/internal/clientinfo"
+	"go_blog/middleware"
 	"go_blog/models"
 	"go_blog/stores"
 	"go_blog/utils"
@@ -51,40 +54,159 @@ func (f *fakeUserRepo) FindByID(ctx context.Context, id uint) (*models.User, err
 	return u, nil
 }
 
+// fakeRefreshStore: hashToUser можно заполнять напрямую — такие токены живут без сессии
 type fakeRefreshStore struct {
-	hashToUser map[string]uint
+	hashToUser    map[string]uint
+	hashToSession map[string]string
+	sessions      map[string]stores.Session
+	used          map[string]string // ротированный хэш -> sid
+	nextID        int
 }
 
 func newFakeRefreshStore() *fakeRefreshStore {
 	return &fakeRefreshStore{
-		hashToUser: make(map[string]uint),
+		hashToUser:    make(map[string]uint),
+		hashToSession: make(map[string]string),
+		sessions:      make(map[string]stores.Session),
+		used:          make(map[string]string),
 	}
 }
 
-func (f *fakeRefreshStore) Save(ctx context.Context, uid uint, hash string, _ time.Duration) error {
+func (f *fakeRefreshStore) Save(ctx context.Context, uid uint, hash string, ttl time.Duration, device clientinfo.Info) (string, error) {
+	f.nextID++
+	sid := fmt.Sprintf("s%d", f.nextID)
+	now := time.Now()
 	f.hashToUser[hash] = uid
-	return nil
+	f.hashToSession[hash] = sid
+	f.sessions[sid] = stores.Session{
+		ID: sid, UserID: uid, UserAgent: device.UserAgent, IP: device.IP,
+		CreatedAt: now, LastUsedAt: now, ExpiresAt: now.Add(ttl),
+	}
+	return sid, nil
 }
 
-func (f *fakeRefreshStore) GetUserIDByHash(ctx context.Context, hash string) (uint, error) {
+func (f *fakeRefreshStore) Lookup(ctx context.Context, hash string) (uint, string, error) {
 	uid, ok := f.hashToUser[hash]
 	if !ok {
-		return 0, stores.ErrInvalidRefresh
+		return 0, "", stores.ErrInvalidRefresh
 	}
-	return uid, nil
+	return uid, f.hashToSession[hash], nil
 }
 
-func (f *fakeRefreshStore) Rotate(ctx context.Context, oldHash string, uid uint, newHash string, _ time.Duration) error {
-	delete(f.hashToUser, oldHash)
+func (f *fakeRefreshStore) Rotate(ctx context.Context, oldHash, newHash string, ttl time.Duration, device clientinfo.Info) (uint, string, error) {
+	if sid, ok := f.used[oldHash]; ok {
+		uid := f.sessions[sid].UserID
+		_ = f.RevokeSession(ctx, uid, sid)
+		return uid, sid, stores.ErrRefreshReused
+	}
+	uid, sid, err := f.Lookup(ctx, oldHash)
+	if err != nil {
+		return 0, "", err
+	}
+	f.dropHash(oldHash)
+	f.used[oldHash] = sid
 	f.hashToUser[newHash] = uid
-	return nil
+	f.hashToSession[newHash] = sid
+	if sess, ok := f.sessions[sid]; ok {
+		sess.LastUsedAt = time.Now()
+		sess.ExpiresAt = sess.LastUsedAt.Add(ttl)
+		if device.IP != "" {
+			sess.IP = device.IP
+		}
+		f.sessions[sid] = sess
+	}
+	return uid, sid, nil
 }
 
-func (f *fakeRefreshStore) Delete(ctx context.Context, hash string, uid uint) error {
+func (f *fakeRefreshStore) dropHash(hash string) {
 	delete(f.hashToUser, hash)
+	delete(f.hashToSession, hash)
+}
+
+func (f *fakeRefreshStore) Delete(ctx context.Context, hash string) error {
+	delete(f.sessions, f.hashToSession[hash])
+	f.dropHash(hash)
+	return nil
+}
+
+func (f *fakeRefreshStore) List(ctx context.Context, uid uint) ([]stores.Session, error) {
+	var out []stores.Session
+	for _, sess := range f.sessions {
+		if sess.UserID == uid {
+			out = append(out, sess)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRefreshStore) RevokeSession(ctx context.Context, uid uint, sid string) error {
+	sess, ok := f.sessions[sid]
+	if !ok || sess.UserID != uid {
+		return stores.ErrSessionNotFound
+	}
+	for h, s := range f.hashToSession {
+		if s == sid {
+			f.dropHash(h)
+		}
+	}
+	delete(f.sessions, sid)
+	return nil
+}
+
+func (f *fakeRefreshStore) RevokeAll(ctx context.Context, uid uint) error {
+	for h, u := range f.hashToUser {
+		if u == uid {
+			delete(f.sessions, f.hashToSession[h])
+			f.dropHash(h)
+		}
+	}
+	return nil
+}
+
+type fakeAccessRevocations struct {
+	tokens   map[string]time.Time
+	sessions map[string]bool
+	users    map[uint]time.Time
+}
+
+func newFakeAccessRevocations() *fakeAccessRevocations {
+	return &fakeAccessRevocations{
+		tokens:   make(map[string]time.Time),
+		sessions: make(map[string]bool),
+		users:    make(map[uint]time.Time),
+	}
+}
+
+type fakePersonalTokens struct {
+	revoked []uint
+}
+
+func (f *fakePersonalTokens) DeleteByUser(ctx context.Context, uid uint) error {
+	f.revoked = append(f.revoked, uid)
+	return nil
+}
+
+func (f *fakeAccessRevocations) RevokeToken(ctx context.Context, jti string, exp time.Time) error {
+	f.tokens[jti] = exp
+	return nil
+}
+
+func (f *fakeAccessRevocations) RevokeSession(ctx context.Context, sid string) error {
+	f.sessions[sid] = true
+	return nil
+}
+
+func (f *fakeAccessRevocations) RevokeUser(ctx context.Context, uid uint) error {
+	f.users[uid] = time.Now()
 	return nil
 }
 
+func (f *fakeAccessRevocations) IsRevoked(ctx context.Context, t stores.AccessToken) (bool, error) {
+	_, denied := f.tokens[t.ID]
+	wm, ok := f.users[t.UserID]
+	return denied || f.sessions[t.SessionID] || (ok && t.IssuedAt.Unix() < wm.Unix()), nil
+}
+
 func createUserViaService(t *testing.T, svc *AuthService, email, password string) uint {
 	t.Helper()
 
@@ -100,7 +222,7 @@ func createUserViaService(t *testing.T, svc *AuthService, email, password string
 func TestAuthService_Login_OK_And_Refresh_Works(t *testing.T) {
 	users := newFakeUserRepo()
 	tokens := newFakeRefreshStore()
-	svc := NewAuthService(users, tokens)
+	svc := NewAuthService(users, tokens, AuthOptions{})
 
 	_, err := svc.Register(context.Background(), dto.RegisterRequest{
 		Nickname: "test",
@@ -123,7 +245,7 @@ func TestAuthService_Login_OK_And_Refresh_Works(t *testing.T) {
 func TestAuthService_Login_InvalidCredentials(t *testing.T) {
 	users := newFakeUserRepo()
 	tokens := newFakeRefreshStore()
-	svc := NewAuthService(users, tokens)
+	svc := NewAuthService(users, tokens, AuthOptions{})
 
 	_, err := svc.Login(context.Background(), dto.LoginRequest{
 		Email:    "no@test.com",
@@ -135,7 +257,7 @@ func TestAuthService_Login_InvalidCredentials(t *testing.T) {
 func TestAuthService_Refresh_Rotation_OldDies(t *testing.T) {
 	users := newFakeUserRepo()
 	tokens := newFakeRefreshStore()
-	svc := NewAuthService(users, tokens)
+	svc := NewAuthService(users, tokens, AuthOptions{})
 
 	_, err := svc.Register(context.Background(), dto.RegisterRequest{
 		Nickname: "test",
@@ -162,7 +284,7 @@ func TestAuthService_Refresh_Rotation_OldDies(t *testing.T) {
 func TestAuthService_MultiSession_LogoutOnlyOneSession(t *testing.T) {
 	users := newFakeUserRepo()
 	tokens := newFakeRefreshStore()
-	svc := NewAuthService(users, tokens)
+	svc := NewAuthService(users, tokens, AuthOptions{})
 
 	_, _ = svc.Register(context.Background(), dto.RegisterRequest{
 		Nickname: "u",
@@ -189,7 +311,7 @@ func TestAuthService_MultiSession_LogoutOnlyOneSession(t *testing.T) {
 func TestAuthService_Logout_InvalidRefresh_IsOK(t *testing.T) {
 	users := newFakeUserRepo()
 	tokens := newFakeRefreshStore()
-	svc := NewAuthService(users, tokens)
+	svc := NewAuthService(users, tokens, AuthOptions{})
 
 	// logout должен быть идемпотентным
 	require.NoError(t, svc.Logout(context.Background(), "not-a-refresh-token"))
@@ -198,7 +320,7 @@ func TestAuthService_Logout_InvalidRefresh_IsOK(t *testing.T) {
 func TestAuthService_Refresh_InvalidRefresh(t *testing.T) {
 	users := newFakeUserRepo()
 	tokens := newFakeRefreshStore()
-	svc := NewAuthService(users, tokens)
+	svc := NewAuthService(users, tokens, AuthOptions{})
 
 	_, err := svc.Refresh(context.Background(), "not-a-refresh-token")
 	require.ErrorIs(t, err, ErrInvalidRefresh)
@@ -207,7 +329,7 @@ func TestAuthService_Refresh_InvalidRefresh(t *testing.T) {
 func TestAuthService_Refresh_UsesCurrentUserRole(t *testing.T) {
 	users := newFakeUserRepo()
 	tokens := newFakeRefreshStore()
-	svc := NewAuthService(users, tokens)
+	svc := NewAuthService(users, tokens, AuthOptions{})
 
 	out, _ := svc.Register(context.Background(), dto.RegisterRequest{
 		Nickname: "u",
@@ -226,3 +348,122 @@ func TestAuthService_Refresh_UsesCurrentUserRole(t *testing.T) {
 	_, claims, _ := utils.ParseAccessJWT(t2.AccessToken)
 	require.Equal(t, "admin", claims["role"])
 }
+
+func TestAuthService_Refresh_RejectsSuspendedUser(t *testing.T) {
+	users := newFakeUserRepo()
+	tokens := newFakeRefreshStore()
+	svc := NewAuthService(users, tokens, AuthOptions{})
+	ctx := context.Background()
+
+	uid := createUserViaService(t, svc, "suspended@test.com", "123456")
+	t1, err := svc.Login(ctx, dto.LoginRequest{Email: "suspended@test.com", Password: "123456"})
+	require.NoError(t, err)
+
+	users.users[uid].IsActive = false
+
+	_, err = svc.Refresh(ctx, t1.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+	sessions, err := tokens.List(ctx, uid)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func TestAuthService_Sessions_KeepDeviceAndIDAcrossRotation(t *testing.T) {
+	users := newFakeUserRepo()
+	tokens := newFakeRefreshStore()
+	svc := NewAuthService(users, tokens, AuthOptions{})
+	uid := createUserViaService(t, svc, "dev@test.com", "123456")
+
+	laptop := clientinfo.WithContext(context.Background(), clientinfo.New("Firefox", "10.0.0.1"))
+	phone := clientinfo.WithContext(context.Background(), clientinfo.New("iPhone", "10.0.0.2"))
+
+	t1, err := svc.Login(laptop, dto.LoginRequest{Email: "dev@test.com", Password: "123456"})
+	require.NoError(t, err)
+	t2, err := svc.Login(phone, dto.LoginRequest{Email: "dev@test.com", Password: "123456"})
+	require.NoError(t, err)
+
+	p1, err := middleware.Authenticate(t1.AccessToken)
+	require.NoError(t, err)
+	p2, err := middleware.Authenticate(t2.AccessToken)
+	require.NoError(t, err)
+	require.NotEqual(t, p1.SessionID, p2.SessionID)
+
+	// ротация не создаёт новую сессию
+	t3, err := svc.Refresh(laptop, t1.RefreshToken)
+	require.NoError(t, err)
+	p3, err := middleware.Authenticate(t3.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, p1.SessionID, p3.SessionID)
+
+	access := newFakeAccessRevocations()
+	sessions := NewSessionService(tokens, access)
+	list, err := sessions.List(context.Background(), uid, p2.SessionID)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	for _, s := range list {
+		require.Equal(t, s.ID == p2.SessionID, s.Current)
+		if s.ID == p1.SessionID {
+			require.Equal(t, "Firefox", s.UserAgent)
+		} else {
+			require.Equal(t, "iPhone", s.UserAgent)
+		}
+	}
+
+	require.ErrorIs(t, sessions.Revoke(context.Background(), uid+1, p1.SessionID), ErrSessionNotFound)
+	require.NoError(t, sessions.Revoke(context.Background(), uid, p1.SessionID))
+	require.True(t, access.sessions[p1.SessionID])
+
+	_, err = svc.Refresh(laptop, t3.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+	_, err = svc.Refresh(phone, t2.RefreshToken)
+	require.NoError(t, err)
+
+	require.NoError(t, sessions.RevokeAll(context.Background(), uid))
+	require.Contains(t, access.users, uid)
+}
+
+type fakeSecurityEvents struct {
+	reused []string
+}
+
+func (f *fakeSecurityEvents) RefreshTokenReused(ctx context.Context, uid uint, sessionID string, device clientinfo.Info) error {
+	f.reused = append(f.reused, fmt.Sprintf("%d:%s:%s", uid, sessionID, device.IP))
+	return nil
+}
+
+func TestAuthService_Refresh_ReuseRevokesFamily(t *testing.T) {
+	users := newFakeUserRepo()
+	tokens := newFakeRefreshStore()
+	security := &fakeSecurityEvents{}
+	access := newFakeAccessRevocations()
+	svc := NewAuthService(users, tokens, AuthOptions{Security: security, Access: access})
+	uid := createUserViaService(t, svc, "reuse@test.com", "123456")
+
+	victim := clientinfo.WithContext(context.Background(), clientinfo.New("Firefox", "10.0.0.1"))
+	attacker := clientinfo.WithContext(context.Background(), clientinfo.New("curl", "203.0.113.7"))
+
+	t1, err := svc.Login(victim, dto.LoginRequest{Email: "reuse@test.com", Password: "123456"})
+	require.NoError(t, err)
+	other, err := svc.Login(victim, dto.LoginRequest{Email: "reuse@test.com", Password: "123456"})
+	require.NoError(t, err)
+
+	// украденный t1 использован первым
+	stolen, err := svc.Refresh(attacker, t1.RefreshToken)
+	require.NoError(t, err)
+	p, err := middleware.Authenticate(stolen.AccessToken)
+	require.NoError(t, err)
+
+	// владелец предъявляет уже ротированный t1 — семья отзывается целиком
+	_, err = svc.Refresh(victim, t1.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+	require.Equal(t, []string{fmt.Sprintf("%d:%s:10.0.0.1", uid, p.SessionID)}, security.reused)
+	// уже выданный атакующему access-токен этой сессии тоже отозван
+	require.Equal(t, map[string]bool{p.SessionID: true}, access.sessions)
+
+	_, err = svc.Refresh(attacker, stolen.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+
+	// другие устройства не затронуты
+	_, err = svc.Refresh(victim, other.RefreshToken)
+	require.NoError(t, err)
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type CommentService struct {
+	db     *gorm.DB
+	repo   *repositories.CommentRepository
+	outbox *repositories.OutboxRepository
+}
+
+func NewCommentService(db *gorm.DB, repo *repositories.CommentRepository, outbox *repositories.OutboxRepository) *CommentService {
+	return &CommentService{db: db, repo: repo, outbox: outbox}
+}
+
+func (s *CommentService) Create(ctx context.Context, postSlug string, uid uint, parentID *uint, text string) (*models.Comment, error) {
+	text = strings.TrimSpace(text)
+
+	var created *models.Comment
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		post, err := s.repo.FindPostBySlugTx(ctx, tx, postSlug)
+		if err != nil {
+			return err
+		}
+
+		payload := events.CommentCreatedPayload{
+			PostID:       uintToString(post.ID),
+			PostSlug:     post.Slug,
+			PostAuthorID: uintToString(post.UserID),
+			Text:         text,
+		}
+
+		if parentID != nil {
+			parent, err := s.repo.FindByIDTx(ctx, tx, *parentID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrInvalidParentComment
+				}
+				return err
+			}
+			// отвечать можно только на комментарий того же поста
+			if parent.PostID != post.ID {
+				return ErrInvalidParentComment
+			}
+			payload.ParentID = uintToString(parent.ID)
+			payload.ParentAuthorID = uintToString(parent.UserID)
+		}
+
+		comment, err := s.repo.CreateTx(ctx, tx, post.ID, uid, parentID, text)
+		if err != nil {
+			return err
+		}
+
+		created = comment
+		payload.CommentID = uintToString(comment.ID)
+
+		env, err := newEvent(ctx, events.CommentCreated, "comment", uintToString(comment.ID), uintToString(uid), payload)
+		if err != nil {
+			return err
+		}
+
+		return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// Delete: автор удаляет свой комментарий; роль с PermCommentModerate — любой
+func (s *CommentService) Delete(ctx context.Context, commentID, uid uint, role string) error {
+	if models.HasPermission(role, models.PermCommentModerate) {
+		return s.repo.Delete(ctx, commentID)
+	}
+	return s.repo.DeleteOwnedBy(ctx, commentID, uid)
+}
+
+func (s *CommentService) ListByPostSlug(ctx context.Context, postSlug string) ([]models.Comment, error) {
+	return s.repo.ListByPostSlug(ctx, postSlug)
+}
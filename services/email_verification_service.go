@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"go_blog/internal/mail"
+	"go_blog/models"
+	"go_blog/stores"
+	"go_blog/utils"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Действия, которые политика может закрыть до подтверждения email
+const (
+	ActionPost    = "posts"
+	ActionComment = "comments"
+)
+
+const (
+	resendLimit  = 3
+	resendWindow = time.Hour
+)
+
+// VerificationPolicy — EMAIL_VERIFICATION_REQUIRED=posts,comments; пусто — ничего не требуем
+type VerificationPolicy struct {
+	Actions []string
+}
+
+func VerificationPolicyFromEnv() VerificationPolicy {
+	var p VerificationPolicy
+	for _, a := range strings.Split(os.Getenv("EMAIL_VERIFICATION_REQUIRED"), ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			p.Actions = append(p.Actions, a)
+		}
+	}
+	return p
+}
+
+func (p VerificationPolicy) Requires(action string) bool {
+	return slices.Contains(p.Actions, action)
+}
+
+type MailQueue interface {
+	Enqueue(ctx context.Context, tmpl, category string, to mail.Recipient, data any, dedupKey string) error
+	Link(path string) string
+}
+
+type VerificationUserRepo interface {
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	MarkEmailVerified(ctx context.Context, id uint, email string) (bool, error)
+}
+
+type EmailVerificationService struct {
+	users    VerificationUserRepo
+	mail     MailQueue
+	once     stores.OneTimeStore
+	counters stores.CounterStore
+	policy   VerificationPolicy
+}
+
+func NewEmailVerificationService(users VerificationUserRepo, mail MailQueue, once stores.OneTimeStore, counters stores.CounterStore, policy VerificationPolicy) *EmailVerificationService {
+	return &EmailVerificationService{users: users, mail: mail, once: once, counters: counters, policy: policy}
+}
+
+type verifyEmailData struct {
+	URL       string
+	ExpiresIn string
+}
+
+// SendVerification ставит письмо со ссылкой в очередь; отправит mail worker
+func (s *EmailVerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	token, claims, err := utils.GenerateEmailVerifyJWT(user.ID, user.Email)
+	if err != nil {
+		return ErrToken
+	}
+
+	data := verifyEmailData{
+		URL:       s.mail.Link("/verify-email?token=" + token),
+		ExpiresIn: strconv.Itoa(int(utils.EmailVerifyTTL().Hours())) + " hours",
+	}
+	return s.mail.Enqueue(ctx, mail.TemplateVerifyEmail, "",
+		mail.Recipient{UserID: user.ID, Email: user.Email, Name: user.Nickname}, data,
+		"verify:"+claims.ID)
+}
+
+// Resend — не чаще resendLimit писем в час на пользователя
+func (s *EmailVerificationService) Resend(ctx context.Context, uid uint) error {
+	user, err := s.users.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	n, err := s.counters.Hit(ctx, "verify-resend:"+strconv.FormatUint(uint64(uid), 10), resendWindow)
+	if err != nil {
+		return err
+	}
+	if n > resendLimit {
+		return ErrTooManyRequests
+	}
+
+	return s.SendVerification(ctx, user)
+}
+
+// Verify — ссылка одноразовая и действует, только пока email пользователя не менялся
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) error {
+	claims, err := utils.ParseEmailVerifyJWT(token)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	// сначала отметка, потом гасим ссылку: ошибка БД не тратит ссылку. Повторно отметить
+	// нельзя — подтверждённый адрес уже не станет неподтверждённым
+	ok, err := s.users.MarkEmailVerified(ctx, claims.UserID, claims.Email)
+	if err != nil {
+		return err
+	}
+	fresh, err := s.once.Use(ctx, "email_verify", claims.ID, time.Until(claims.Exp))
+	if ok {
+		if err != nil {
+			log.Printf("verify email: mark link used for user %d: %v", claims.UserID, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidVerificationToken
+	}
+
+	user, err := s.users.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+	if user.EmailVerifiedAt != nil && user.Email == claims.Email {
+		return ErrEmailAlreadyVerified
+	}
+	return ErrInvalidVerificationToken
+}
+
+// IsVerified — для middleware RequireVerifiedEmail
+func (s *EmailVerificationService) IsVerified(ctx context.Context, uid uint) (bool, error) {
+	user, err := s.users.FindByID(ctx, uid)
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerifiedAt != nil, nil
+}
+
+func (s *EmailVerificationService) Requires(action string) bool {
+	return s.policy.Requires(action)
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"go_blog/dto"
+	"go_blog/internal/mail"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sentMail struct {
+	tmpl string
+	to   mail.Recipient
+	data any
+}
+
+type fakeMailQueue struct {
+	sent []sentMail
+}
+
+func (f *fakeMailQueue) Enqueue(ctx context.Context, tmpl, category string, to mail.Recipient, data any, dedupKey string) error {
+	f.sent = append(f.sent, sentMail{tmpl: tmpl, to: to, data: data})
+	return nil
+}
+
+func (f *fakeMailQueue) Link(path string) string {
+	return "http://blog.test" + path
+}
+
+func (f *fakeMailQueue) lastToken(t *testing.T) string {
+	t.Helper()
+	require.NotEmpty(t, f.sent)
+	u, err := url.Parse(f.sent[len(f.sent)-1].data.(verifyEmailData).URL)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+type fakeOneTime struct {
+	used map[string]bool
+}
+
+func (f *fakeOneTime) Use(ctx context.Context, purpose, id string, ttl time.Duration) (bool, error) {
+	if f.used[purpose+id] {
+		return false, nil
+	}
+	f.used[purpose+id] = true
+	return true, nil
+}
+
+func (f *fakeOneTime) Release(ctx context.Context, purpose, id string) error {
+	delete(f.used, purpose+id)
+	return nil
+}
+
+type fakeCounter struct {
+	n map[string]int64
+}
+
+func (f *fakeCounter) Hit(ctx context.Context, name string, window time.Duration) (int64, error) {
+	f.n[name]++
+	return f.n[name], nil
+}
+
+func (f *fakeUserRepo) MarkEmailVerified(ctx context.Context, id uint, email string) (bool, error) {
+	u, ok := f.users[id]
+	if !ok || u.Email != email || u.EmailVerifiedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	u.EmailVerifiedAt = &now
+	return true, nil
+}
+
+func TestEmailVerification_RegisterSendsLink_VerifyOnce(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	users := newFakeUserRepo()
+	queue := &fakeMailQueue{}
+	verification := NewEmailVerificationService(users, queue, &fakeOneTime{used: map[string]bool{}},
+		&fakeCounter{n: map[string]int64{}}, VerificationPolicy{Actions: []string{ActionPost}})
+	auth := NewAuthService(users, newFakeRefreshStore(), AuthOptions{Verifier: verification})
+	ctx := context.Background()
+
+	out, err := auth.Register(ctx, dto.RegisterRequest{Nickname: "bob", Email: "bob@test.com", Password: "secret1"})
+	require.NoError(t, err)
+	require.False(t, out.EmailVerified)
+	require.Len(t, queue.sent, 1)
+	require.Equal(t, mail.TemplateVerifyEmail, queue.sent[0].tmpl)
+	require.Equal(t, "bob@test.com", queue.sent[0].to.Email)
+
+	token := queue.lastToken(t)
+	require.NoError(t, verification.Verify(ctx, token))
+	require.NotNil(t, users.users[out.ID].EmailVerifiedAt)
+
+	// ссылка одноразовая
+	require.ErrorIs(t, verification.Verify(ctx, token), ErrInvalidVerificationToken)
+
+	ok, err := verification.IsVerified(ctx, out.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestEmailVerification_RejectsForgedAndStaleEmail(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	users := newFakeUserRepo()
+	queue := &fakeMailQueue{}
+	verification := NewEmailVerificationService(users, queue, &fakeOneTime{used: map[string]bool{}},
+		&fakeCounter{n: map[string]int64{}}, VerificationPolicy{Actions: []string{ActionPost}})
+	auth := NewAuthService(users, newFakeRefreshStore(), AuthOptions{Verifier: verification})
+	ctx := context.Background()
+
+	out, err := auth.Register(ctx, dto.RegisterRequest{Nickname: "bob", Email: "bob@test.com", Password: "secret1"})
+	require.NoError(t, err)
+	token := queue.lastToken(t)
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	require.ErrorIs(t, verification.Verify(ctx, parts[0]+"."+parts[1]+".AAAA"), ErrInvalidVerificationToken)
+
+	// адрес сменился — старая ссылка не подтверждает новый
+	users.users[out.ID].Email = "new@test.com"
+	require.ErrorIs(t, verification.Verify(ctx, token), ErrInvalidVerificationToken)
+	require.Nil(t, users.users[out.ID].EmailVerifiedAt)
+}
+
+func TestEmailVerification_ResendRateLimited(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	users := newFakeUserRepo()
+	queue := &fakeMailQueue{}
+	verification := NewEmailVerificationService(users, queue, &fakeOneTime{used: map[string]bool{}},
+		&fakeCounter{n: map[string]int64{}}, VerificationPolicy{Actions: []string{ActionPost}})
+	auth := NewAuthService(users, newFakeRefreshStore(), AuthOptions{Verifier: verification})
+	ctx := context.Background()
+
+	out, err := auth.Register(ctx, dto.RegisterRequest{Nickname: "bob", Email: "bob@test.com", Password: "secret1"})
+	require.NoError(t, err)
+
+	for i := 0; i < resendLimit; i++ {
+		require.NoError(t, verification.Resend(ctx, out.ID))
+	}
+	require.ErrorIs(t, verification.Resend(ctx, out.ID), ErrTooManyRequests)
+	require.Len(t, queue.sent, 1+resendLimit)
+
+	now := time.Now()
+	users.users[out.ID].EmailVerifiedAt = &now
+	require.ErrorIs(t, verification.Resend(ctx, out.ID), ErrEmailAlreadyVerified)
+}
+
+func TestVerificationPolicy(t *testing.T) {
+	t.Setenv("EMAIL_VERIFICATION_REQUIRED", " posts , comments")
+	p := VerificationPolicyFromEnv()
+	require.True(t, p.Requires(ActionPost))
+	require.True(t, p.Requires(ActionComment))
+
+	require.False(t, VerificationPolicy{}.Requires(ActionPost))
+}
+
+var _ VerificationUserRepo = (*fakeUserRepo)(nil)
@@ -3,9 +3,47 @@ package services
 import "errors"
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrInvalidRefresh     = errors.New("invalid refresh token")
-	ErrToken              = errors.New("token error")
-	ErrPostNotFound       = errors.New("post not found")
-	ErrNoFieldsToUpdate   = errors.New("no fields to update")
+	ErrInvalidCredentials       = errors.New("invalid credentials")
+	ErrInvalidRefresh           = errors.New("invalid refresh token")
+	ErrToken                    = errors.New("token error")
+	ErrPostNotFound             = errors.New("post not found")
+	ErrNoFieldsToUpdate         = errors.New("no fields to update")
+	ErrWebhookNotFound          = errors.New("webhook not found")
+	ErrInvalidWebhookURL        = errors.New("invalid webhook url")
+	ErrInvalidEventType         = errors.New("unknown event type")
+	ErrInvalidAuditQuery        = errors.New("invalid audit query")
+	ErrInvalidParentComment     = errors.New("invalid parent comment")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrCannotFollowSelf         = errors.New("cannot follow yourself")
+	ErrNotificationNotFound     = errors.New("notification not found")
+	ErrInvalidNotificationType  = errors.New("unknown notification type")
+	ErrInvalidVerificationToken = errors.New("invalid verification token")
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+	ErrTooManyRequests          = errors.New("too many requests")
+	ErrInvalidResetToken        = errors.New("invalid reset token")
+	ErrWrongPassword            = errors.New("wrong password")
+	ErrSameEmail                = errors.New("email is the same")
+	ErrEmailTaken               = errors.New("email already taken")
+	ErrSessionNotFound          = errors.New("session not found")
+	ErrMFAAlreadyEnabled        = errors.New("mfa already enabled")
+	ErrMFANotEnabled            = errors.New("mfa not enabled")
+	ErrInvalidMFACode           = errors.New("invalid mfa code")
+	ErrInvalidMFAToken          = errors.New("invalid mfa token")
+	ErrUnknownOIDCProvider      = errors.New("unknown oidc provider")
+	ErrInvalidOIDCState         = errors.New("invalid oidc state")
+	ErrOIDCProvider             = errors.New("oidc provider error")
+	ErrOIDCEmailRequired        = errors.New("oidc provider did not return an email")
+	ErrOIDCEmailUnverified      = errors.New("oidc provider did not verify the email")
+	ErrInvalidOIDCLoginCode     = errors.New("invalid oidc login code")
+	ErrInvalidLinkToken         = errors.New("invalid identity link token")
+	ErrIdentityLinked           = errors.New("identity already linked")
+	ErrPersonalTokenNotFound    = errors.New("personal access token not found")
+	ErrInvalidScope             = errors.New("unknown token scope")
+	ErrTooManyPersonalTokens    = errors.New("too many personal access tokens")
+	ErrInvalidRole              = errors.New("unknown role")
+	ErrCannotChangeOwnRole      = errors.New("cannot change your own role")
+	ErrCannotSuspendSelf        = errors.New("cannot suspend yourself")
+	ErrInvalidSuspensionExpiry  = errors.New("suspension expiry must be in the future")
+	ErrUserNotSuspended         = errors.New("user is not suspended")
+	ErrInvalidWSTicket          = errors.New("invalid websocket ticket")
 )
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+
+	"gorm.io/gorm"
+)
+
+type FollowService struct {
+	db     *gorm.DB
+	repo   *repositories.FollowRepository
+	outbox *repositories.OutboxRepository
+}
+
+func NewFollowService(db *gorm.DB, repo *repositories.FollowRepository, outbox *repositories.OutboxRepository) *FollowService {
+	return &FollowService{db: db, repo: repo, outbox: outbox}
+}
+
+func (s *FollowService) Follow(ctx context.Context, followerID, followeeID uint) error {
+	if followerID == followeeID {
+		return ErrCannotFollowSelf
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var followee models.User
+		if err := tx.WithContext(ctx).Where("id = ? AND is_active = ?", followeeID, true).First(&followee).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		if err := s.repo.CreateTx(ctx, tx, followerID, followeeID); err != nil {
+			return err
+		}
+
+		env, err := newEvent(ctx, events.UserFollowed, "user", uintToString(followeeID), uintToString(followerID), events.UserFollowedPayload{
+			FollowerID: uintToString(followerID),
+			FolloweeID: uintToString(followeeID),
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+	})
+}
+
+func (s *FollowService) Unfollow(ctx context.Context, followerID, followeeID uint) error {
+	return s.repo.Delete(ctx, followerID, followeeID)
+}
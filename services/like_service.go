@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+
+	"gorm.io/gorm"
+)
+
+type LikeService struct {
+	db     *gorm.DB
+	repo   *repositories.LikeRepository
+	outbox *repositories.OutboxRepository
+}
+
+func NewLikeService(db *gorm.DB, repo *repositories.LikeRepository, outbox *repositories.OutboxRepository) *LikeService {
+	return &LikeService{db: db, repo: repo, outbox: outbox}
+}
+
+func (s *LikeService) Like(ctx context.Context, slug string, uid uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		post, err := s.repo.FindPostBySlugTx(ctx, tx, slug)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.LikeTx(ctx, tx, post.ID, uid); err != nil {
+			return err
+		}
+
+		return s.emit(ctx, tx, events.PostLiked, post, uid)
+	})
+}
+
+func (s *LikeService) Unlike(ctx context.Context, slug string, uid uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		post, err := s.repo.FindPostBySlugTx(ctx, tx, slug)
+		if err != nil {
+			return err
+		}
+
+		removed, err := s.repo.UnlikeTx(ctx, tx, post.ID, uid)
+		if err != nil || !removed {
+			return err
+		}
+
+		return s.emit(ctx, tx, events.PostUnliked, post, uid)
+	})
+}
+
+func (s *LikeService) Count(ctx context.Context, slug string) (int64, error) {
+	return s.repo.CountByPostSlug(ctx, slug)
+}
+
+func (s *LikeService) emit(ctx context.Context, tx *gorm.DB, eventType string, post *models.Post, uid uint) error {
+	count, err := s.repo.CountTx(ctx, tx, post.ID)
+	if err != nil {
+		return err
+	}
+
+	env, err := newEvent(ctx, eventType, "post", uintToString(post.ID), uintToString(uid), events.PostLikePayload{
+		PostID:       uintToString(post.ID),
+		PostSlug:     post.Slug,
+		PostAuthorID: uintToString(post.UserID),
+		UserID:       uintToString(uid),
+		LikesCount:   count,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_blog/internal/mail"
+	"go_blog/models"
+	"go_blog/stores"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoginThrottledError — вход по аккаунту временно закрыт; errors.Is(err, ErrTooManyRequests).
+// Одинаков для существующих и несуществующих адресов.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many login attempts, retry after %s", e.RetryAfter)
+}
+
+func (e *LoginThrottledError) Is(target error) bool {
+	return target == ErrTooManyRequests
+}
+
+// LoginThrottlePolicy: первые FreeAttempts неудач без задержки, дальше задержка удваивается
+// от BaseDelay до MaxDelay; на LockAfter-й неудаче аккаунт закрывается на LockFor
+type LoginThrottlePolicy struct {
+	Window       time.Duration
+	FreeAttempts int64
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockAfter    int64
+	LockFor      time.Duration
+}
+
+func DefaultLoginThrottlePolicy() LoginThrottlePolicy {
+	return LoginThrottlePolicy{
+		Window:       time.Hour,
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockAfter:    10,
+		LockFor:      15 * time.Minute,
+	}
+}
+
+// delay — пауза после n-й неудачи; locked — это блокировка, а не задержка
+func (p LoginThrottlePolicy) delay(n int64) (d time.Duration, locked bool) {
+	if n >= p.LockAfter {
+		return p.LockFor, true
+	}
+	if n <= p.FreeAttempts {
+		return 0, false
+	}
+	d = p.BaseDelay
+	for i := p.FreeAttempts + 1; i < n && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDelay), false
+}
+
+type LoginThrottleUserRepo interface {
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+}
+
+type LoginThrottleService struct {
+	attempts stores.LoginAttemptStore
+	users    LoginThrottleUserRepo
+	mail     MailQueue
+	policy   LoginThrottlePolicy
+}
+
+func NewLoginThrottleService(attempts stores.LoginAttemptStore, users LoginThrottleUserRepo, mail MailQueue, policy LoginThrottlePolicy) *LoginThrottleService {
+	return &LoginThrottleService{attempts: attempts, users: users, mail: mail, policy: policy}
+}
+
+func loginAccount(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Allow — до проверки пароля: пока действует задержка, пароль даже не сверяем
+func (s *LoginThrottleService) Allow(ctx context.Context, email string) error {
+	wait, err := s.attempts.Blocked(ctx, loginAccount(email))
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Failed учитывает неудачу; user == nil — адреса нет, но счёт ведётся так же
+func (s *LoginThrottleService) Failed(ctx context.Context, email string, user *models.User) error {
+	account := loginAccount(email)
+
+	n, err := s.attempts.Fail(ctx, account, s.policy.Window)
+	if err != nil {
+		return err
+	}
+	d, locked := s.policy.delay(n)
+	if d == 0 {
+		return nil
+	}
+	if err := s.attempts.Block(ctx, account, d); err != nil {
+		return err
+	}
+
+	// письмо — один раз за окно, на самой блокировке
+	if locked && n == s.policy.LockAfter && user != nil {
+		log.Printf("login: account %d locked for %s after %d failed attempts", user.ID, d, n)
+		if err := s.notifyLocked(ctx, user, n); err != nil {
+			log.Printf("login: notify user %d about lockout: %v", user.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *LoginThrottleService) Succeeded(ctx context.Context, email string) error {
+	return s.attempts.Reset(ctx, loginAccount(email))
+}
+
+// Unlock — админ снимает блокировку и обнуляет счётчик
+func (s *LoginThrottleService) Unlock(ctx context.Context, uid uint) error {
+	user, err := s.users.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	return s.attempts.Reset(ctx, loginAccount(user.Email))
+}
+
+type accountLockedData struct {
+	Attempts  int64
+	LockedFor string
+	ResetURL  string
+}
+
+func (s *LoginThrottleService) notifyLocked(ctx context.Context, user *models.User, attempts int64) error {
+	if s.mail == nil {
+		return nil
+	}
+	data := accountLockedData{
+		Attempts:  attempts,
+		LockedFor: strconv.Itoa(int(s.policy.LockFor.Minutes())) + " minutes",
+		ResetURL:  s.mail.Link("/forgot-password"),
+	}
+	return s.mail.Enqueue(ctx, mail.TemplateAccountLocked, "",
+		mail.Recipient{UserID: user.ID, Email: user.Email, Name: user.Nickname}, data,
+		"locked:"+strconv.FormatUint(uint64(user.ID), 10)+":"+strconv.FormatInt(time.Now().Unix(), 10))
+}
+
+// IsLoginThrottled — для контроллеров: ошибка блокировки и сколько ждать
+func IsLoginThrottled(err error) (time.Duration, bool) {
+	var e *LoginThrottledError
+	if errors.As(err, &e) {
+		return e.RetryAfter, true
+	}
+	return 0, false
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"go_blog/dto"
+	"go_blog/internal/mail"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLoginAttempts struct {
+	fails   map[string]int64
+	blocked map[string]time.Time
+}
+
+func newFakeLoginAttempts() *fakeLoginAttempts {
+	return &fakeLoginAttempts{fails: map[string]int64{}, blocked: map[string]time.Time{}}
+}
+
+func (f *fakeLoginAttempts) Blocked(ctx context.Context, account string) (time.Duration, error) {
+	if until, ok := f.blocked[account]; ok && time.Now().Before(until) {
+		return time.Until(until), nil
+	}
+	return 0, nil
+}
+
+func (f *fakeLoginAttempts) Fail(ctx context.Context, account string, window time.Duration) (int64, error) {
+	f.fails[account]++
+	return f.fails[account], nil
+}
+
+func (f *fakeLoginAttempts) Block(ctx context.Context, account string, d time.Duration) error {
+	f.blocked[account] = time.Now().Add(d)
+	return nil
+}
+
+func (f *fakeLoginAttempts) Reset(ctx context.Context, account string) error {
+	delete(f.fails, account)
+	delete(f.blocked, account)
+	return nil
+}
+
+// unblock — задержка «прошла», счётчик остаётся
+func (f *fakeLoginAttempts) unblock() {
+	clear(f.blocked)
+}
+
+func TestLoginThrottlePolicy_Delay(t *testing.T) {
+	p := DefaultLoginThrottlePolicy()
+
+	for n, want := range map[int64]time.Duration{
+		1: 0, 3: 0,
+		4: time.Second, 5: 2 * time.Second, 6: 4 * time.Second, 9: 32 * time.Second,
+	} {
+		d, locked := p.delay(n)
+		require.Equal(t, want, d, n)
+		require.False(t, locked, n)
+	}
+
+	d, locked := p.delay(p.LockAfter)
+	require.True(t, locked)
+	require.Equal(t, p.LockFor, d)
+
+	p.LockAfter = 100
+	d, _ = p.delay(50)
+	require.Equal(t, p.MaxDelay, d)
+}
+
+func TestLoginThrottle_LocksAccountAndNotifiesOwner(t *testing.T) {
+	users := newFakeUserRepo()
+	attempts := newFakeLoginAttempts()
+	queue := &fakeMailQueue{}
+	throttle := NewLoginThrottleService(attempts, users, queue, DefaultLoginThrottlePolicy())
+	auth := NewAuthService(users, newFakeRefreshStore(), AuthOptions{Guard: throttle})
+	ctx := context.Background()
+	uid := createUserViaService(t, auth, "victim@test.com", "right-pass")
+	policy := DefaultLoginThrottlePolicy()
+
+	for i := int64(1); i <= policy.FreeAttempts; i++ {
+		_, err := auth.Login(ctx, dto.LoginRequest{Email: "victim@test.com", Password: "wrong"})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	// дальше — задержка, и даже верный пароль не проверяется
+	_, err := auth.Login(ctx, dto.LoginRequest{Email: "victim@test.com", Password: "wrong"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = auth.Login(ctx, dto.LoginRequest{Email: "Victim@test.com", Password: "right-pass"})
+	require.ErrorIs(t, err, ErrTooManyRequests)
+	wait, ok := IsLoginThrottled(err)
+	require.True(t, ok)
+	require.Greater(t, wait, time.Duration(0))
+
+	for i := policy.FreeAttempts + 2; i <= policy.LockAfter; i++ {
+		attempts.unblock()
+		_, err := auth.Login(ctx, dto.LoginRequest{Email: "victim@test.com", Password: "wrong"})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	_, err = auth.Login(ctx, dto.LoginRequest{Email: "victim@test.com", Password: "right-pass"})
+	wait, ok = IsLoginThrottled(err)
+	require.True(t, ok)
+	require.Greater(t, wait, policy.MaxDelay)
+
+	require.Len(t, queue.sent, 1)
+	require.Equal(t, mail.TemplateAccountLocked, queue.sent[0].tmpl)
+	require.Equal(t, "victim@test.com", queue.sent[0].to.Email)
+
+	require.NoError(t, throttle.Unlock(ctx, uid))
+	_, err = auth.Login(ctx, dto.LoginRequest{Email: "victim@test.com", Password: "right-pass"})
+	require.NoError(t, err)
+}
+
+func TestLoginThrottle_UnknownEmailLooksTheSame(t *testing.T) {
+	users := newFakeUserRepo()
+	attempts := newFakeLoginAttempts()
+	queue := &fakeMailQueue{}
+	throttle := NewLoginThrottleService(attempts, users, queue, DefaultLoginThrottlePolicy())
+	auth := NewAuthService(users, newFakeRefreshStore(), AuthOptions{Guard: throttle})
+	ctx := context.Background()
+	policy := DefaultLoginThrottlePolicy()
+
+	for i := int64(1); i <= policy.LockAfter; i++ {
+		attempts.unblock()
+		_, err := auth.Login(ctx, dto.LoginRequest{Email: "nobody@test.com", Password: "wrong"})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	_, err := auth.Login(ctx, dto.LoginRequest{Email: "nobody@test.com", Password: "wrong"})
+	_, ok := IsLoginThrottled(err)
+	require.True(t, ok)
+	require.Empty(t, queue.sent)
+}
+
+func TestLoginThrottle_SuccessResetsFailures(t *testing.T) {
+	users := newFakeUserRepo()
+	attempts := newFakeLoginAttempts()
+	throttle := NewLoginThrottleService(attempts, users, &fakeMailQueue{}, DefaultLoginThrottlePolicy())
+	auth := NewAuthService(users, newFakeRefreshStore(), AuthOptions{Guard: throttle})
+	ctx := context.Background()
+	createUserViaService(t, auth, "ok@test.com", "right-pass")
+
+	for i := 0; i < 2; i++ {
+		_, err := auth.Login(ctx, dto.LoginRequest{Email: "ok@test.com", Password: "wrong"})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err := auth.Login(ctx, dto.LoginRequest{Email: "ok@test.com", Password: "right-pass"})
+	require.NoError(t, err)
+	require.Zero(t, attempts.fails["ok@test.com"])
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go_blog/dto"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+	"go_blog/internal/totp"
+	"go_blog/models"
+	"go_blog/stores"
+	"go_blog/utils"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount = 10
+	// попыток ввода кода на один MFA-challenge
+	mfaVerifyAttempts = 5
+	// и на пользователя по всем challenge: новый challenge после пароля не даёт новых попыток
+	mfaUserAttempts = 10
+	mfaUserWindow   = 15 * time.Minute
+)
+
+type MFAService struct {
+	db       *gorm.DB
+	repo     *repositories.MFARepository
+	users    UserRepo
+	outbox   *repositories.OutboxRepository
+	tokens   stores.RefreshStore
+	once     stores.OneTimeStore
+	counters stores.CounterStore
+	guard    LoginGuard
+}
+
+func NewMFAService(db *gorm.DB, repo *repositories.MFARepository, users UserRepo, outbox *repositories.OutboxRepository, tokens stores.RefreshStore, once stores.OneTimeStore, counters stores.CounterStore, guard LoginGuard) *MFAService {
+	return &MFAService{db: db, repo: repo, users: users, outbox: outbox, tokens: tokens, once: once, counters: counters, guard: guard}
+}
+
+func mfaIssuer() string {
+	if v := os.Getenv("MFA_ISSUER"); v != "" {
+		return v
+	}
+	return "go_blog"
+}
+
+// Enabled — 2FA включена, только если секрет подтверждён первым кодом
+func (s *MFAService) Enabled(ctx context.Context, uid uint) (bool, error) {
+	m, err := s.repo.Find(ctx, uid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return m.ConfirmedAt != nil, nil
+}
+
+// Challenge — вместо пары токенов после верного пароля
+func (s *MFAService) Challenge(ctx context.Context, uid uint) (string, error) {
+	token, _, err := utils.GenerateMFAChallengeJWT(uid)
+	if err != nil {
+		return "", ErrToken
+	}
+	return token, nil
+}
+
+// Enroll выдаёт новый секрет; 2FA включится после Confirm. Повторный Enroll до подтверждения заменяет секрет.
+// Нужен пароль: иначе украденный access-токен подключит свой TOTP и запрёт владельца.
+func (s *MFAService) Enroll(ctx context.Context, uid uint, password string) (dto.MFAEnrollResponse, error) {
+	user, err := s.checkPassword(ctx, uid, password)
+	if err != nil {
+		return dto.MFAEnrollResponse{}, err
+	}
+
+	enabled, err := s.Enabled(ctx, uid)
+	if err != nil {
+		return dto.MFAEnrollResponse{}, err
+	}
+	if enabled {
+		return dto.MFAEnrollResponse{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return dto.MFAEnrollResponse{}, err
+	}
+	enc, err := utils.EncryptMFASecret(secret)
+	if err != nil {
+		return dto.MFAEnrollResponse{}, err
+	}
+	if err := s.repo.SavePending(ctx, uid, enc); err != nil {
+		return dto.MFAEnrollResponse{}, err
+	}
+
+	return dto.MFAEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(mfaIssuer(), user.Email, secret),
+	}, nil
+}
+
+// Confirm включает 2FA по первому коду и отдаёт коды восстановления — показываются один раз; пароль — как в Enroll
+func (s *MFAService) Confirm(ctx context.Context, uid uint, password, code string) (dto.RecoveryCodesResponse, error) {
+	if _, err := s.checkPassword(ctx, uid, password); err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	m, err := s.repo.Find(ctx, uid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.RecoveryCodesResponse{}, ErrMFANotEnabled
+	}
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	if m.ConfirmedAt != nil {
+		return dto.RecoveryCodesResponse{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.DecryptMFASecret(m.SecretEnc)
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return dto.RecoveryCodesResponse{}, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		confirmed, err := s.repo.ConfirmTx(ctx, tx, uid, step)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrMFAAlreadyEnabled
+		}
+		if err := s.repo.ReplaceRecoveryCodesTx(ctx, tx, uid, hashes); err != nil {
+			return err
+		}
+		return s.mfaEventTx(ctx, tx, events.UserMFAEnabled, uid)
+	})
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	return dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Verify — вторая ступень входа: challenge из Login + код из приложения или код восстановления
+func (s *MFAService) Verify(ctx context.Context, mfaToken, code string) (dto.TokenPairResponse, error) {
+	claims, err := utils.ParseMFAChallengeJWT(mfaToken)
+	if err != nil {
+		return dto.TokenPairResponse{}, ErrInvalidMFAToken
+	}
+
+	n, err := s.counters.Hit(ctx, "mfa:"+claims.ID, time.Until(claims.Exp))
+	if err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+	if n > mfaVerifyAttempts {
+		return dto.TokenPairResponse{}, ErrTooManyRequests
+	}
+	n, err = s.counters.Hit(ctx, "mfa-user:"+uintToString(claims.UserID), mfaUserWindow)
+	if err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+	if n > mfaUserAttempts {
+		return dto.TokenPairResponse{}, ErrTooManyRequests
+	}
+
+	user, err := s.users.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+	// заблокирован между паролем и кодом
+	if !user.IsActive {
+		return dto.TokenPairResponse{}, ErrInvalidCredentials
+	}
+	if s.guard != nil {
+		if err := s.guard.Allow(ctx, user.Email); err != nil {
+			return dto.TokenPairResponse{}, err
+		}
+	}
+
+	m, err := s.confirmed(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrMFANotEnabled) {
+			return dto.TokenPairResponse{}, ErrInvalidMFAToken
+		}
+		return dto.TokenPairResponse{}, err
+	}
+
+	// challenge одноразовый и гасится до проверки кода: повторно предъявленный challenge
+	// не сожжёт ни шаг TOTP, ни код восстановления
+	fresh, err := s.once.Use(ctx, "mfa_challenge", claims.ID, time.Until(claims.Exp))
+	if err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+	if !fresh {
+		return dto.TokenPairResponse{}, ErrInvalidMFAToken
+	}
+
+	if err := s.checkCode(ctx, m, code); err != nil {
+		// код не принят — challenge годится для следующей попытки (их число ограничено выше)
+		if rerr := s.once.Release(ctx, "mfa_challenge", claims.ID); rerr != nil {
+			log.Printf("mfa: release challenge for user %d: %v", user.ID, rerr)
+		}
+		// неверный код — такая же неудачная попытка входа, как неверный пароль
+		if errors.Is(err, ErrInvalidMFACode) && s.guard != nil {
+			if gerr := s.guard.Failed(ctx, user.Email, user); gerr != nil {
+				log.Printf("mfa: record failed attempt for user %d: %v", user.ID, gerr)
+			}
+		}
+		return dto.TokenPairResponse{}, err
+	}
+
+	if s.guard != nil {
+		if err := s.guard.Succeeded(ctx, user.Email); err != nil {
+			log.Printf("mfa: reset failed attempts for user %d: %v", user.ID, err)
+		}
+	}
+	return issueTokens(ctx, s.tokens, user)
+}
+
+// Disable — с паролем и действующим кодом (или кодом восстановления)
+func (s *MFAService) Disable(ctx context.Context, uid uint, password, code string) error {
+	if err := s.reauth(ctx, uid, password, code); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.DeleteTx(ctx, tx, uid); err != nil {
+			return err
+		}
+		return s.mfaEventTx(ctx, tx, events.UserMFADisabled, uid)
+	})
+}
+
+// RegenerateRecoveryCodes — прежние коды перестают действовать
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, uid uint, password, code string) (dto.RecoveryCodesResponse, error) {
+	if err := s.reauth(ctx, uid, password, code); err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.ReplaceRecoveryCodesTx(ctx, tx, uid, hashes); err != nil {
+			return err
+		}
+		return s.mfaEventTx(ctx, tx, events.UserMFARecoveryCodesRenewed, uid)
+	})
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	return dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *MFAService) checkPassword(ctx context.Context, uid uint, password string) (*models.User, error) {
+	user, err := s.users.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.CheckPasswordHash(user.Password, password) {
+		return nil, ErrWrongPassword
+	}
+	return user, nil
+}
+
+func (s *MFAService) reauth(ctx context.Context, uid uint, password, code string) error {
+	if _, err := s.checkPassword(ctx, uid, password); err != nil {
+		return err
+	}
+
+	m, err := s.confirmed(ctx, uid)
+	if err != nil {
+		return err
+	}
+	return s.checkCode(ctx, m, code)
+}
+
+func (s *MFAService) confirmed(ctx context.Context, uid uint) (*models.UserMFA, error) {
+	m, err := s.repo.Find(ctx, uid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMFANotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.ConfirmedAt == nil {
+		return nil, ErrMFANotEnabled
+	}
+	return m, nil
+}
+
+// checkCode: 6 цифр — TOTP (шаг принимается один раз), иначе — код восстановления (гасится)
+func (s *MFAService) checkCode(ctx context.Context, m *models.UserMFA, code string) error {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits && strings.Trim(code, "0123456789") == "" {
+		secret, err := utils.DecryptMFASecret(m.SecretEnc)
+		if err != nil {
+			return err
+		}
+		step, ok := totp.Validate(secret, code, time.Now())
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		used, err := s.repo.UseStep(ctx, m.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, m.UserID, utils.HashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) mfaEventTx(ctx context.Context, tx *gorm.DB, eventType string, uid uint) error {
+	env, err := newEvent(ctx, eventType, "user", uintToString(uid), uintToString(uid), events.UserMFAPayload{
+		UserID: uintToString(uid),
+	})
+	if err != nil {
+		return err
+	}
+	return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+}
+
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	codes = make([]string, recoveryCodeCount)
+	hashes = make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = utils.NewRecoveryCode(); err != nil {
+			return nil, nil, err
+		}
+		hashes[i] = utils.HashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
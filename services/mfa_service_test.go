@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"go_blog/dto"
+	"go_blog/internal/repositories"
+	"go_blog/internal/totp"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"go_blog/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func totpCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	code, err := totp.Code(secret, step)
+	require.NoError(t, err)
+	return code
+}
+
+func TestMFAService_EnrollConfirmAndLogin(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	hash, err := utils.HashPassword("pass-123")
+	require.NoError(t, err)
+	user := &models.User{Nickname: "mfa", Email: "mfa@test.com", Password: hash, IsActive: true}
+	require.NoError(t, tx.Create(user).Error)
+
+	users := repositories.NewUserRepository(tx)
+	refresh := newFakeRefreshStore()
+	mfa := NewMFAService(tx, repositories.NewMFARepository(tx), users, repositories.NewOutboxRepository(tx),
+		refresh, &fakeOneTime{used: map[string]bool{}}, &fakeCounter{n: map[string]int64{}}, nil)
+	auth := NewAuthService(users, refresh, AuthOptions{MFA: mfa})
+	ctx := context.Background()
+	login := dto.LoginRequest{Email: user.Email, Password: "pass-123"}
+
+	// украденного access-токена мало, чтобы подключить свой TOTP
+	_, err = mfa.Enroll(ctx, user.ID, "wrong")
+	require.ErrorIs(t, err, ErrWrongPassword)
+	enroll, err := mfa.Enroll(ctx, user.ID, "pass-123")
+	require.NoError(t, err)
+	require.Contains(t, enroll.OTPAuthURI, "secret="+enroll.Secret)
+
+	// до подтверждения вход по паролю
+	out, err := auth.Login(ctx, login)
+	require.NoError(t, err)
+	require.False(t, out.MFARequired)
+	require.NotEmpty(t, out.AccessToken)
+
+	now := totp.Step(time.Now())
+	_, err = mfa.Confirm(ctx, user.ID, "wrong", totpCode(t, enroll.Secret, now))
+	require.ErrorIs(t, err, ErrWrongPassword)
+	_, err = mfa.Confirm(ctx, user.ID, "pass-123", "000000")
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+	codes, err := mfa.Confirm(ctx, user.ID, "pass-123", totpCode(t, enroll.Secret, now))
+	require.NoError(t, err)
+	require.Len(t, codes.RecoveryCodes, recoveryCodeCount)
+
+	_, err = mfa.Enroll(ctx, user.ID, "pass-123")
+	require.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+
+	out, err = auth.Login(ctx, login)
+	require.NoError(t, err)
+	require.True(t, out.MFARequired)
+	require.Empty(t, out.AccessToken)
+	require.Empty(t, out.RefreshToken)
+
+	// код подтверждения повторно не принимается
+	_, err = mfa.Verify(ctx, out.MFAToken, totpCode(t, enroll.Secret, now))
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+
+	pair, err := mfa.Verify(ctx, out.MFAToken, totpCode(t, enroll.Secret, now+1))
+	require.NoError(t, err)
+	require.NotEmpty(t, pair.AccessToken)
+	require.NotEmpty(t, pair.RefreshToken)
+
+	// challenge одноразовый; повтор не тратит код восстановления
+	_, err = mfa.Verify(ctx, out.MFAToken, codes.RecoveryCodes[0])
+	require.ErrorIs(t, err, ErrInvalidMFAToken)
+	out, err = auth.Login(ctx, login)
+	require.NoError(t, err)
+	_, err = mfa.Verify(ctx, out.MFAToken, codes.RecoveryCodes[0])
+	require.NoError(t, err)
+
+	_, err = mfa.Verify(ctx, "garbage", "123456")
+	require.ErrorIs(t, err, ErrInvalidMFAToken)
+
+	require.Equal(t, []string{"UserMFAEnabled"}, outboxTypes(t, tx))
+}
+
+func TestMFAService_RecoveryCodesAndDisable(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	hash, err := utils.HashPassword("pass-123")
+	require.NoError(t, err)
+	user := &models.User{Nickname: "mfa", Email: "mfa@test.com", Password: hash, IsActive: true}
+	require.NoError(t, tx.Create(user).Error)
+
+	users := repositories.NewUserRepository(tx)
+	refresh := newFakeRefreshStore()
+	mfa := NewMFAService(tx, repositories.NewMFARepository(tx), users, repositories.NewOutboxRepository(tx),
+		refresh, &fakeOneTime{used: map[string]bool{}}, &fakeCounter{n: map[string]int64{}}, nil)
+	auth := NewAuthService(users, refresh, AuthOptions{MFA: mfa})
+	ctx := context.Background()
+
+	enroll, err := mfa.Enroll(ctx, user.ID, "pass-123")
+	require.NoError(t, err)
+	codes, err := mfa.Confirm(ctx, user.ID, "pass-123", totpCode(t, enroll.Secret, totp.Step(time.Now())))
+	require.NoError(t, err)
+
+	out, err := auth.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "pass-123"})
+	require.NoError(t, err)
+	_, err = mfa.Verify(ctx, out.MFAToken, codes.RecoveryCodes[0])
+	require.NoError(t, err)
+
+	// код восстановления гасится
+	out, err = auth.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "pass-123"})
+	require.NoError(t, err)
+	_, err = mfa.Verify(ctx, out.MFAToken, codes.RecoveryCodes[0])
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+
+	renewed, err := mfa.RegenerateRecoveryCodes(ctx, user.ID, "pass-123", codes.RecoveryCodes[1])
+	require.NoError(t, err)
+
+	// после перевыпуска старые коды недействительны
+	require.ErrorIs(t, mfa.Disable(ctx, user.ID, "pass-123", codes.RecoveryCodes[2]), ErrInvalidMFACode)
+	require.ErrorIs(t, mfa.Disable(ctx, user.ID, "wrong", renewed.RecoveryCodes[0]), ErrWrongPassword)
+	require.NoError(t, mfa.Disable(ctx, user.ID, "pass-123", renewed.RecoveryCodes[0]))
+
+	out, err = auth.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "pass-123"})
+	require.NoError(t, err)
+	require.False(t, out.MFARequired)
+
+	require.Equal(t, []string{"UserMFAEnabled", "UserMFARecoveryCodesRenewed", "UserMFADisabled"}, outboxTypes(t, tx))
+}
+
+func TestMFAService_VerifyAttemptsLimited(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	hash, err := utils.HashPassword("pass-123")
+	require.NoError(t, err)
+	user := &models.User{Nickname: "mfa", Email: "mfa@test.com", Password: hash, IsActive: true}
+	require.NoError(t, tx.Create(user).Error)
+
+	users := repositories.NewUserRepository(tx)
+	refresh := newFakeRefreshStore()
+	mfa := NewMFAService(tx, repositories.NewMFARepository(tx), users, repositories.NewOutboxRepository(tx),
+		refresh, &fakeOneTime{used: map[string]bool{}}, &fakeCounter{n: map[string]int64{}}, nil)
+	auth := NewAuthService(users, refresh, AuthOptions{MFA: mfa})
+	ctx := context.Background()
+
+	enroll, err := mfa.Enroll(ctx, user.ID, "pass-123")
+	require.NoError(t, err)
+	_, err = mfa.Confirm(ctx, user.ID, "pass-123", totpCode(t, enroll.Secret, totp.Step(time.Now())))
+	require.NoError(t, err)
+
+	out, err := auth.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "pass-123"})
+	require.NoError(t, err)
+
+	for i := 0; i < mfaVerifyAttempts; i++ {
+		_, err = mfa.Verify(ctx, out.MFAToken, "wrong-code")
+		require.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+	_, err = mfa.Verify(ctx, out.MFAToken, totpCode(t, enroll.Secret, totp.Step(time.Now())+1))
+	require.ErrorIs(t, err, ErrTooManyRequests)
+}
+
+func TestMFAService_FailedCodesCountAsFailedLogins(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	hash, err := utils.HashPassword("pass-123")
+	require.NoError(t, err)
+	user := &models.User{Nickname: "mfa", Email: "mfa@test.com", Password: hash, IsActive: true}
+	require.NoError(t, tx.Create(user).Error)
+
+	users := repositories.NewUserRepository(tx)
+	refresh := newFakeRefreshStore()
+	attempts := newFakeLoginAttempts()
+	guard := NewLoginThrottleService(attempts, users, &fakeMailQueue{}, DefaultLoginThrottlePolicy())
+	mfa := NewMFAService(tx, repositories.NewMFARepository(tx), users, repositories.NewOutboxRepository(tx),
+		refresh, &fakeOneTime{used: map[string]bool{}}, &fakeCounter{n: map[string]int64{}}, guard)
+	auth := NewAuthService(users, refresh, AuthOptions{MFA: mfa, Guard: guard})
+	ctx := context.Background()
+	login := dto.LoginRequest{Email: user.Email, Password: "pass-123"}
+
+	enroll, err := mfa.Enroll(ctx, user.ID, "pass-123")
+	require.NoError(t, err)
+	now := totp.Step(time.Now())
+	_, err = mfa.Confirm(ctx, user.ID, "pass-123", totpCode(t, enroll.Secret, now))
+	require.NoError(t, err)
+
+	_, err = auth.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "wrong"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// верный пароль при включённой 2FA неудачи не сбрасывает, неверный код — добавляет
+	out, err := auth.Login(ctx, login)
+	require.NoError(t, err)
+	require.True(t, out.MFARequired)
+	require.Equal(t, int64(1), attempts.fails[user.Email])
+	_, err = mfa.Verify(ctx, out.MFAToken, "000000")
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+	require.Equal(t, int64(2), attempts.fails[user.Email])
+
+	// вход завершён — счётчик сброшен
+	_, err = mfa.Verify(ctx, out.MFAToken, totpCode(t, enroll.Secret, now+1))
+	require.NoError(t, err)
+	require.NotContains(t, attempts.fails, user.Email)
+
+	// новый challenge не даёт новых попыток: лимит на пользователя
+	for i := 2; i < mfaUserAttempts; i++ {
+		attempts.unblock()
+		out, err = auth.Login(ctx, login)
+		require.NoError(t, err)
+		_, err = mfa.Verify(ctx, out.MFAToken, "000000")
+		require.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+	attempts.unblock()
+	out, err = auth.Login(ctx, login)
+	require.NoError(t, err)
+	_, err = mfa.Verify(ctx, out.MFAToken, totpCode(t, enroll.Secret, now+2))
+	require.ErrorIs(t, err, ErrTooManyRequests)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go_blog/dto"
+	"go_blog/internal/mail"
+	"go_blog/internal/realtime"
+	"go_blog/internal/repositories"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+type NotificationService struct {
+	repo   *repositories.NotificationRepository
+	signer *mail.Signer
+}
+
+func NewNotificationService(repo *repositories.NotificationRepository, signer *mail.Signer) *NotificationService {
+	return &NotificationService{repo: repo, signer: signer}
+}
+
+// preferenceTypes — типы уведомлений и категории писем
+func preferenceTypes() []string {
+	return append(slices.Clone(realtime.NotificationTypes), mail.Categories...)
+}
+
+func (s *NotificationService) List(ctx context.Context, uid uint, unreadOnly bool, page, limit int) (dto.NotificationListResponse, error) {
+	items, total, err := s.repo.ListForUser(ctx, uid, unreadOnly, page, limit)
+	if err != nil {
+		return dto.NotificationListResponse{}, err
+	}
+	unread, err := s.repo.CountUnread(ctx, uid)
+	if err != nil {
+		return dto.NotificationListResponse{}, err
+	}
+
+	out := make([]dto.NotificationResponse, 0, len(items))
+	for _, n := range items {
+		out = append(out, dto.NotificationResponse{
+			ID:          n.ID,
+			Type:        n.Type,
+			ActorUserID: n.ActorUserID,
+			ActorCount:  n.ActorCount,
+			PostID:      n.PostID,
+			CommentID:   n.CommentID,
+			Data:        json.RawMessage(n.Data),
+			Read:        n.ReadAt != nil,
+			CreatedAt:   n.CreatedAt,
+			UpdatedAt:   n.UpdatedAt,
+		})
+	}
+
+	return dto.NotificationListResponse{
+		Ok:            true,
+		Page:          page,
+		Limit:         limit,
+		Total:         total,
+		UnreadCount:   unread,
+		Notifications: out,
+	}, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, uid, id uint) error {
+	if err := s.repo.MarkRead(ctx, uid, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotificationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, uid uint) (int64, error) {
+	return s.repo.MarkAllRead(ctx, uid)
+}
+
+// Preferences — все типы; не заданные явно включены
+func (s *NotificationService) Preferences(ctx context.Context, uid uint) (map[string]bool, error) {
+	set, err := s.repo.Preferences(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	types := preferenceTypes()
+	out := make(map[string]bool, len(types))
+	for _, t := range types {
+		enabled, ok := set[t]
+		out[t] = !ok || enabled
+	}
+	return out, nil
+}
+
+func (s *NotificationService) SetPreferences(ctx context.Context, uid uint, prefs map[string]bool) (map[string]bool, error) {
+	types := preferenceTypes()
+	for t := range prefs {
+		if !slices.Contains(types, t) {
+			return nil, ErrInvalidNotificationType
+		}
+	}
+	if err := s.repo.SetPreferences(ctx, uid, prefs); err != nil {
+		return nil, err
+	}
+	return s.Preferences(ctx, uid)
+}
+
+// UnsubscribeCategory — проверка ссылки из письма без изменений (страница подтверждения)
+func (s *NotificationService) UnsubscribeCategory(token string) (string, error) {
+	_, category, err := s.signer.Verify(token)
+	return category, err
+}
+
+// Unsubscribe — по подписанной ссылке из письма, без авторизации; возвращает категорию
+func (s *NotificationService) Unsubscribe(ctx context.Context, token string) (string, error) {
+	uid, category, err := s.signer.Verify(token)
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.SetPreferences(ctx, uid, map[string]bool{category: false}); err != nil {
+		return "", err
+	}
+	return category, nil
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go_blog/dto"
+	"go_blog/internal/events"
+	"go_blog/internal/oidc"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/stores"
+	"go_blog/utils"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// OIDCStateTTL — сколько ждём возврата пользователя от провайдера (и живёт state-cookie)
+	OIDCStateTTL = 10 * time.Minute
+	// oidcLoginCodeTTL — SPA обменивает код сразу после redirect'а
+	oidcLoginCodeTTL = time.Minute
+)
+
+// OIDCProvider — authorization code flow одного провайдера; реализует oidc.Client
+type OIDCProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (oidc.Claims, error)
+}
+
+type OIDCUserRepo interface {
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	CreateTx(ctx context.Context, tx *gorm.DB, user *models.User) error
+}
+
+type OIDCService struct {
+	db         *gorm.DB
+	providers  map[string]OIDCProvider
+	states     stores.OIDCStateStore
+	identities *repositories.IdentityRepository
+	users      OIDCUserRepo
+	outbox     *repositories.OutboxRepository
+	auth       *AuthService
+}
+
+func NewOIDCService(db *gorm.DB, providers map[string]OIDCProvider, states stores.OIDCStateStore, identities *repositories.IdentityRepository, users OIDCUserRepo, outbox *repositories.OutboxRepository, auth *AuthService) *OIDCService {
+	return &OIDCService{db: db, providers: providers, states: states, identities: identities, users: users, outbox: outbox, auth: auth}
+}
+
+// Providers — имена настроенных провайдеров
+func (s *OIDCService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start — ссылка на провайдера; state, nonce и PKCE verifier остаются в Redis до callback.
+// state возвращается и для cookie браузера, начавшего вход: callback примет только его.
+func (s *OIDCService) Start(ctx context.Context, provider string) (authURL, state string, err error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownOIDCProvider
+	}
+
+	var st stores.OIDCState
+	if state, err = oidc.RandomString(); err != nil {
+		return "", "", err
+	}
+	if st.Nonce, err = oidc.RandomString(); err != nil {
+		return "", "", err
+	}
+	if st.Verifier, err = oidc.RandomString(); err != nil {
+		return "", "", err
+	}
+	st.Provider = provider
+
+	authURL, err = p.AuthCodeURL(ctx, state, st.Nonce, st.Verifier)
+	if err != nil {
+		log.Printf("oidc %s: %v", provider, err)
+		return "", "", ErrOIDCProvider
+	}
+	if err := s.states.Save(ctx, state, st, OIDCStateTTL); err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// Callback завершает вход и возвращает одноразовый код, который SPA обменивает на результат
+// (Exchange). boundState — state из cookie браузера: без совпадения чужой незавершённый вход
+// нельзя доиграть в браузере жертвы (login CSRF).
+func (s *OIDCService) Callback(ctx context.Context, provider, state, boundState, code string) (string, error) {
+	if boundState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(boundState)) != 1 {
+		return "", ErrInvalidOIDCState
+	}
+
+	out, err := s.complete(ctx, provider, state, code)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	plain, hash, err := utils.NewOneTimeCode()
+	if err != nil {
+		return "", ErrToken
+	}
+	if err := s.states.SaveLogin(ctx, hash, string(result), oidcLoginCodeTTL); err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// Exchange гасит код из Callback и отдаёт результат входа
+func (s *OIDCService) Exchange(ctx context.Context, code string) (dto.OIDCCallbackResponse, error) {
+	result, err := s.states.ConsumeLogin(ctx, utils.HashRefresh(code))
+	if errors.Is(err, stores.ErrInvalidOIDCLoginCode) {
+		return dto.OIDCCallbackResponse{}, ErrInvalidOIDCLoginCode
+	}
+	if err != nil {
+		return dto.OIDCCallbackResponse{}, err
+	}
+
+	var out dto.OIDCCallbackResponse
+	if err := json.Unmarshal([]byte(result), &out); err != nil {
+		return dto.OIDCCallbackResponse{}, ErrInvalidOIDCLoginCode
+	}
+	return out, nil
+}
+
+// complete: известный вход — пара токенов (через ту же 2FA, что и пароль); новый адрес — новый аккаунт;
+// адрес уже занят — привязка только после входа в этот аккаунт (link_token)
+func (s *OIDCService) complete(ctx context.Context, provider, state, code string) (dto.OIDCCallbackResponse, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return dto.OIDCCallbackResponse{}, ErrUnknownOIDCProvider
+	}
+
+	st, err := s.states.Consume(ctx, state)
+	if err != nil {
+		if errors.Is(err, stores.ErrInvalidOIDCState) {
+			return dto.OIDCCallbackResponse{}, ErrInvalidOIDCState
+		}
+		return dto.OIDCCallbackResponse{}, err
+	}
+	// state выдан для другого провайдера
+	if st.Provider != provider {
+		return dto.OIDCCallbackResponse{}, ErrInvalidOIDCState
+	}
+
+	claims, err := p.Exchange(ctx, code, st.Verifier, st.Nonce)
+	if err != nil {
+		log.Printf("oidc %s: %v", provider, err)
+		return dto.OIDCCallbackResponse{}, ErrOIDCProvider
+	}
+
+	identity, err := s.identities.FindBySubject(ctx, provider, claims.Subject)
+	switch {
+	case err == nil:
+		return s.loginIdentity(ctx, identity)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return dto.OIDCCallbackResponse{}, err
+	}
+
+	if claims.Email == "" {
+		return dto.OIDCCallbackResponse{}, ErrOIDCEmailRequired
+	}
+	// неподтверждённый адрес у провайдера — не доказательство владения: ни аккаунта, ни привязки
+	if !claims.EmailVerified {
+		return dto.OIDCCallbackResponse{}, ErrOIDCEmailUnverified
+	}
+
+	existing, err := s.users.FindByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		token, _, err := utils.GenerateOIDCLinkJWT(existing.ID, provider, claims.Subject, claims.Email)
+		if err != nil {
+			return dto.OIDCCallbackResponse{}, ErrToken
+		}
+		return dto.OIDCCallbackResponse{LinkRequired: true, LinkToken: token}, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return dto.OIDCCallbackResponse{}, err
+	}
+
+	user, err := s.createUser(ctx, provider, claims)
+	if err != nil {
+		return dto.OIDCCallbackResponse{}, err
+	}
+	out, err := s.auth.LoginVerified(ctx, user)
+	return dto.OIDCCallbackResponse{LoginResponse: out}, err
+}
+
+// ConfirmLink — владелец аккаунта (уже вошедший) подтверждает привязку входа через провайдера
+func (s *OIDCService) ConfirmLink(ctx context.Context, uid uint, linkToken string) error {
+	claims, err := utils.ParseOIDCLinkJWT(linkToken)
+	if err != nil || claims.UserID != uid {
+		return ErrInvalidLinkToken
+	}
+	if _, ok := s.providers[claims.Provider]; !ok {
+		return ErrUnknownOIDCProvider
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.linkTx(ctx, tx, uid, claims.Provider, claims.Subject, claims.Email)
+	})
+}
+
+func (s *OIDCService) loginIdentity(ctx context.Context, identity *models.Identity) (dto.OIDCCallbackResponse, error) {
+	user, err := s.users.FindByID(ctx, identity.UserID)
+	if err != nil {
+		return dto.OIDCCallbackResponse{}, err
+	}
+	if !user.IsActive {
+		return dto.OIDCCallbackResponse{}, ErrInvalidCredentials
+	}
+	out, err := s.auth.LoginVerified(ctx, user)
+	return dto.OIDCCallbackResponse{LoginResponse: out}, err
+}
+
+// createUser — аккаунт без пароля: войти по паролю можно будет после сброса пароля.
+// Адрес провайдер подтвердил — он сразу считается подтверждённым.
+func (s *OIDCService) createUser(ctx context.Context, provider string, claims oidc.Claims) (*models.User, error) {
+	if !claims.EmailVerified {
+		return nil, ErrOIDCEmailUnverified
+	}
+
+	secret, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := utils.HashPassword(secret)
+	if err != nil {
+		return nil, err
+	}
+	nickname, err := oidcNickname(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	user := &models.User{Nickname: nickname, Email: claims.Email, Password: hash, IsActive: true, Role: models.RoleUser, EmailVerifiedAt: &now}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.users.CreateTx(ctx, tx, user); err != nil {
+			return err
+		}
+		return s.linkTx(ctx, tx, user.ID, provider, claims.Subject, claims.Email)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *OIDCService) linkTx(ctx context.Context, tx *gorm.DB, uid uint, provider, subject, email string) error {
+	err := s.identities.CreateTx(ctx, tx, &models.Identity{UserID: uid, Provider: provider, Subject: subject, Email: email})
+	if errors.Is(err, repositories.ErrIdentityExists) {
+		return ErrIdentityLinked
+	}
+	if err != nil {
+		return err
+	}
+
+	env, err := newEvent(ctx, events.UserIdentityLinked, "user", uintToString(uid), uintToString(uid), events.UserIdentityLinkedPayload{
+		UserID:   uintToString(uid),
+		Provider: provider,
+	})
+	if err != nil {
+		return err
+	}
+	return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+}
+
+// oidcNickname — из preferred_username или адреса, со случайным хвостом: ник уникален
+func oidcNickname(claims oidc.Claims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(base) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '.' || r == '-' {
+			b.WriteRune(r)
+		}
+		if b.Len() >= 20 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("user")
+	}
+
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return b.String() + "_" + hex.EncodeToString(suffix), nil
+}
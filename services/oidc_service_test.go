@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"go_blog/dto"
+	"go_blog/internal/oidc"
+	"go_blog/internal/oidc/oidctest"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/stores"
+	"go_blog/testhelpers"
+	"go_blog/utils"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOIDCStates struct {
+	states map[string]stores.OIDCState
+	logins map[string]string
+}
+
+func (f *fakeOIDCStates) Save(ctx context.Context, state string, v stores.OIDCState, ttl time.Duration) error {
+	f.states[state] = v
+	return nil
+}
+
+func (f *fakeOIDCStates) Consume(ctx context.Context, state string) (stores.OIDCState, error) {
+	v, ok := f.states[state]
+	if !ok {
+		return stores.OIDCState{}, stores.ErrInvalidOIDCState
+	}
+	delete(f.states, state)
+	return v, nil
+}
+
+func (f *fakeOIDCStates) SaveLogin(ctx context.Context, hash, result string, ttl time.Duration) error {
+	f.logins[hash] = result
+	return nil
+}
+
+func (f *fakeOIDCStates) ConsumeLogin(ctx context.Context, hash string) (string, error) {
+	v, ok := f.logins[hash]
+	if !ok {
+		return "", stores.ErrInvalidOIDCLoginCode
+	}
+	delete(f.logins, hash)
+	return v, nil
+}
+
+func oidcLogin(t *testing.T, svc *OIDCService, p *oidctest.Provider, u oidctest.User) (code, state string) {
+	t.Helper()
+	authURL, _, err := svc.Start(context.Background(), "stub")
+	require.NoError(t, err)
+	return p.Authorize(t, authURL, u)
+}
+
+// oidcFinish — callback в браузере, начавшем вход (state совпадает с cookie), и обмен кода
+func oidcFinish(ctx context.Context, svc *OIDCService, state, code string) (dto.OIDCCallbackResponse, error) {
+	loginCode, err := svc.Callback(ctx, "stub", state, state, code)
+	if err != nil {
+		return dto.OIDCCallbackResponse{}, err
+	}
+	return svc.Exchange(ctx, loginCode)
+}
+
+func TestOIDCService_NewUserThenReturningLogin(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	p := oidctest.NewProvider(t)
+	users := repositories.NewUserRepository(tx)
+	auth := NewAuthService(users, newFakeRefreshStore(), AuthOptions{})
+	svc := NewOIDCService(tx, map[string]OIDCProvider{"stub": oidc.NewClient(p.Config("stub", "http://app/cb"), nil)},
+		&fakeOIDCStates{states: map[string]stores.OIDCState{}, logins: map[string]string{}}, repositories.NewIdentityRepository(tx), users,
+		repositories.NewOutboxRepository(tx), auth)
+	ctx := context.Background()
+	u := oidctest.User{Subject: "sub-1", Email: "new@test.com", EmailVerified: true, Name: "New"}
+
+	code, state := oidcLogin(t, svc, p, u)
+	out, err := oidcFinish(ctx, svc, state, code)
+	require.NoError(t, err)
+	require.False(t, out.LinkRequired)
+	require.NotEmpty(t, out.AccessToken)
+	require.NotEmpty(t, out.RefreshToken)
+
+	// state одноразовый
+	_, err = oidcFinish(ctx, svc, state, code)
+	require.ErrorIs(t, err, ErrInvalidOIDCState)
+
+	var user models.User
+	require.NoError(t, tx.Where("email = ?", "new@test.com").First(&user).Error)
+	require.NotNil(t, user.EmailVerifiedAt)
+	require.Regexp(t, regexp.MustCompile(`^new_[0-9a-f]{6}$`), user.Nickname)
+
+	code, state = oidcLogin(t, svc, p, u)
+	out, err = oidcFinish(ctx, svc, state, code)
+	require.NoError(t, err)
+	require.NotEmpty(t, out.AccessToken)
+
+	var count int64
+	require.NoError(t, tx.Model(&models.User{}).Where("email = ?", "new@test.com").Count(&count).Error)
+	require.Equal(t, int64(1), count)
+	require.Equal(t, []string{"UserIdentityLinked"}, outboxTypes(t, tx))
+}
+
+func TestOIDCService_ExistingEmailNeedsConfirmedLink(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	p := oidctest.NewProvider(t)
+	users := repositories.NewUserRepository(tx)
+	auth := NewAuthService(users, newFakeRefreshStore(), AuthOptions{})
+	svc := NewOIDCService(tx, map[string]OIDCProvider{"stub": oidc.NewClient(p.Config("stub", "http://app/cb"), nil)},
+		&fakeOIDCStates{states: map[string]stores.OIDCState{}, logins: map[string]string{}}, repositories.NewIdentityRepository(tx), users,
+		repositories.NewOutboxRepository(tx), auth)
+	ctx := context.Background()
+
+	hash, err := utils.HashPassword("pass-123")
+	require.NoError(t, err)
+	owner := &models.User{Nickname: "owner", Email: "owner@test.com", Password: hash, IsActive: true}
+	require.NoError(t, tx.Create(owner).Error)
+
+	u := oidctest.User{Subject: "sub-owner", Email: "owner@test.com", EmailVerified: true}
+	code, state := oidcLogin(t, svc, p, u)
+	out, err := oidcFinish(ctx, svc, state, code)
+	require.NoError(t, err)
+	require.True(t, out.LinkRequired)
+	require.Empty(t, out.AccessToken)
+
+	// подтвердить может только владелец аккаунта
+	require.ErrorIs(t, svc.ConfirmLink(ctx, owner.ID+1, out.LinkToken), ErrInvalidLinkToken)
+	require.ErrorIs(t, svc.ConfirmLink(ctx, owner.ID, "garbage"), ErrInvalidLinkToken)
+	require.NoError(t, svc.ConfirmLink(ctx, owner.ID, out.LinkToken))
+	require.ErrorIs(t, svc.ConfirmLink(ctx, owner.ID, out.LinkToken), ErrIdentityLinked)
+
+	code, state = oidcLogin(t, svc, p, u)
+	out, err = oidcFinish(ctx, svc, state, code)
+	require.NoError(t, err)
+	require.False(t, out.LinkRequired)
+	require.NotEmpty(t, out.AccessToken)
+}
+
+func TestOIDCService_RejectsUnverifiedEmail(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	p := oidctest.NewProvider(t)
+	users := repositories.NewUserRepository(tx)
+	auth := NewAuthService(users, newFakeRefreshStore(), AuthOptions{})
+	svc := NewOIDCService(tx, map[string]OIDCProvider{"stub": oidc.NewClient(p.Config("stub", "http://app/cb"), nil)},
+		&fakeOIDCStates{states: map[string]stores.OIDCState{}, logins: map[string]string{}}, repositories.NewIdentityRepository(tx), users,
+		repositories.NewOutboxRepository(tx), auth)
+	ctx := context.Background()
+
+	hash, err := utils.HashPassword("pass-123")
+	require.NoError(t, err)
+	require.NoError(t, tx.Create(&models.User{Nickname: "owner", Email: "owner@test.com", Password: hash, IsActive: true}).Error)
+
+	// ни нового аккаунта, ни предложения привязки к существующему
+	for _, email := range []string{"new@test.com", "owner@test.com"} {
+		code, state := oidcLogin(t, svc, p, oidctest.User{Subject: "sub-" + email, Email: email, EmailVerified: false})
+		_, err := oidcFinish(ctx, svc, state, code)
+		require.ErrorIs(t, err, ErrOIDCEmailUnverified, email)
+	}
+
+	var count int64
+	require.NoError(t, tx.Model(&models.User{}).Where("email = ?", "new@test.com").Count(&count).Error)
+	require.Zero(t, count)
+	require.NoError(t, tx.Model(&models.Identity{}).Count(&count).Error)
+	require.Zero(t, count)
+	require.Empty(t, outboxTypes(t, tx))
+}
+
+func TestOIDCService_RejectsUnknownProviderAndForeignState(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	p := oidctest.NewProvider(t)
+	users := repositories.NewUserRepository(tx)
+	auth := NewAuthService(users, newFakeRefreshStore(), AuthOptions{})
+	svc := NewOIDCService(tx, map[string]OIDCProvider{"stub": oidc.NewClient(p.Config("stub", "http://app/cb"), nil)},
+		&fakeOIDCStates{states: map[string]stores.OIDCState{}, logins: map[string]string{}}, repositories.NewIdentityRepository(tx), users,
+		repositories.NewOutboxRepository(tx), auth)
+	ctx := context.Background()
+
+	_, _, err := svc.Start(ctx, "nope")
+	require.ErrorIs(t, err, ErrUnknownOIDCProvider)
+
+	code, _ := oidcLogin(t, svc, p, oidctest.User{Subject: "sub-1", Email: "x@test.com"})
+	_, err = svc.Callback(ctx, "stub", "forged-state", "forged-state", code)
+	require.ErrorIs(t, err, ErrInvalidOIDCState)
+}
+
+func TestOIDCService_CallbackBoundToStartingBrowser(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	p := oidctest.NewProvider(t)
+	users := repositories.NewUserRepository(tx)
+	auth := NewAuthService(users, newFakeRefreshStore(), AuthOptions{})
+	svc := NewOIDCService(tx, map[string]OIDCProvider{"stub": oidc.NewClient(p.Config("stub", "http://app/cb"), nil)},
+		&fakeOIDCStates{states: map[string]stores.OIDCState{}, logins: map[string]string{}}, repositories.NewIdentityRepository(tx), users,
+		repositories.NewOutboxRepository(tx), auth)
+	ctx := context.Background()
+	u := oidctest.User{Subject: "sub-1", Email: "new@test.com", EmailVerified: true}
+
+	// вход атакующего, доигранный в браузере жертвы: у жертвы нет (или другая) state-cookie
+	code, state := oidcLogin(t, svc, p, u)
+	for _, bound := range []string{"", "victim-state"} {
+		_, err := svc.Callback(ctx, "stub", state, bound, code)
+		require.ErrorIs(t, err, ErrInvalidOIDCState)
+	}
+
+	loginCode, err := svc.Callback(ctx, "stub", state, state, code)
+	require.NoError(t, err)
+	require.NotContains(t, loginCode, ".") // не JWT
+
+	out, err := svc.Exchange(ctx, loginCode)
+	require.NoError(t, err)
+	require.NotEmpty(t, out.AccessToken)
+
+	// код одноразовый
+	_, err = svc.Exchange(ctx, loginCode)
+	require.ErrorIs(t, err, ErrInvalidOIDCLoginCode)
+}
+
+func TestOIDCNickname(t *testing.T) {
+	for claims, prefix := range map[oidc.Claims]string{
+		{PreferredUsername: "Ann.Lee"}:                    "ann.lee_",
+		{Email: "bob+tag@test.com"}:                       "bobtag_",
+		{Email: "@test.com"}:                              "user_",
+		{Email: "a-very-long-local-part-indeed@test.com"}: "a-very-long-local-pa_",
+	} {
+		got, err := oidcNickname(claims)
+		require.NoError(t, err)
+		require.Regexp(t, "^"+regexp.QuoteMeta(prefix)+"[0-9a-f]{6}$", got)
+		require.LessOrEqual(t, len(got), 30)
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"go_blog/internal/events"
+	"go_blog/internal/requestid"
+	"go_blog/models"
+)
+
+const eventsTopic = "blog.events"
+
+// newEvent — событие последней версии схемы, привязанное к текущему HTTP-запросу
+func newEvent(ctx context.Context, eventType, aggregateType, aggregateID, actorUserID string, payload any) (events.Envelope, error) {
+	env, err := events.Schemas.NewEnvelope(eventType, aggregateType, aggregateID, actorUserID, payload)
+	if err != nil {
+		return events.Envelope{}, err
+	}
+	env.CorrelationID = requestid.FromContext(ctx)
+	return env, nil
+}
+
+func newOutboxEvent(env events.Envelope) *models.OutboxEvent {
+	return &models.OutboxEvent{
+		EventID:       env.EventID,
+		Topic:         eventsTopic,
+		EventType:     env.EventType,
+		AggregateType: env.AggregateType,
+		AggregateID:   env.AggregateID,
+		ActorUserID:   env.ActorUserID,
+		CorrelationID: env.CorrelationID,
+		Version:       env.Version,
+		Payload:       string(env.Payload),
+		OccurredAt:    env.OccurredAt,
+		Status:        models.OutboxNew,
+	}
+}
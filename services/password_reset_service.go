@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go_blog/internal/mail"
+	"go_blog/models"
+	"go_blog/stores"
+	"go_blog/utils"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	forgotLimit  = 3
+	forgotWindow = time.Hour
+	// поиск адреса и постановка письма в очередь идут после ответа — столько им даётся
+	forgotSendTimeout = 30 * time.Second
+)
+
+type PasswordResetUserRepo interface {
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	UpdatePassword(ctx context.Context, id uint, hash string) error
+}
+
+type PasswordResetService struct {
+	users    PasswordResetUserRepo
+	resets   stores.PasswordResetStore
+	refresh  stores.RefreshStore
+	counters stores.CounterStore
+	mail     MailQueue
+	access   stores.AccessRevocationStore
+	pats     PersonalTokenRevoker
+	// async запускает отправку из Forgot в фоне; тесты подменяют на синхронный вызов
+	async func(func())
+}
+
+func NewPasswordResetService(users PasswordResetUserRepo, resets stores.PasswordResetStore, refresh stores.RefreshStore, counters stores.CounterStore, mail MailQueue, access stores.AccessRevocationStore, pats PersonalTokenRevoker) *PasswordResetService {
+	return &PasswordResetService{users: users, resets: resets, refresh: refresh, counters: counters, mail: mail, access: access, pats: pats,
+		async: func(f func()) { go f() }}
+}
+
+type passwordResetData struct {
+	URL       string
+	ExpiresIn string
+}
+
+// Forgot ничего не сообщает наружу о существовании адреса: нет пользователя
+// или превышен лимит — тот же nil, что и при отправке. Поиск пользователя и письмо — в фоне
+// для любого адреса: по времени ответа известный адрес не отличить от неизвестного.
+func (s *PasswordResetService) Forgot(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+
+	n, err := s.counters.Hit(ctx, "pwreset:"+strings.ToLower(email), forgotWindow)
+	if err != nil {
+		return err
+	}
+	if n > forgotLimit {
+		return nil
+	}
+
+	bg := context.WithoutCancel(ctx)
+	s.async(func() {
+		ctx, cancel := context.WithTimeout(bg, forgotSendTimeout)
+		defer cancel()
+		if err := s.sendForgot(ctx, email); err != nil {
+			log.Printf("password reset: send link: %v", err)
+		}
+	})
+	return nil
+}
+
+func (s *PasswordResetService) sendForgot(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.SendLink(ctx, user)
+}
+
+// SendLink — письмо со ссылкой сброса без лимита Forgot (сброс, назначенный администратором)
+func (s *PasswordResetService) SendLink(ctx context.Context, user *models.User) error {
+	plain, hash, err := utils.NewPasswordResetToken()
+	if err != nil {
+		return ErrToken
+	}
+	ttl := utils.PasswordResetTTL()
+	if err := s.resets.Save(ctx, user.ID, hash, ttl); err != nil {
+		return err
+	}
+
+	data := passwordResetData{
+		URL:       s.mail.Link("/reset-password?token=" + plain),
+		ExpiresIn: strconv.Itoa(int(ttl.Minutes())) + " minutes",
+	}
+	return s.mail.Enqueue(ctx, mail.TemplatePasswordReset, "",
+		mail.Recipient{UserID: user.ID, Email: user.Email, Name: user.Nickname}, data,
+		"pwreset:"+hash)
+}
+
+// Reset меняет пароль и завершает все сессии пользователя
+func (s *PasswordResetService) Reset(ctx context.Context, token, newPassword string) error {
+	uid, err := s.resets.Consume(ctx, utils.HashRefresh(token))
+	if err != nil {
+		if errors.Is(err, stores.ErrInvalidResetToken) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	if _, err := s.users.FindByID(ctx, uid); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.users.UpdatePassword(ctx, uid, hash); err != nil {
+		return err
+	}
+
+	return revokeCompromised(ctx, s.refresh, s.access, s.pats, uid)
+}
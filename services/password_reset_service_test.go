@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"go_blog/dto"
+	"go_blog/internal/mail"
+	"go_blog/stores"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResetStore struct {
+	byHash map[string]uint
+	byUser map[uint]string
+}
+
+func (f *fakeResetStore) Save(ctx context.Context, uid uint, hash string, ttl time.Duration) error {
+	delete(f.byHash, f.byUser[uid])
+	f.byHash[hash] = uid
+	f.byUser[uid] = hash
+	return nil
+}
+
+func (f *fakeResetStore) Consume(ctx context.Context, hash string) (uint, error) {
+	uid, ok := f.byHash[hash]
+	if !ok {
+		return 0, stores.ErrInvalidResetToken
+	}
+	delete(f.byHash, hash)
+	return uid, nil
+}
+
+func (f *fakeUserRepo) UpdatePassword(ctx context.Context, id uint, hash string) error {
+	f.users[id].Password = hash
+	return nil
+}
+
+func resetToken(t *testing.T, q *fakeMailQueue) string {
+	t.Helper()
+	require.NotEmpty(t, q.sent)
+	last := q.sent[len(q.sent)-1]
+	require.Equal(t, mail.TemplatePasswordReset, last.tmpl)
+	u, err := url.Parse(last.data.(passwordResetData).URL)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+func TestPasswordReset_ResetsPasswordAndRevokesSessions(t *testing.T) {
+	users := newFakeUserRepo()
+	tokens := newFakeRefreshStore()
+	queue := &fakeMailQueue{}
+	access := newFakeAccessRevocations()
+	pats := &fakePersonalTokens{}
+	resets := NewPasswordResetService(users, &fakeResetStore{byHash: map[string]uint{}, byUser: map[uint]string{}},
+		tokens, &fakeCounter{n: map[string]int64{}}, queue, access, pats)
+	resets.async = func(f func()) { f() }
+	auth := NewAuthService(users, tokens, AuthOptions{})
+	ctx := context.Background()
+
+	uid := createUserViaService(t, auth, "reset@test.com", "old-pass")
+	session, err := auth.Login(ctx, dto.LoginRequest{Email: "reset@test.com", Password: "old-pass"})
+	require.NoError(t, err)
+
+	require.NoError(t, resets.Forgot(ctx, "reset@test.com"))
+	token := resetToken(t, queue)
+
+	require.NoError(t, resets.Reset(ctx, token, "new-pass"))
+
+	// ссылка одноразовая
+	require.ErrorIs(t, resets.Reset(ctx, token, "other-pass"), ErrInvalidResetToken)
+
+	_, err = auth.Refresh(ctx, session.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+	require.Contains(t, access.users, uid)
+	require.Equal(t, []uint{uid}, pats.revoked)
+
+	_, err = auth.Login(ctx, dto.LoginRequest{Email: "reset@test.com", Password: "old-pass"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = auth.Login(ctx, dto.LoginRequest{Email: "reset@test.com", Password: "new-pass"})
+	require.NoError(t, err)
+}
+
+func TestPasswordReset_UnknownEmailLooksTheSame(t *testing.T) {
+	users := newFakeUserRepo()
+	tokens := newFakeRefreshStore()
+	queue := &fakeMailQueue{}
+	resets := NewPasswordResetService(users, &fakeResetStore{byHash: map[string]uint{}, byUser: map[uint]string{}},
+		tokens, &fakeCounter{n: map[string]int64{}}, queue, newFakeAccessRevocations(), &fakePersonalTokens{})
+	resets.async = func(f func()) { f() }
+	auth := NewAuthService(users, tokens, AuthOptions{})
+	ctx := context.Background()
+	createUserViaService(t, auth, "known@test.com", "pass")
+
+	// до ответа ни для какого адреса ни поиска, ни письма — только фоновая задача
+	var pending []func()
+	resets.async = func(f func()) { pending = append(pending, f) }
+	require.NoError(t, resets.Forgot(ctx, "nobody@test.com"))
+	require.NoError(t, resets.Forgot(ctx, "known@test.com"))
+	require.Empty(t, queue.sent)
+	require.Len(t, pending, 2)
+
+	for _, f := range pending {
+		f()
+	}
+	require.Len(t, queue.sent, 1)
+	require.Equal(t, "known@test.com", queue.sent[0].to.Email)
+}
+
+func TestPasswordReset_NewLinkInvalidatesOldAndLimit(t *testing.T) {
+	users := newFakeUserRepo()
+	tokens := newFakeRefreshStore()
+	queue := &fakeMailQueue{}
+	resets := NewPasswordResetService(users, &fakeResetStore{byHash: map[string]uint{}, byUser: map[uint]string{}},
+		tokens, &fakeCounter{n: map[string]int64{}}, queue, newFakeAccessRevocations(), &fakePersonalTokens{})
+	resets.async = func(f func()) { f() }
+	auth := NewAuthService(users, tokens, AuthOptions{})
+	ctx := context.Background()
+	createUserViaService(t, auth, "twice@test.com", "old-pass")
+
+	require.NoError(t, resets.Forgot(ctx, "twice@test.com"))
+	first := resetToken(t, queue)
+	require.NoError(t, resets.Forgot(ctx, "twice@test.com"))
+	second := resetToken(t, queue)
+
+	require.ErrorIs(t, resets.Reset(ctx, first, "new-pass"), ErrInvalidResetToken)
+	require.NoError(t, resets.Reset(ctx, second, "new-pass"))
+
+	// сверх лимита — молча ничего не шлём
+	for i := 0; i < forgotLimit; i++ {
+		require.NoError(t, resets.Forgot(ctx, "twice@test.com"))
+	}
+	require.Len(t, queue.sent, forgotLimit)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go_blog/dto"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxPersonalTokens — на пользователя; токены без срока иначе копятся бесконечно
+const maxPersonalTokens = 50
+
+// personalTokenPrefixLen — сколько символов токена видно в списке: "gbp_" + 4
+const personalTokenPrefixLen = len(utils.PersonalTokenPrefix) + 4
+
+// PersonalTokenService — токены доступа для скриптов и CI (/user/me/tokens)
+type PersonalTokenService struct {
+	repo *repositories.PersonalTokenRepository
+}
+
+func NewPersonalTokenService(repo *repositories.PersonalTokenRepository) *PersonalTokenService {
+	return &PersonalTokenService{repo: repo}
+}
+
+// Create — токен в ответе показывается один раз, хранится только его hash
+func (s *PersonalTokenService) Create(ctx context.Context, uid uint, req dto.CreatePersonalTokenRequest) (dto.PersonalTokenResponse, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return dto.PersonalTokenResponse{}, err
+	}
+
+	n, err := s.repo.CountByUser(ctx, uid)
+	if err != nil {
+		return dto.PersonalTokenResponse{}, err
+	}
+	if n >= maxPersonalTokens {
+		return dto.PersonalTokenResponse{}, ErrTooManyPersonalTokens
+	}
+
+	plain, hash, err := utils.NewPersonalToken()
+	if err != nil {
+		return dto.PersonalTokenResponse{}, ErrToken
+	}
+
+	t := &models.PersonalAccessToken{
+		UserID:    uid,
+		Name:      strings.TrimSpace(req.Name),
+		TokenHash: hash,
+		Prefix:    plain[:personalTokenPrefixLen],
+		Scopes:    strings.Join(scopes, ","),
+	}
+	if req.ExpiresInDays != nil {
+		exp := time.Now().UTC().AddDate(0, 0, *req.ExpiresInDays)
+		t.ExpiresAt = &exp
+	}
+	if err := s.repo.Create(ctx, t); err != nil {
+		return dto.PersonalTokenResponse{}, err
+	}
+
+	resp := personalTokenToResp(*t)
+	resp.Token = plain
+	return resp, nil
+}
+
+// List — включая истёкшие: пользователь должен видеть, что скрипт перестал работать из-за срока
+func (s *PersonalTokenService) List(ctx context.Context, uid uint) ([]dto.PersonalTokenResponse, error) {
+	tokens, err := s.repo.ListByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.PersonalTokenResponse, 0, len(tokens))
+	for _, t := range tokens {
+		out = append(out, personalTokenToResp(t))
+	}
+	return out, nil
+}
+
+// Revoke — удаление действует сразу: токен проверяется по БД на каждом запросе
+func (s *PersonalTokenService) Revoke(ctx context.Context, uid, id uint) error {
+	ok, err := s.repo.Delete(ctx, uid, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPersonalTokenNotFound
+	}
+	return nil
+}
+
+func normalizeScopes(in []string) ([]string, error) {
+	out := make([]string, 0, len(in))
+	for _, sc := range in {
+		sc = strings.TrimSpace(sc)
+		if !slices.Contains(models.Scopes, sc) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, sc)
+		}
+		if !slices.Contains(out, sc) {
+			out = append(out, sc)
+		}
+	}
+	return out, nil
+}
+
+func personalTokenToResp(t models.PersonalAccessToken) dto.PersonalTokenResponse {
+	return dto.PersonalTokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     t.ScopeList(),
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
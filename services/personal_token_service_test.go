@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"go_blog/dto"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"go_blog/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalTokenService_CreateListRevoke(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	ctx := context.Background()
+
+	user := &models.User{Nickname: "ci", Email: "ci@test.com", Password: "x", IsActive: true}
+	require.NoError(t, tx.Create(user).Error)
+
+	svc := NewPersonalTokenService(repositories.NewPersonalTokenRepository(tx))
+
+	days := 30
+	created, err := svc.Create(ctx, user.ID, dto.CreatePersonalTokenRequest{
+		Name:          "deploy",
+		Scopes:        []string{models.ScopePostsWrite, models.ScopePostsWrite, models.ScopeRead},
+		ExpiresInDays: &days,
+	})
+	require.NoError(t, err)
+	require.True(t, utils.IsPersonalToken(created.Token))
+	require.Equal(t, created.Token[:len(created.Prefix)], created.Prefix)
+	require.Equal(t, []string{models.ScopePostsWrite, models.ScopeRead}, created.Scopes)
+	require.WithinDuration(t, time.Now().AddDate(0, 0, days), *created.ExpiresAt, time.Minute)
+
+	// в БД только hash
+	var stored models.PersonalAccessToken
+	require.NoError(t, tx.First(&stored, created.ID).Error)
+	require.Equal(t, utils.HashRefresh(created.Token), stored.TokenHash)
+
+	list, err := svc.List(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Empty(t, list[0].Token)
+
+	require.ErrorIs(t, svc.Revoke(ctx, user.ID+1, created.ID), ErrPersonalTokenNotFound)
+	require.NoError(t, svc.Revoke(ctx, user.ID, created.ID))
+	require.ErrorIs(t, svc.Revoke(ctx, user.ID, created.ID), ErrPersonalTokenNotFound)
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	_, err := normalizeScopes([]string{models.ScopeRead, "admin"})
+	require.ErrorIs(t, err, ErrInvalidScope)
+
+	got, err := normalizeScopes([]string{" read ", models.ScopeCommentsWrite, models.ScopeRead})
+	require.NoError(t, err)
+	require.Equal(t, []string{models.ScopeRead, models.ScopeCommentsWrite}, got)
+}
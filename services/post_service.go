@@ -2,16 +2,13 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"go_blog/internal/events"
 	"go_blog/internal/repositories"
 	"go_blog/models"
 	"strings"
-	"time"
 
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -47,7 +44,7 @@ func (s *PostService) Create(ctx context.Context, uid uint, title, text string)
 
 		created = post
 
-		payloadBytes, err := json.Marshal(events.PostCreatedPayload{
+		env, err := newEvent(ctx, events.PostCreated, "post", uintToString(post.ID), uintToString(uid), events.PostCreatedPayload{
 			PostID: uintToString(post.ID),
 			Title:  post.Title,
 			Slug:   post.Slug,
@@ -56,30 +53,7 @@ func (s *PostService) Create(ctx context.Context, uid uint, title, text string)
 			return err
 		}
 
-		env := events.Envelope{
-			EventID:       uuid.NewString(),
-			EventType:     "PostCreated",
-			OccurredAt:    time.Now().UTC(),
-			AggregateType: "post",
-			AggregateID:   uintToString(post.ID),
-			ActorUserID:   uintToString(uid),
-			Version:       1,
-			Payload:       payloadBytes,
-		}
-
-		out := &models.OutboxEvent{
-			EventID:       env.EventID,
-			Topic:         "blog.events",
-			EventType:     env.EventType,
-			AggregateType: env.AggregateType,
-			AggregateID:   env.AggregateID,
-			ActorUserID:   env.ActorUserID,
-			Payload:       string(env.Payload),
-			OccurredAt:    env.OccurredAt,
-			Status:        models.OutboxNew,
-		}
-
-		return s.outbox.CreateTx(ctx, tx, out)
+		return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
 	})
 
 	if err != nil {
@@ -90,7 +64,8 @@ func (s *PostService) Create(ctx context.Context, uid uint, title, text string)
 
 }
 
-func (s *PostService) Update(ctx context.Context, slug string, uid uint, title, text *string) (*models.Post, error) {
+// Update: автор правит свой пост; роль с PermPostEditAny — любой (событие пишется от имени модератора)
+func (s *PostService) Update(ctx context.Context, slug string, uid uint, role string, title, text *string) (*models.Post, error) {
 	updates := map[string]any{}
 
 	if title != nil {
@@ -104,7 +79,33 @@ func (s *PostService) Update(ctx context.Context, slug string, uid uint, title,
 		return nil, ErrNoFieldsToUpdate
 	}
 
-	post, err := s.repo.UpdateOwnedBy(ctx, slug, uid, updates)
+	var post *models.Post
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var updated *models.Post
+		var err error
+		if models.HasPermission(role, models.PermPostEditAny) {
+			updated, err = s.repo.UpdateAnyTx(ctx, tx, slug, updates)
+		} else {
+			updated, err = s.repo.UpdateOwnedByTx(ctx, tx, slug, uid, updates)
+		}
+		if err != nil {
+			return err
+		}
+
+		post = updated
+
+		env, err := newEvent(ctx, events.PostUpdated, "post", uintToString(updated.ID), uintToString(uid), events.PostUpdatedPayload{
+			PostID: uintToString(updated.ID),
+			Title:  updated.Title,
+			Slug:   updated.Slug,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+	})
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrPostNotFound
@@ -112,17 +113,43 @@ func (s *PostService) Update(ctx context.Context, slug string, uid uint, title,
 		return nil, err
 	}
 
+	s.repo.Invalidate(ctx, slug)
+
 	return post, nil
 }
 
-func (s *PostService) Delete(ctx context.Context, slug string, uid uint) error {
-	err := s.repo.DeleteOwnedBy(ctx, slug, uid)
+// Delete: автор удаляет свой пост; роль с PermPostDeleteAny — любой
+func (s *PostService) Delete(ctx context.Context, slug string, uid uint, role string) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var post *models.Post
+		var err error
+		if models.HasPermission(role, models.PermPostDeleteAny) {
+			post, err = s.repo.DeleteAnyTx(ctx, tx, slug)
+		} else {
+			post, err = s.repo.DeleteOwnedByTx(ctx, tx, slug, uid)
+		}
+		if err != nil {
+			return err
+		}
+
+		env, err := newEvent(ctx, events.PostDeleted, "post", uintToString(post.ID), uintToString(uid), events.PostDeletedPayload{
+			PostID: uintToString(post.ID),
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+	})
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrPostNotFound
 		}
 		return err
 	}
+
+	s.repo.Invalidate(ctx, slug)
+
 	return nil
 }
 
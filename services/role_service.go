@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go_blog/dto"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/stores"
+
+	"gorm.io/gorm"
+)
+
+// RoleService — назначение ролей администратором
+type RoleService struct {
+	db     *gorm.DB
+	users  *repositories.UserRepository
+	outbox *repositories.OutboxRepository
+	access stores.AccessRevocationStore
+}
+
+func NewRoleService(db *gorm.DB, users *repositories.UserRepository, outbox *repositories.OutboxRepository, access stores.AccessRevocationStore) *RoleService {
+	return &RoleService{db: db, users: users, outbox: outbox, access: access}
+}
+
+// Assign: роль зашита в access-токены, поэтому уже выданные отзываются —
+// после refresh пользователь получит токен с новой ролью. Свою роль менять нельзя:
+// так последний администратор не лишит себя прав случайно.
+func (s *RoleService) Assign(ctx context.Context, actorID, uid uint, role string) (dto.UserRoleResponse, error) {
+	if !models.IsValidRole(role) {
+		return dto.UserRoleResponse{}, ErrInvalidRole
+	}
+	if actorID == uid {
+		return dto.UserRoleResponse{}, ErrCannotChangeOwnRole
+	}
+
+	user, err := s.users.FindByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.UserRoleResponse{}, ErrUserNotFound
+		}
+		return dto.UserRoleResponse{}, err
+	}
+	if user.Role == role {
+		return roleToResp(uid, role), nil
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.users.UpdateRoleTx(ctx, tx, uid, role); err != nil {
+			return err
+		}
+
+		env, err := newEvent(ctx, events.UserRoleChanged, "user", uintToString(uid), uintToString(actorID), events.UserRoleChangedPayload{
+			UserID:  uintToString(uid),
+			OldRole: user.Role,
+			NewRole: role,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+	})
+	if err != nil {
+		return dto.UserRoleResponse{}, err
+	}
+
+	if err := s.access.RevokeUser(ctx, uid); err != nil {
+		return dto.UserRoleResponse{}, err
+	}
+	return roleToResp(uid, role), nil
+}
+
+func roleToResp(uid uint, role string) dto.UserRoleResponse {
+	return dto.UserRoleResponse{UserID: uid, Role: role, Permissions: models.RolePermissions(role)}
+}
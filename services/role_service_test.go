@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleService_Assign(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	ctx := context.Background()
+
+	admin := &models.User{Nickname: "admin", Email: "admin@test.com", Password: "x", IsActive: true, Role: models.RoleAdmin}
+	user := &models.User{Nickname: "mod", Email: "mod@test.com", Password: "x", IsActive: true, Role: models.RoleUser}
+	require.NoError(t, tx.Create(admin).Error)
+	require.NoError(t, tx.Create(user).Error)
+
+	access := newFakeAccessRevocations()
+	svc := NewRoleService(tx, repositories.NewUserRepository(tx), repositories.NewOutboxRepository(tx), access)
+
+	_, err := svc.Assign(ctx, admin.ID, user.ID, "root")
+	require.ErrorIs(t, err, ErrInvalidRole)
+	_, err = svc.Assign(ctx, admin.ID, admin.ID, models.RoleUser)
+	require.ErrorIs(t, err, ErrCannotChangeOwnRole)
+	_, err = svc.Assign(ctx, admin.ID, user.ID+100, models.RoleModerator)
+	require.ErrorIs(t, err, ErrUserNotFound)
+
+	out, err := svc.Assign(ctx, admin.ID, user.ID, models.RoleModerator)
+	require.NoError(t, err)
+	require.Equal(t, models.RoleModerator, out.Role)
+	require.Contains(t, out.Permissions, models.PermCommentModerate)
+
+	var stored models.User
+	require.NoError(t, tx.First(&stored, user.ID).Error)
+	require.Equal(t, models.RoleModerator, stored.Role)
+
+	// access-токены со старой ролью отозваны
+	require.Contains(t, access.users, user.ID)
+	require.Equal(t, []string{"UserRoleChanged"}, outboxTypes(t, tx))
+}
+
+func TestPostService_ModeratorEditsAnyPost(t *testing.T) {
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+	ctx := context.Background()
+
+	author := &models.User{Nickname: "author", Email: "author@test.com", Password: "x", IsActive: true}
+	other := &models.User{Nickname: "other", Email: "other@test.com", Password: "x", IsActive: true}
+	mod := &models.User{Nickname: "mod", Email: "mod@test.com", Password: "x", IsActive: true, Role: models.RoleModerator}
+	require.NoError(t, tx.Create(author).Error)
+	require.NoError(t, tx.Create(other).Error)
+	require.NoError(t, tx.Create(mod).Error)
+
+	posts := NewPostService(tx, repositories.NewPostRepository(tx, nil), repositories.NewOutboxRepository(tx))
+	comments := NewCommentService(tx, repositories.NewCommentRepository(tx), repositories.NewOutboxRepository(tx))
+
+	post, err := posts.Create(ctx, author.ID, "Title", "Text")
+	require.NoError(t, err)
+	comment, err := comments.Create(ctx, post.Slug, author.ID, nil, "hi")
+	require.NoError(t, err)
+
+	title := "Edited"
+	_, err = posts.Update(ctx, post.Slug, other.ID, models.RoleUser, &title, nil)
+	require.ErrorIs(t, err, ErrPostNotFound)
+	require.ErrorIs(t, comments.Delete(ctx, comment.ID, other.ID, models.RoleUser), repositories.ErrForbidden)
+
+	updated, err := posts.Update(ctx, post.Slug, mod.ID, models.RoleModerator, &title, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Edited", updated.Title)
+	require.Equal(t, author.ID, updated.UserID)
+
+	require.NoError(t, comments.Delete(ctx, comment.ID, mod.ID, models.RoleModerator))
+	require.NoError(t, posts.Delete(ctx, post.Slug, mod.ID, models.RoleModerator))
+}
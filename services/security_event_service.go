@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"go_blog/internal/clientinfo"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// SecurityEventService пишет события безопасности в outbox (в аудит, не в вебхуки)
+type SecurityEventService struct {
+	db     *gorm.DB
+	outbox *repositories.OutboxRepository
+}
+
+func NewSecurityEventService(db *gorm.DB, outbox *repositories.OutboxRepository) *SecurityEventService {
+	return &SecurityEventService{db: db, outbox: outbox}
+}
+
+func (s *SecurityEventService) RefreshTokenReused(ctx context.Context, uid uint, sessionID string, device clientinfo.Info) error {
+	env, err := newEvent(ctx, events.RefreshTokenReused, "user", uintToString(uid), uintToString(uid), events.RefreshTokenReusedPayload{
+		UserID:    uintToString(uid),
+		SessionID: sessionID,
+		IP:        device.IP,
+		UserAgent: device.UserAgent,
+	})
+	if err != nil {
+		return err
+	}
+	return s.outbox.CreateTx(ctx, s.db.WithContext(ctx), newOutboxEvent(env))
+}
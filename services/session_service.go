@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go_blog/dto"
+	"go_blog/stores"
+	"time"
+)
+
+// PersonalTokenRevoker — токены доступа (gbp_…) отзываются удалением
+type PersonalTokenRevoker interface {
+	DeleteByUser(ctx context.Context, uid uint) error
+}
+
+// SessionService — устройства пользователя (refresh-сессии)
+type SessionService struct {
+	tokens stores.RefreshStore
+	access stores.AccessRevocationStore
+}
+
+func NewSessionService(tokens stores.RefreshStore, access stores.AccessRevocationStore) *SessionService {
+	return &SessionService{tokens: tokens, access: access}
+}
+
+// List — сессии пользователя, свежие первыми; currentID помечает сессию текущего access-токена
+func (s *SessionService) List(ctx context.Context, uid uint, currentID string) ([]dto.SessionResponse, error) {
+	sessions, err := s.tokens.List(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]dto.SessionResponse, 0, len(sessions))
+	for _, sess := range sessions {
+		out = append(out, dto.SessionResponse{
+			ID:         sess.ID,
+			UserAgent:  sess.UserAgent,
+			IP:         sess.IP,
+			CreatedAt:  sess.CreatedAt,
+			LastUsedAt: sess.LastUsedAt,
+			ExpiresAt:  sess.ExpiresAt,
+			Current:    currentID != "" && sess.ID == currentID,
+		})
+	}
+	return out, nil
+}
+
+// Revoke закрывает одну сессию вместе с её access-токенами; чужая или истёкшая — ErrSessionNotFound
+func (s *SessionService) Revoke(ctx context.Context, uid uint, sessionID string) error {
+	if err := s.tokens.RevokeSession(ctx, uid, sessionID); err != nil {
+		if errors.Is(err, stores.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	return s.access.RevokeSession(ctx, sessionID)
+}
+
+// RevokeAll — «выйти везде», включая текущее устройство; токены доступа (gbp_…) не трогает
+func (s *SessionService) RevokeAll(ctx context.Context, uid uint) error {
+	return revokeEverywhere(ctx, s.tokens, s.access, uid)
+}
+
+// revokeEverywhere — все refresh-сессии и все уже выданные access-токены пользователя
+func revokeEverywhere(ctx context.Context, refresh stores.RefreshStore, access stores.AccessRevocationStore, uid uint) error {
+	if err := refresh.RevokeAll(ctx, uid); err != nil {
+		return err
+	}
+	return access.RevokeUser(ctx, uid)
+}
+
+// revokeCompromised — пароль мог утечь: кроме сессий удаляются и токены доступа,
+// которые могли выпустить по украденному паролю
+func revokeCompromised(ctx context.Context, refresh stores.RefreshStore, access stores.AccessRevocationStore, pats PersonalTokenRevoker, uid uint) error {
+	if err := revokeEverywhere(ctx, refresh, access, uid); err != nil {
+		return err
+	}
+	return pats.DeleteByUser(ctx, uid)
+}
+
+// RevokeAccessToken — в denylist до exp (logout с предъявленным access-токеном)
+func (s *SessionService) RevokeAccessToken(ctx context.Context, jti string, exp time.Time) error {
+	return s.access.RevokeToken(ctx, jti, exp)
+}
@@ -2,16 +2,33 @@ package services
 
 import (
 	"context"
+	"errors"
 	"go_blog/dto"
+	"go_blog/internal/events"
+	"go_blog/internal/mail"
 	"go_blog/internal/repositories"
+	"go_blog/stores"
+	"go_blog/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type UserService struct {
-	users *repositories.UserRepository
+	db      *gorm.DB
+	users   *repositories.UserRepository
+	outbox  *repositories.OutboxRepository
+	refresh stores.RefreshStore
+	once    stores.OneTimeStore
+	mail    MailQueue
+	access  stores.AccessRevocationStore
+	pats    PersonalTokenRevoker
 }
 
-func NewUserService(users *repositories.UserRepository) *UserService {
-	return &UserService{users: users}
+func NewUserService(db *gorm.DB, users *repositories.UserRepository, outbox *repositories.OutboxRepository, refresh stores.RefreshStore, once stores.OneTimeStore, mail MailQueue, access stores.AccessRevocationStore, pats PersonalTokenRevoker) *UserService {
+	return &UserService{db: db, users: users, outbox: outbox, refresh: refresh, once: once, mail: mail, access: access, pats: pats}
 }
 
 func (s *UserService) Me(ctx context.Context, userID uint) (dto.UserMeResponse, error) {
@@ -21,8 +38,155 @@ func (s *UserService) Me(ctx context.Context, userID uint) (dto.UserMeResponse,
 	}
 
 	return dto.UserMeResponse{
-		ID:       u.ID,
-		Nickname: u.Nickname,
-		Email:    u.Email,
+		ID:            u.ID,
+		Nickname:      u.Nickname,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerifiedAt != nil,
 	}, nil
 }
+
+// ChangePassword: все остальные сессии завершаются, вызывающему выдаётся новая пара токенов
+func (s *UserService) ChangePassword(ctx context.Context, uid uint, current, next string) (dto.TokenPairResponse, error) {
+	user, err := s.users.FindByID(ctx, uid)
+	if err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+	if !utils.CheckPasswordHash(user.Password, current) {
+		return dto.TokenPairResponse{}, ErrWrongPassword
+	}
+
+	hash, err := utils.HashPassword(next)
+	if err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.users.UpdatePasswordTx(ctx, tx, uid, hash); err != nil {
+			return err
+		}
+
+		env, err := newEvent(ctx, events.UserPasswordChanged, "user", uintToString(uid), uintToString(uid), events.UserPasswordChangedPayload{
+			UserID: uintToString(uid),
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+	})
+	if err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+
+	if err := revokeCompromised(ctx, s.refresh, s.access, s.pats, uid); err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+	return issueTokens(ctx, s.refresh, user)
+}
+
+type emailChangeData struct {
+	URL       string
+	ExpiresIn string
+}
+
+// RequestEmailChange — адрес не меняется, пока владелец нового не откроет ссылку из письма
+func (s *UserService) RequestEmailChange(ctx context.Context, uid uint, password, newEmail string) error {
+	newEmail = strings.TrimSpace(newEmail)
+
+	user, err := s.users.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if !utils.CheckPasswordHash(user.Password, password) {
+		return ErrWrongPassword
+	}
+	if strings.EqualFold(user.Email, newEmail) {
+		return ErrSameEmail
+	}
+
+	taken, err := s.users.EmailTaken(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrEmailTaken
+	}
+
+	token, claims, err := utils.GenerateEmailChangeJWT(uid, user.Email, newEmail)
+	if err != nil {
+		return ErrToken
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		env, err := newEvent(ctx, events.UserEmailChangeRequested, "user", uintToString(uid), uintToString(uid), events.UserEmailChangeRequestedPayload{
+			UserID:   uintToString(uid),
+			NewEmail: newEmail,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+	})
+	if err != nil {
+		return err
+	}
+
+	data := emailChangeData{
+		URL:       s.mail.Link("/confirm-email-change?token=" + token),
+		ExpiresIn: strconv.Itoa(int(utils.EmailVerifyTTL().Hours())) + " hours",
+	}
+	return s.mail.Enqueue(ctx, mail.TemplateEmailChange, "",
+		mail.Recipient{UserID: uid, Email: newEmail, Name: user.Nickname}, data,
+		"email-change:"+claims.ID)
+}
+
+// ConfirmEmailChange — по ссылке из письма, в сессии того же пользователя
+func (s *UserService) ConfirmEmailChange(ctx context.Context, uid uint, token string) (dto.TokenPairResponse, error) {
+	claims, err := utils.ParseEmailChangeJWT(token)
+	if err != nil || claims.UserID != uid {
+		return dto.TokenPairResponse{}, ErrInvalidVerificationToken
+	}
+
+	fresh, err := s.once.Use(ctx, "email_change", claims.ID, time.Until(claims.Exp))
+	if err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+	if !fresh {
+		return dto.TokenPairResponse{}, ErrInvalidVerificationToken
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ok, err := s.users.ChangeEmailTx(ctx, tx, uid, claims.OldEmail, claims.Email)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserExists) {
+				return ErrEmailTaken
+			}
+			return err
+		}
+		// адрес сменили другой ссылкой — эта устарела
+		if !ok {
+			return ErrInvalidVerificationToken
+		}
+
+		env, err := newEvent(ctx, events.UserEmailChanged, "user", uintToString(uid), uintToString(uid), events.UserEmailChangedPayload{
+			UserID:   uintToString(uid),
+			OldEmail: claims.OldEmail,
+			NewEmail: claims.Email,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.CreateTx(ctx, tx, newOutboxEvent(env))
+	})
+	if err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+
+	user, err := s.users.FindByID(ctx, uid)
+	if err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+	if err := revokeEverywhere(ctx, s.refresh, s.access, uid); err != nil {
+		return dto.TokenPairResponse{}, err
+	}
+	return issueTokens(ctx, s.refresh, user)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"go_blog/internal/repositories"
+	"go_blog/models"
+	"go_blog/testhelpers"
+	"go_blog/utils"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func outboxTypes(t *testing.T, tx *gorm.DB) []string {
+	t.Helper()
+	var types []string
+	require.NoError(t, tx.Model(&models.OutboxEvent{}).Order("id").Pluck("event_type", &types).Error)
+	return types
+}
+
+func TestUserService_ChangePassword_RevokesOtherSessions(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	hash, err := utils.HashPassword("old-pass")
+	require.NoError(t, err)
+	user := &models.User{Nickname: "acc", Email: "acc@test.com", Password: hash, IsActive: true}
+	require.NoError(t, tx.Create(user).Error)
+
+	refresh := newFakeRefreshStore()
+	svc := NewUserService(tx, repositories.NewUserRepository(tx), repositories.NewOutboxRepository(tx),
+		refresh, &fakeOneTime{used: map[string]bool{}}, &fakeMailQueue{}, newFakeAccessRevocations(), &fakePersonalTokens{})
+	ctx := context.Background()
+	refresh.hashToUser["other-session"] = user.ID
+
+	_, err = svc.ChangePassword(ctx, user.ID, "wrong", "new-pass")
+	require.ErrorIs(t, err, ErrWrongPassword)
+
+	out, err := svc.ChangePassword(ctx, user.ID, "old-pass", "new-pass")
+	require.NoError(t, err)
+	require.NotEmpty(t, out.RefreshToken)
+
+	_, ok := refresh.hashToUser["other-session"]
+	require.False(t, ok)
+	require.Equal(t, user.ID, refresh.hashToUser[utils.HashRefresh(out.RefreshToken)])
+
+	var got models.User
+	require.NoError(t, tx.First(&got, user.ID).Error)
+	require.True(t, utils.CheckPasswordHash(got.Password, "new-pass"))
+	require.Equal(t, []string{"UserPasswordChanged"}, outboxTypes(t, tx))
+}
+
+func TestUserService_ChangeEmail_SwitchesOnlyAfterConfirm(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := testhelpers.SetupTestDB(t)
+	tx := testhelpers.BeginTx(t, db)
+
+	hash, err := utils.HashPassword("old-pass")
+	require.NoError(t, err)
+	user := &models.User{Nickname: "acc", Email: "acc@test.com", Password: hash, IsActive: true}
+	require.NoError(t, tx.Create(user).Error)
+
+	refresh := newFakeRefreshStore()
+	queue := &fakeMailQueue{}
+	svc := NewUserService(tx, repositories.NewUserRepository(tx), repositories.NewOutboxRepository(tx),
+		refresh, &fakeOneTime{used: map[string]bool{}}, queue, newFakeAccessRevocations(), &fakePersonalTokens{})
+	ctx := context.Background()
+	refresh.hashToUser["other-session"] = user.ID
+
+	require.ErrorIs(t, svc.RequestEmailChange(ctx, user.ID, "old-pass", "ACC@test.com"), ErrSameEmail)
+	require.NoError(t, svc.RequestEmailChange(ctx, user.ID, "old-pass", "new@test.com"))
+
+	var got models.User
+	require.NoError(t, tx.First(&got, user.ID).Error)
+	require.Equal(t, "acc@test.com", got.Email)
+
+	require.Len(t, queue.sent, 1)
+	require.Equal(t, "new@test.com", queue.sent[0].to.Email)
+	u, err := url.Parse(queue.sent[0].data.(emailChangeData).URL)
+	require.NoError(t, err)
+	token := u.Query().Get("token")
+
+	// чужая сессия ссылку не примет
+	_, err = svc.ConfirmEmailChange(ctx, user.ID+1, token)
+	require.ErrorIs(t, err, ErrInvalidVerificationToken)
+
+	_, err = svc.ConfirmEmailChange(ctx, user.ID, token)
+	require.NoError(t, err)
+
+	require.NoError(t, tx.First(&got, user.ID).Error)
+	require.Equal(t, "new@test.com", got.Email)
+	require.NotNil(t, got.EmailVerifiedAt)
+	_, ok := refresh.hashToUser["other-session"]
+	require.False(t, ok)
+
+	_, err = svc.ConfirmEmailChange(ctx, user.ID, token)
+	require.ErrorIs(t, err, ErrInvalidVerificationToken)
+
+	require.Equal(t, []string{"UserEmailChangeRequested", "UserEmailChanged"}, outboxTypes(t, tx))
+}
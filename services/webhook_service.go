@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_blog/dto"
+	"go_blog/internal/events"
+	"go_blog/internal/repositories"
+	"go_blog/internal/webhooks"
+	"go_blog/models"
+	"net/url"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type WebhookService struct {
+	repo *repositories.WebhookRepository
+}
+
+func NewWebhookService(repo *repositories.WebhookRepository) *WebhookService {
+	return &WebhookService{repo: repo}
+}
+
+func (s *WebhookService) Create(ctx context.Context, uid uint, req dto.WebhookCreateRequest) (dto.WebhookResponse, error) {
+	if err := validateWebhookURL(ctx, req.URL); err != nil {
+		return dto.WebhookResponse{}, err
+	}
+	types, err := normalizeEventTypes(req.EventTypes)
+	if err != nil {
+		return dto.WebhookResponse{}, err
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		return dto.WebhookResponse{}, err
+	}
+
+	sub := &models.WebhookSubscription{
+		UserID:     uid,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: strings.Join(types, ","),
+		IsActive:   true,
+	}
+	if err := s.repo.Create(ctx, sub); err != nil {
+		return dto.WebhookResponse{}, err
+	}
+
+	resp := webhookToResp(*sub)
+	resp.Secret = secret
+	return resp, nil
+}
+
+func (s *WebhookService) List(ctx context.Context, uid uint) ([]dto.WebhookResponse, error) {
+	subs, err := s.repo.ListByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.WebhookResponse, 0, len(subs))
+	for _, sub := range subs {
+		out = append(out, webhookToResp(sub))
+	}
+	return out, nil
+}
+
+func (s *WebhookService) Get(ctx context.Context, uid, id uint) (dto.WebhookResponse, error) {
+	sub, err := s.find(ctx, uid, id)
+	if err != nil {
+		return dto.WebhookResponse{}, err
+	}
+	return webhookToResp(*sub), nil
+}
+
+func (s *WebhookService) Update(ctx context.Context, uid, id uint, req dto.WebhookUpdateRequest) (dto.WebhookResponse, error) {
+	sub, err := s.find(ctx, uid, id)
+	if err != nil {
+		return dto.WebhookResponse{}, err
+	}
+
+	updates := map[string]any{}
+	if req.URL != nil {
+		if err := validateWebhookURL(ctx, *req.URL); err != nil {
+			return dto.WebhookResponse{}, err
+		}
+		updates["url"] = *req.URL
+	}
+	if req.EventTypes != nil {
+		types, err := normalizeEventTypes(req.EventTypes)
+		if err != nil {
+			return dto.WebhookResponse{}, err
+		}
+		updates["event_types"] = strings.Join(types, ",")
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+		if *req.IsActive {
+			// ручное включение — начинаем счёт неудач заново
+			updates["failure_count"] = 0
+			updates["disabled_at"] = nil
+			updates["disabled_reason"] = ""
+		}
+	}
+
+	if len(updates) == 0 {
+		return dto.WebhookResponse{}, ErrNoFieldsToUpdate
+	}
+
+	if err := s.repo.Update(ctx, sub, updates); err != nil {
+		return dto.WebhookResponse{}, err
+	}
+
+	if req.IsActive != nil && !*req.IsActive {
+		if err := s.repo.FailPending(ctx, sub.ID, "subscription disabled"); err != nil {
+			return dto.WebhookResponse{}, err
+		}
+	}
+
+	sub, err = s.find(ctx, uid, id)
+	if err != nil {
+		return dto.WebhookResponse{}, err
+	}
+	return webhookToResp(*sub), nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, uid, id uint) error {
+	sub, err := s.find(ctx, uid, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, sub); err != nil {
+		return err
+	}
+	return s.repo.FailPending(ctx, sub.ID, "subscription deleted")
+}
+
+func (s *WebhookService) Deliveries(ctx context.Context, uid, id uint, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	sub, err := s.find(ctx, uid, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListDeliveries(ctx, sub.ID, page, limit)
+}
+
+func (s *WebhookService) find(ctx context.Context, uid, id uint) (*models.WebhookSubscription, error) {
+	sub, err := s.repo.FindOwnedBy(ctx, id, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return sub, nil
+}
+
+// validateWebhookURL: только http(s) и только публичные адреса — на внутренние сервисы вебхук не настроить
+func validateWebhookURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ErrInvalidWebhookURL
+	}
+	if err := webhooks.CheckURL(ctx, raw); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhookURL, err)
+	}
+	return nil
+}
+
+func normalizeEventTypes(in []string) ([]string, error) {
+	known := events.Schemas.PublicTypes()
+
+	out := make([]string, 0, len(in))
+	for _, t := range in {
+		t = strings.TrimSpace(t)
+		if t == "*" {
+			return []string{"*"}, nil
+		}
+		if !slices.Contains(known, t) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidEventType, t)
+		}
+		if !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func webhookToResp(sub models.WebhookSubscription) dto.WebhookResponse {
+	return dto.WebhookResponse{
+		ID:             sub.ID,
+		URL:            sub.URL,
+		EventTypes:     strings.Split(sub.EventTypes, ","),
+		IsActive:       sub.IsActive,
+		FailureCount:   sub.FailureCount,
+		DisabledAt:     sub.DisabledAt,
+		DisabledReason: sub.DisabledReason,
+		CreatedAt:      sub.CreatedAt,
+	}
+}
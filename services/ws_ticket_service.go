@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go_blog/dto"
+	"go_blog/stores"
+	"go_blog/utils"
+)
+
+// WSTicketService — билеты на /ws: браузерный WebSocket не ставит Authorization,
+// а access-токен в URL попал бы в логи. В URL уходит только одноразовый билет.
+type WSTicketService struct {
+	tickets stores.WSTicketStore
+}
+
+func NewWSTicketService(tickets stores.WSTicketStore) *WSTicketService {
+	return &WSTicketService{tickets: tickets}
+}
+
+// Issue — билет на уже проверенный access-токен
+func (s *WSTicketService) Issue(ctx context.Context, accessToken string) (dto.WSTicketResponse, error) {
+	plain, hash, err := utils.NewWSTicket()
+	if err != nil {
+		return dto.WSTicketResponse{}, ErrToken
+	}
+	if err := s.tickets.Save(ctx, hash, accessToken, utils.WSTicketTTL); err != nil {
+		return dto.WSTicketResponse{}, err
+	}
+	return dto.WSTicketResponse{Ticket: plain, ExpiresIn: int(utils.WSTicketTTL.Seconds())}, nil
+}
+
+// Redeem гасит билет и возвращает access-токен, которым он выдан
+func (s *WSTicketService) Redeem(ctx context.Context, ticket string) (string, error) {
+	token, err := s.tickets.Consume(ctx, utils.HashRefresh(ticket))
+	if errors.Is(err, stores.ErrInvalidWSTicket) {
+		return "", ErrInvalidWSTicket
+	}
+	return token, err
+}
@@ -0,0 +1,94 @@
+package stores
+
+import (
+	"context"
+	"go_blog/utils"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AccessToken — то, по чему проверяется отзыв уже выданного access-токена
+type AccessToken struct {
+	ID        string // jti
+	UserID    uint
+	SessionID string
+	IssuedAt  time.Time
+}
+
+// AccessRevocationStore — отзыв access-токенов до их exp
+type AccessRevocationStore interface {
+	// RevokeToken — denylist одного токена до его exp
+	RevokeToken(ctx context.Context, jti string, exp time.Time) error
+	// RevokeSession — все токены сессии (закрытое устройство)
+	RevokeSession(ctx context.Context, sessionID string) error
+	// RevokeUser — все токены пользователя, выданные не позже текущей миллисекунды
+	RevokeUser(ctx context.Context, userID uint) error
+	// IsRevoked — одна поездка в Redis
+	IsRevoked(ctx context.Context, t AccessToken) (bool, error)
+}
+
+type AccessRevocationRedisStore struct {
+	rdb *redis.Client
+}
+
+func NewAccessRevocationRedisStore(rdb *redis.Client) *AccessRevocationRedisStore {
+	return &AccessRevocationRedisStore{rdb: rdb}
+}
+
+// access:deny:<jti>                -> 1, TTL до exp токена
+// access:revoked:session:<sid>     -> 1, TTL = AccessTTL
+// access:revoked:user:<id>         -> unix-время отзыва в мс, TTL = AccessTTL
+//
+// Метки сессии и пользователя живут AccessTTL: к этому времени все токены, выданные до отзыва, истекли сами.
+
+func (s *AccessRevocationRedisStore) RevokeToken(ctx context.Context, jti string, exp time.Time) error {
+	ttl := time.Until(exp)
+	if ttl <= 0 {
+		return nil
+	}
+	return s.rdb.Set(ctx, utils.AccessDenyKey(jti), 1, ttl).Err()
+}
+
+func (s *AccessRevocationRedisStore) RevokeSession(ctx context.Context, sessionID string) error {
+	return s.rdb.Set(ctx, utils.AccessSessionRevokedKey(sessionID), 1, utils.AccessTTL()).Err()
+}
+
+// RevokeUser: iat выдаётся с точностью до мс, поэтому отзываем и токен той же миллисекунды —
+// токены, выданные вслед за сменой пароля, уже позже метки
+func (s *AccessRevocationRedisStore) RevokeUser(ctx context.Context, userID uint) error {
+	return s.rdb.Set(ctx, utils.AccessUserWatermarkKey(userID), time.Now().UnixMilli(), utils.AccessTTL()).Err()
+}
+
+// secondsWatermarkBound — меньше этого метка в секундах (в мс это 2001 год)
+const secondsWatermarkBound = 1_000_000_000_000
+
+func (s *AccessRevocationRedisStore) IsRevoked(ctx context.Context, t AccessToken) (bool, error) {
+	keys := []string{utils.AccessDenyKey(t.ID), utils.AccessUserWatermarkKey(t.UserID)}
+	if t.SessionID != "" {
+		keys = append(keys, utils.AccessSessionRevokedKey(t.SessionID))
+	}
+
+	vals, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+
+	if vals[0] != nil {
+		return true, nil
+	}
+	if wm, ok := vals[1].(string); ok {
+		ms, err := strconv.ParseInt(wm, 10, 64)
+		if err == nil && ms < secondsWatermarkBound {
+			ms *= 1000 // метка, поставленная до перехода на мс
+		}
+		if err == nil && t.IssuedAt.UnixMilli() <= ms {
+			return true, nil
+		}
+	}
+	if len(vals) > 2 && vals[2] != nil {
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,64 @@
+package stores
+
+import (
+	"context"
+	"go_blog/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LoginAttemptStore — неудачные входы по аккаунту, а не по IP: распределённый перебор
+// одного пароля упирается в тот же счётчик
+type LoginAttemptStore interface {
+	// Blocked — сколько ещё ждать до следующей попытки; 0 — можно
+	Blocked(ctx context.Context, account string) (time.Duration, error)
+	// Fail учитывает неудачу и возвращает число неудач в окне
+	Fail(ctx context.Context, account string, window time.Duration) (int64, error)
+	Block(ctx context.Context, account string, d time.Duration) error
+	// Reset — успешный вход или разблокировка админом
+	Reset(ctx context.Context, account string) error
+}
+
+type LoginAttemptRedisStore struct {
+	rdb *redis.Client
+}
+
+func NewLoginAttemptRedisStore(rdb *redis.Client) *LoginAttemptRedisStore {
+	return &LoginAttemptRedisStore{rdb: rdb}
+}
+
+// login:fail:<account>   -> число неудач, TTL — окно с первой неудачи
+// login:block:<account>  -> 1, TTL — задержка или блокировка
+
+func (s *LoginAttemptRedisStore) Blocked(ctx context.Context, account string) (time.Duration, error) {
+	ttl, err := s.rdb.PTTL(ctx, utils.LoginBlockKey(account)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// -2: ключа нет, -1: без TTL (такого не пишем)
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *LoginAttemptRedisStore) Fail(ctx context.Context, account string, window time.Duration) (int64, error) {
+	key := utils.LoginFailKey(account)
+
+	pipe := s.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (s *LoginAttemptRedisStore) Block(ctx context.Context, account string, d time.Duration) error {
+	return s.rdb.Set(ctx, utils.LoginBlockKey(account), 1, d).Err()
+}
+
+func (s *LoginAttemptRedisStore) Reset(ctx context.Context, account string) error {
+	return s.rdb.Del(ctx, utils.LoginFailKey(account), utils.LoginBlockKey(account)).Err()
+}
@@ -0,0 +1,76 @@
+package stores
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go_blog/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrInvalidOIDCState     = errors.New("invalid oidc state")
+	ErrInvalidOIDCLoginCode = errors.New("invalid oidc login code")
+)
+
+// OIDCState — то, что нужно callback'у: к какому провайдеру ушли, nonce и PKCE verifier
+type OIDCState struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+type OIDCStateStore interface {
+	Save(ctx context.Context, state string, v OIDCState, ttl time.Duration) error
+	// Consume одноразовый: повторный callback с тем же state получит ErrInvalidOIDCState
+	Consume(ctx context.Context, state string) (OIDCState, error)
+	// SaveLogin/ConsumeLogin — результат callback'а (JSON) по hash одноразового кода,
+	// который SPA обменивает POST'ом: токены не попадают в URL и историю браузера
+	SaveLogin(ctx context.Context, hash, result string, ttl time.Duration) error
+	ConsumeLogin(ctx context.Context, hash string) (string, error)
+}
+
+type OIDCStateRedisStore struct {
+	rdb *redis.Client
+}
+
+func NewOIDCStateRedisStore(rdb *redis.Client) *OIDCStateRedisStore {
+	return &OIDCStateRedisStore{rdb: rdb}
+}
+
+func (s *OIDCStateRedisStore) Save(ctx context.Context, state string, v OIDCState, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, utils.OIDCStateKey(state), data, ttl).Err()
+}
+
+func (s *OIDCStateRedisStore) Consume(ctx context.Context, state string) (OIDCState, error) {
+	data, err := s.rdb.GetDel(ctx, utils.OIDCStateKey(state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return OIDCState{}, ErrInvalidOIDCState
+	}
+	if err != nil {
+		return OIDCState{}, err
+	}
+	var v OIDCState
+	if err := json.Unmarshal(data, &v); err != nil {
+		return OIDCState{}, ErrInvalidOIDCState
+	}
+	return v, nil
+}
+
+func (s *OIDCStateRedisStore) SaveLogin(ctx context.Context, hash, result string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, utils.OIDCLoginKey(hash), result, ttl).Err()
+}
+
+func (s *OIDCStateRedisStore) ConsumeLogin(ctx context.Context, hash string) (string, error) {
+	result, err := s.rdb.GetDel(ctx, utils.OIDCLoginKey(hash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrInvalidOIDCLoginCode
+	}
+	return result, err
+}
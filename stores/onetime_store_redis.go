@@ -0,0 +1,62 @@
+package stores
+
+import (
+	"context"
+	"go_blog/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// OneTimeStore — отметка «уже использовано» для одноразовых ссылок
+type OneTimeStore interface {
+	// Use возвращает false, если id уже был использован
+	Use(ctx context.Context, purpose, id string, ttl time.Duration) (bool, error)
+	// Release снимает отметку: попытка не удалась, id можно предъявить снова
+	Release(ctx context.Context, purpose, id string) error
+}
+
+// CounterStore — счётчик в фиксированном окне (лимиты на отправку писем и т.п.)
+type CounterStore interface {
+	Hit(ctx context.Context, name string, window time.Duration) (int64, error)
+}
+
+type OneTimeRedisStore struct {
+	rdb *redis.Client
+}
+
+func NewOneTimeRedisStore(rdb *redis.Client) *OneTimeRedisStore {
+	return &OneTimeRedisStore{rdb: rdb}
+}
+
+// Use: ttl — до истечения самого токена, дольше помнить незачем
+func (s *OneTimeRedisStore) Use(ctx context.Context, purpose, id string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return s.rdb.SetNX(ctx, utils.OneTimeKey(purpose, id), 1, ttl).Result()
+}
+
+func (s *OneTimeRedisStore) Release(ctx context.Context, purpose, id string) error {
+	return s.rdb.Del(ctx, utils.OneTimeKey(purpose, id)).Err()
+}
+
+type CounterRedisStore struct {
+	rdb *redis.Client
+}
+
+func NewCounterRedisStore(rdb *redis.Client) *CounterRedisStore {
+	return &CounterRedisStore{rdb: rdb}
+}
+
+func (s *CounterRedisStore) Hit(ctx context.Context, name string, window time.Duration) (int64, error) {
+	key := utils.CounterKey(name)
+
+	pipe := s.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
@@ -0,0 +1,60 @@
+package stores
+
+import (
+	"context"
+	"errors"
+	"go_blog/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrInvalidResetToken = errors.New("invalid reset token")
+
+// PasswordResetStore: храним только хэш токена; у пользователя действует одна последняя ссылка
+type PasswordResetStore interface {
+	Save(ctx context.Context, userID uint, hash string, ttl time.Duration) error
+	// Consume — одноразово: второй вызов с тем же хэшем вернёт ErrInvalidResetToken
+	Consume(ctx context.Context, hash string) (uint, error)
+}
+
+type PasswordResetRedisStore struct {
+	rdb *redis.Client
+}
+
+func NewPasswordResetRedisStore(rdb *redis.Client) *PasswordResetRedisStore {
+	return &PasswordResetRedisStore{rdb: rdb}
+}
+
+// pwreset:token:<hash> -> userID
+// pwreset:user:<id>    -> hash последней выданной ссылки
+
+func (s *PasswordResetRedisStore) Save(ctx context.Context, userID uint, hash string, ttl time.Duration) error {
+	uk := utils.PasswordResetUserKey(userID)
+
+	old, err := s.rdb.Get(ctx, uk).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	pipe := s.rdb.TxPipeline()
+	if old != "" {
+		pipe.Del(ctx, utils.PasswordResetTokenKey(old))
+	}
+	pipe.Set(ctx, utils.PasswordResetTokenKey(hash), userID, ttl)
+	pipe.Set(ctx, uk, hash, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *PasswordResetRedisStore) Consume(ctx context.Context, hash string) (uint, error) {
+	u64, err := s.rdb.GetDel(ctx, utils.PasswordResetTokenKey(hash)).Uint64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, ErrInvalidResetToken
+		}
+		return 0, err
+	}
+	_ = s.rdb.Del(ctx, utils.PasswordResetUserKey(uint(u64))).Err()
+	return uint(u64), nil
+}
@@ -3,19 +3,47 @@ package stores
 import (
 	"context"
 	"errors"
+	"go_blog/internal/clientinfo"
 	"go_blog/utils"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
-var ErrInvalidRefresh = errors.New("invalid refresh token")
+var (
+	ErrInvalidRefresh  = errors.New("invalid refresh token")
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrRefreshReused — предъявлен уже ротированный токен; вся семья (сессия) отозвана
+	ErrRefreshReused = errors.New("refresh token reused")
+)
+
+// Session — одно устройство пользователя; id не меняется при ротации refresh-токена
+type Session struct {
+	ID         string
+	UserID     uint
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
 
 type RefreshStore interface {
-	Save(ctx context.Context, userID uint, hash string, ttl time.Duration) error
-	GetUserIDByHash(ctx context.Context, hash string) (uint, error)
-	Rotate(ctx context.Context, oldHash string, userID uint, newHash string, ttl time.Duration) error
-	Delete(ctx context.Context, hash string, userID uint) error
+	// Save открывает новую сессию и возвращает её id
+	Save(ctx context.Context, userID uint, hash string, ttl time.Duration, device clientinfo.Info) (string, error)
+	Lookup(ctx context.Context, hash string) (userID uint, sessionID string, err error)
+	// Rotate атомарно меняет refresh-токен сессии: из параллельных вызовов со старым токеном успешен ровно один.
+	// Повторное предъявление ротированного токена отзывает сессию и возвращает ErrRefreshReused вместе с userID/sessionID.
+	Rotate(ctx context.Context, oldHash, newHash string, ttl time.Duration, device clientinfo.Info) (userID uint, sessionID string, err error)
+	Delete(ctx context.Context, hash string) error
+	List(ctx context.Context, userID uint) ([]Session, error)
+	RevokeSession(ctx context.Context, userID uint, sessionID string) error
+	// RevokeAll — выход со всех устройств
+	RevokeAll(ctx context.Context, userID uint) error
 }
 
 type RefreshRedisStore struct {
@@ -26,53 +54,309 @@ func NewRefreshRedisStore(rdb *redis.Client) *RefreshRedisStore {
 	return &RefreshRedisStore{rdb: rdb}
 }
 
-// refresh:token:<hash> -> userID
-// refresh:user:<id>    -> hash
+// refresh:token:<hash>             -> "<userID>:<sessionID>", указатель на семью; авторитетен только hash сессии
+// refresh:{<id>}:session:<sid>     -> hash {user_id, hash, parent, rotations, user_agent, ip, created_at, last_used_at, expires_at}
+// refresh:{<id>}:sessions          -> set id сессий пользователя
+// refresh:{<id>}:used:<hash>       -> метка уже ротированного токена
+//
+// Сессия — семья токенов: все токены цепочки ротаций указывают на один sid.
+// Все ключи сессии живут ровно столько, сколько её текущий refresh-токен.
+// Указатель читается отдельной командой, дальше скрипты работают только с ключами слота {<id>}:
+// так хранилище работает и в Redis Cluster. Токен действителен, пока его hash совпадает с полем hash сессии.
+
+func tokenValue(userID uint, sessionID string) string {
+	return strconv.FormatUint(uint64(userID), 10) + ":" + sessionID
+}
+
+// parseTokenValue: значения без "<userID>:<sessionID>" остались от старого формата — такой токен недействителен
+func parseTokenValue(v string) (uint, string, error) {
+	uidStr, sid, ok := strings.Cut(v, ":")
+	if !ok || sid == "" {
+		return 0, "", ErrInvalidRefresh
+	}
+	uid, err := strconv.ParseUint(uidStr, 10, 64)
+	if err != nil || uid == 0 {
+		return 0, "", ErrInvalidRefresh
+	}
+	return uint(uid), sid, nil
+}
 
-func (s *RefreshRedisStore) Save(ctx context.Context, userID uint, hash string, ttl time.Duration) error {
+func (s *RefreshRedisStore) Save(ctx context.Context, userID uint, hash string, ttl time.Duration, device clientinfo.Info) (string, error) {
+	sid := uuid.NewString()
+	now := time.Now()
+	sk := utils.RefreshSessionKey(userID, sid)
 	uk := utils.RefreshUserKey(userID)
 
-	oldHash, err := s.rdb.Get(ctx, uk).Result()
-	if err == nil && oldHash != "" {
-		_ = s.rdb.Del(ctx)
+	pipe := s.rdb.TxPipeline()
+	pipe.HSet(ctx, sk,
+		"user_id", userID,
+		"hash", hash,
+		"rotations", 0,
+		"user_agent", device.UserAgent,
+		"ip", device.IP,
+		"created_at", now.Unix(),
+		"last_used_at", now.Unix(),
+		"expires_at", now.Add(ttl).Unix(),
+	)
+	pipe.Expire(ctx, sk, ttl)
+	pipe.SAdd(ctx, uk, sid)
+	pipe.Expire(ctx, uk, ttl) // set живёт не меньше самой свежей сессии
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	if err := s.rdb.Set(ctx, utils.RefreshTokenKey(hash), tokenValue(userID, sid), ttl).Err(); err != nil {
+		return "", err
 	}
+	return sid, nil
+}
 
-	pipe := s.rdb.Pipeline()
-	pipe.Set(ctx, utils.RefreshTokenKey(hash), userID, ttl)
-	pipe.Set(ctx, uk, hash, ttl)
-	_, err = pipe.Exec(ctx)
-	return err
+// resolve — владелец и сессия по указателю; указатель старого формата удаляется
+func (s *RefreshRedisStore) resolve(ctx context.Context, hash string) (uint, string, error) {
+	v, err := s.rdb.Get(ctx, utils.RefreshTokenKey(hash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, "", ErrInvalidRefresh
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	uid, sid, err := parseTokenValue(v)
+	if err != nil {
+		_ = s.rdb.Del(ctx, utils.RefreshTokenKey(hash)).Err()
+		return 0, "", err
+	}
+	return uid, sid, nil
 }
 
-func (s *RefreshRedisStore) GetUserIDByHash(ctx context.Context, hash string) (uint, error) {
-	u64, err := s.rdb.Get(ctx, utils.RefreshTokenKey(hash)).Uint64()
+func (s *RefreshRedisStore) Lookup(ctx context.Context, hash string) (uint, string, error) {
+	uid, sid, err := s.resolve(ctx, hash)
+	if err != nil {
+		return 0, "", err
+	}
+	cur, err := s.rdb.HGet(ctx, utils.RefreshSessionKey(uid, sid), "hash").Result()
+	if errors.Is(err, redis.Nil) || (err == nil && cur != hash) {
+		return 0, "", ErrInvalidRefresh
+	}
 	if err != nil {
-		return 0, ErrInvalidRefresh
+		return 0, "", err
 	}
-	return uint(u64), nil
+	return uid, sid, nil
 }
 
-func (s *RefreshRedisStore) Rotate(ctx context.Context, oldHash string, userID uint, newHash string, ttl time.Duration) error {
-	pipe := s.rdb.Pipeline()
-	pipe.Del(ctx, utils.RefreshTokenKey(oldHash))              // убрать старый tokenKey
-	pipe.Set(ctx, utils.RefreshTokenKey(newHash), userID, ttl) // новый tokenKey
-	pipe.Set(ctx, utils.RefreshUserKey(userID), newHash, ttl)  // обновить userKey
-	_, err := pipe.Exec(ctx)
-	return err
+// KEYS: session key, user key, used key старого токена
+// ARGV: old hash, new hash, ttl (sec), now (unix), user agent, ip, session id
+//
+// Ответ: 1 — ротация; 2 — токен уже ротирован, семья отозвана; 0 — токен недействителен.
+var rotateScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[3]) == 1 then
+	redis.call('DEL', KEYS[1])
+	redis.call('SREM', KEYS[2], ARGV[7])
+	return 2
+end
+if redis.call('HGET', KEYS[1], 'hash') ~= ARGV[1] then return 0 end
+
+local ttl = tonumber(ARGV[3])
+redis.call('SET', KEYS[3], '1', 'EX', ttl)
+redis.call('HSET', KEYS[1], 'hash', ARGV[2], 'parent', ARGV[1], 'last_used_at', ARGV[4], 'expires_at', tonumber(ARGV[4]) + ttl)
+redis.call('HINCRBY', KEYS[1], 'rotations', 1)
+if ARGV[5] ~= '' then redis.call('HSET', KEYS[1], 'user_agent', ARGV[5]) end
+if ARGV[6] ~= '' then redis.call('HSET', KEYS[1], 'ip', ARGV[6]) end
+redis.call('EXPIRE', KEYS[1], ttl)
+redis.call('EXPIRE', KEYS[2], ttl)
+return 1
+`)
+
+func (s *RefreshRedisStore) Rotate(ctx context.Context, oldHash, newHash string, ttl time.Duration, device clientinfo.Info) (uint, string, error) {
+	uid, sid, err := s.resolve(ctx, oldHash)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// указатель нового токена пишется до ротации: проигравшим он ничего не даёт — hash сессии другой
+	newKey := utils.RefreshTokenKey(newHash)
+	if err := s.rdb.Set(ctx, newKey, tokenValue(uid, sid), ttl).Err(); err != nil {
+		return 0, "", err
+	}
+
+	res, err := rotateScript.Run(ctx, s.rdb,
+		[]string{utils.RefreshSessionKey(uid, sid), utils.RefreshUserKey(uid), utils.RefreshUsedKey(uid, oldHash)},
+		oldHash, newHash, int64(ttl/time.Second), time.Now().Unix(), device.UserAgent, device.IP, sid,
+	).Int()
+	if err != nil {
+		return 0, "", err
+	}
+
+	if res == 1 {
+		// указатель старого токена нужен, пока жива метка: иначе повторное предъявление не узнать
+		_ = s.rdb.Expire(ctx, utils.RefreshTokenKey(oldHash), ttl).Err()
+		return uid, sid, nil
+	}
+	_ = s.rdb.Del(ctx, newKey).Err()
+	if res == 2 {
+		return uid, sid, ErrRefreshReused
+	}
+	return 0, "", ErrInvalidRefresh
+}
+
+// KEYS: session key, user key
+// ARGV: hash, session id
+var deleteScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'hash') ~= ARGV[1] then return 0 end
+redis.call('DEL', KEYS[1])
+redis.call('SREM', KEYS[2], ARGV[2])
+return 1
+`)
+
+func (s *RefreshRedisStore) Delete(ctx context.Context, hash string) error {
+	uid, sid, err := s.resolve(ctx, hash)
+	if errors.Is(err, ErrInvalidRefresh) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	n, err := deleteScript.Run(ctx, s.rdb,
+		[]string{utils.RefreshSessionKey(uid, sid), utils.RefreshUserKey(uid)},
+		hash, sid,
+	).Int()
+	if err != nil || n == 0 {
+		// ротированный токен не трогаем: его указатель ещё ловит повторное предъявление
+		return err
+	}
+	return s.rdb.Del(ctx, utils.RefreshTokenKey(hash)).Err()
 }
 
-func (s *RefreshRedisStore) Delete(ctx context.Context, hash string, userID uint) error {
-	tk := utils.RefreshTokenKey(hash)
+func (s *RefreshRedisStore) List(ctx context.Context, userID uint) ([]Session, error) {
 	uk := utils.RefreshUserKey(userID)
 
+	sids, err := s.rdb.SMembers(ctx, uk).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(sids) == 0 {
+		return nil, nil
+	}
+
 	pipe := s.rdb.Pipeline()
-	pipe.Del(ctx, tk)
+	cmds := make([]*redis.MapStringStringCmd, len(sids))
+	for i, sid := range sids {
+		cmds[i] = pipe.HGetAll(ctx, utils.RefreshSessionKey(userID, sid))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
 
-	cur, err := s.rdb.Get(ctx, uk).Result()
-	if err == nil && cur == hash {
-		pipe.Del(ctx, uk)
+	out := make([]Session, 0, len(sids))
+	var expired []any
+	for i, cmd := range cmds {
+		h := cmd.Val()
+		if len(h) == 0 {
+			// сессия истекла раньше set'а
+			expired = append(expired, sids[i])
+			continue
+		}
+		out = append(out, Session{
+			ID:         sids[i],
+			UserID:     userID,
+			UserAgent:  h["user_agent"],
+			IP:         h["ip"],
+			CreatedAt:  unixField(h["created_at"]),
+			LastUsedAt: unixField(h["last_used_at"]),
+			ExpiresAt:  unixField(h["expires_at"]),
+		})
+	}
+	if len(expired) > 0 {
+		_ = s.rdb.SRem(ctx, uk, expired...).Err()
 	}
 
-	_, err = pipe.Exec(ctx)
-	return err
+	sort.Slice(out, func(i, j int) bool { return out[i].LastUsedAt.After(out[j].LastUsedAt) })
+	return out, nil
+}
+
+func unixField(v string) time.Time {
+	sec, _ := strconv.ParseInt(v, 10, 64)
+	return time.Unix(sec, 0).UTC()
+}
+
+// KEYS: session key, user key
+// ARGV: session id
+//
+// Ответ: hash текущего токена закрытой сессии; nil — сессии нет.
+var revokeSessionScript = redis.NewScript(`
+local hash = redis.call('HGET', KEYS[1], 'hash')
+redis.call('SREM', KEYS[2], ARGV[1])
+if not hash then return false end
+redis.call('DEL', KEYS[1])
+return hash
+`)
+
+func (s *RefreshRedisStore) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	hash, err := revokeSessionScript.Run(ctx, s.rdb,
+		[]string{utils.RefreshSessionKey(userID, sessionID), utils.RefreshUserKey(userID)},
+		sessionID,
+	).Text()
+	if errors.Is(err, redis.Nil) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.rdb.Del(ctx, utils.RefreshTokenKey(hash)).Err()
+}
+
+// KEYS: user key, ключи всех сессий из set'а
+// ARGV: id сессий в том же порядке
+//
+// Ответ: hash'и текущих токенов закрытых сессий; nil — set изменился после чтения, нужен повтор.
+var revokeAllScript = redis.NewScript(`
+if redis.call('SCARD', KEYS[1]) ~= #ARGV then return false end
+for _, sid in ipairs(ARGV) do
+	if redis.call('SISMEMBER', KEYS[1], sid) == 0 then return false end
+end
+local hashes = {}
+for i = 2, #KEYS do
+	local hash = redis.call('HGET', KEYS[i], 'hash')
+	if hash then table.insert(hashes, hash) end
+	redis.call('DEL', KEYS[i])
+end
+redis.call('DEL', KEYS[1])
+return hashes
+`)
+
+// revokeAllAttempts — сколько раз перечитывать set, если параллельно открываются сессии
+const revokeAllAttempts = 5
+
+func (s *RefreshRedisStore) RevokeAll(ctx context.Context, userID uint) error {
+	uk := utils.RefreshUserKey(userID)
+	for range revokeAllAttempts {
+		sids, err := s.rdb.SMembers(ctx, uk).Result()
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(sids)+1)
+		keys = append(keys, uk)
+		args := make([]any, 0, len(sids))
+		for _, sid := range sids {
+			keys = append(keys, utils.RefreshSessionKey(userID, sid))
+			args = append(args, sid)
+		}
+
+		hashes, err := revokeAllScript.Run(ctx, s.rdb, keys, args...).StringSlice()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+		// указатели лежат в разных слотах — отдельными командами
+		pipe := s.rdb.Pipeline()
+		for _, h := range hashes {
+			pipe.Del(ctx, utils.RefreshTokenKey(h))
+		}
+		_, err = pipe.Exec(ctx)
+		return err
+	}
+	return errors.New("refresh: sessions kept changing during revoke all")
 }
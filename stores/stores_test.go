@@ -0,0 +1,348 @@
+package stores
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_blog/internal/clientinfo"
+	"go_blog/testhelpers"
+	"go_blog/utils"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshRedisStore_RevokeAll(t *testing.T) {
+	rdb := testhelpers.SetupTestRedis(t)
+	s := NewRefreshRedisStore(rdb)
+	ctx := context.Background()
+
+	_, err := s.Save(ctx, 1, "a", time.Hour, clientinfo.Info{})
+	require.NoError(t, err)
+	_, err = s.Save(ctx, 1, "b", time.Hour, clientinfo.Info{})
+	require.NoError(t, err)
+	_, err = s.Save(ctx, 2, "c", time.Hour, clientinfo.Info{})
+	require.NoError(t, err)
+	_, _, err = s.Rotate(ctx, "b", "b2", time.Hour, clientinfo.Info{})
+	require.NoError(t, err)
+
+	require.NoError(t, s.RevokeAll(ctx, 1))
+
+	for _, h := range []string{"a", "b", "b2"} {
+		_, _, err := s.Lookup(ctx, h)
+		require.ErrorIs(t, err, ErrInvalidRefresh)
+	}
+	list, err := s.List(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	uid, _, err := s.Lookup(ctx, "c")
+	require.NoError(t, err)
+	require.Equal(t, uint(2), uid)
+}
+
+func TestRefreshRedisStore_MultipleDevices(t *testing.T) {
+	rdb := testhelpers.SetupTestRedis(t)
+	s := NewRefreshRedisStore(rdb)
+	ctx := context.Background()
+
+	laptop, err := s.Save(ctx, 1, "laptop", time.Hour, clientinfo.Info{UserAgent: "Firefox", IP: "10.0.0.1"})
+	require.NoError(t, err)
+	phone, err := s.Save(ctx, 1, "phone", time.Hour, clientinfo.Info{UserAgent: "iPhone", IP: "10.0.0.2"})
+	require.NoError(t, err)
+	require.NotEqual(t, laptop, phone)
+
+	// ротация сохраняет id сессии и обновляет IP
+	uid, sid, err := s.Rotate(ctx, "laptop", "laptop2", time.Hour, clientinfo.Info{IP: "10.0.0.3"})
+	require.NoError(t, err)
+	require.Equal(t, uint(1), uid)
+	require.Equal(t, laptop, sid)
+
+	list, err := s.List(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	byID := map[string]Session{}
+	for _, sess := range list {
+		byID[sess.ID] = sess
+	}
+	require.Equal(t, "Firefox", byID[laptop].UserAgent)
+	require.Equal(t, "10.0.0.3", byID[laptop].IP)
+	require.Equal(t, "iPhone", byID[phone].UserAgent)
+
+	// чужую сессию закрыть нельзя
+	require.ErrorIs(t, s.RevokeSession(ctx, 2, phone), ErrSessionNotFound)
+	require.NoError(t, s.RevokeSession(ctx, 1, phone))
+	require.ErrorIs(t, s.RevokeSession(ctx, 1, phone), ErrSessionNotFound)
+
+	_, _, err = s.Lookup(ctx, "phone")
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+	_, _, err = s.Lookup(ctx, "laptop2")
+	require.NoError(t, err)
+
+	require.NoError(t, s.Delete(ctx, "laptop2"))
+	list, err = s.List(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
+
+func TestRefreshRedisStore_ConcurrentRotateHasSingleWinner(t *testing.T) {
+	rdb := testhelpers.SetupTestRedis(t)
+	s := NewRefreshRedisStore(rdb)
+	ctx := context.Background()
+
+	sid, err := s.Save(ctx, 1, "old", time.Hour, clientinfo.Info{})
+	require.NoError(t, err)
+
+	const n = 20
+	var (
+		wg              sync.WaitGroup
+		mu              sync.Mutex
+		winners, reused int
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, got, err := s.Rotate(ctx, "old", fmt.Sprintf("new-%d", i), time.Hour, clientinfo.Info{})
+			require.Equal(t, sid, got)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				winners++
+			case errors.Is(err, ErrRefreshReused):
+				reused++
+			default:
+				t.Errorf("rotate: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// ровно одна ротация, остальные — повторное использование, которое закрывает сессию
+	require.Equal(t, 1, winners)
+	require.Equal(t, n-1, reused)
+
+	for i := 0; i < n; i++ {
+		_, _, err := s.Lookup(ctx, fmt.Sprintf("new-%d", i))
+		require.ErrorIs(t, err, ErrInvalidRefresh)
+	}
+	list, err := s.List(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
+
+func TestRefreshRedisStore_ReuseRevokesOnlyItsFamily(t *testing.T) {
+	rdb := testhelpers.SetupTestRedis(t)
+	s := NewRefreshRedisStore(rdb)
+	ctx := context.Background()
+
+	family, err := s.Save(ctx, 1, "a1", time.Hour, clientinfo.Info{})
+	require.NoError(t, err)
+	other, err := s.Save(ctx, 1, "b1", time.Hour, clientinfo.Info{})
+	require.NoError(t, err)
+
+	_, _, err = s.Rotate(ctx, "a1", "a2", time.Hour, clientinfo.Info{})
+	require.NoError(t, err)
+	_, _, err = s.Rotate(ctx, "a2", "a3", time.Hour, clientinfo.Info{})
+	require.NoError(t, err)
+
+	// любой предок цепочки выдаёт кражу
+	uid, sid, err := s.Rotate(ctx, "a1", "x", time.Hour, clientinfo.Info{})
+	require.ErrorIs(t, err, ErrRefreshReused)
+	require.Equal(t, uint(1), uid)
+	require.Equal(t, family, sid)
+
+	_, _, err = s.Lookup(ctx, "a3")
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+	_, _, err = s.Lookup(ctx, "x")
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+
+	list, err := s.List(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, other, list[0].ID)
+
+	// неизвестный токен — не повторное использование
+	_, _, err = s.Rotate(ctx, "never-issued", "y", time.Hour, clientinfo.Info{})
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+}
+
+// значения указателей старого формата (без id сессии) — недействительный токен, не ошибка сервера
+func TestRefreshRedisStore_LegacyTokenValueIsInvalid(t *testing.T) {
+	rdb := testhelpers.SetupTestRedis(t)
+	s := NewRefreshRedisStore(rdb)
+	ctx := context.Background()
+
+	require.NoError(t, rdb.Set(ctx, utils.RefreshTokenKey("legacy"), "7", time.Hour).Err())
+	_, _, err := s.Rotate(ctx, "legacy", "new", time.Hour, clientinfo.Info{})
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+	_, _, err = s.Lookup(ctx, "new")
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+
+	require.NoError(t, rdb.Set(ctx, utils.RefreshTokenKey("legacy"), "7", time.Hour).Err())
+	_, _, err = s.Lookup(ctx, "legacy")
+	require.ErrorIs(t, err, ErrInvalidRefresh)
+	require.NoError(t, s.Delete(ctx, "legacy"))
+	require.Zero(t, rdb.Exists(ctx, utils.RefreshTokenKey("legacy")).Val())
+}
+
+// все ключи сессий пользователя — в одном слоте Redis Cluster
+func TestRefreshKeys_ShareUserHashTag(t *testing.T) {
+	for _, k := range []string{utils.RefreshUserKey(7), utils.RefreshSessionKey(7, "sid"), utils.RefreshUsedKey(7, "hash")} {
+		require.Contains(t, k, "{7}")
+	}
+}
+
+func TestPasswordResetRedisStore_SingleUseLatestOnly(t *testing.T) {
+	rdb := testhelpers.SetupTestRedis(t)
+	s := NewPasswordResetRedisStore(rdb)
+	ctx := context.Background()
+
+	require.NoError(t, s.Save(ctx, 1, "old", time.Minute))
+	require.NoError(t, s.Save(ctx, 1, "new", time.Minute))
+
+	_, err := s.Consume(ctx, "old")
+	require.ErrorIs(t, err, ErrInvalidResetToken)
+
+	uid, err := s.Consume(ctx, "new")
+	require.NoError(t, err)
+	require.Equal(t, uint(1), uid)
+
+	_, err = s.Consume(ctx, "new")
+	require.ErrorIs(t, err, ErrInvalidResetToken)
+}
+
+func TestAccessRevocationRedisStore(t *testing.T) {
+	rdb := testhelpers.SetupTestRedis(t)
+	s := NewAccessRevocationRedisStore(rdb)
+	ctx := context.Background()
+
+	old := time.Now().Add(-time.Minute)
+	tok := AccessToken{ID: "jti-1", UserID: 1, SessionID: "s1", IssuedAt: old}
+
+	revoked, err := s.IsRevoked(ctx, tok)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// denylist по jti
+	require.NoError(t, s.RevokeToken(ctx, "jti-1", time.Now().Add(time.Minute)))
+	revoked, err = s.IsRevoked(ctx, tok)
+	require.NoError(t, err)
+	require.True(t, revoked)
+	ttl, err := rdb.TTL(ctx, "access:deny:jti-1").Result()
+	require.NoError(t, err)
+	require.LessOrEqual(t, ttl, time.Minute)
+
+	// закрытая сессия
+	other := AccessToken{ID: "jti-2", UserID: 1, SessionID: "s2", IssuedAt: old}
+	require.NoError(t, s.RevokeSession(ctx, "s2"))
+	revoked, err = s.IsRevoked(ctx, other)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// метка пользователя: старые токены отозваны, выданные после — нет
+	before := AccessToken{ID: "jti-3", UserID: 2, IssuedAt: old}
+	require.NoError(t, s.RevokeUser(ctx, 2))
+	revoked, err = s.IsRevoked(ctx, before)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	after := AccessToken{ID: "jti-4", UserID: 2, IssuedAt: time.Now().Add(time.Second)}
+	revoked, err = s.IsRevoked(ctx, after)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// токен, выданный в ту же секунду до отзыва, тоже отозван
+	sameSecond := AccessToken{ID: "jti-5", UserID: 3, IssuedAt: time.Now()}
+	require.NoError(t, s.RevokeUser(ctx, 3))
+	revoked, err = s.IsRevoked(ctx, sameSecond)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// метка в секундах, поставленная до перехода на мс
+	require.NoError(t, rdb.Set(ctx, "access:revoked:user:4", time.Now().Unix(), time.Minute).Err())
+	revoked, err = s.IsRevoked(ctx, AccessToken{ID: "jti-6", UserID: 4, IssuedAt: old})
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestLoginAttemptRedisStore(t *testing.T) {
+	rdb := testhelpers.SetupTestRedis(t)
+	s := NewLoginAttemptRedisStore(rdb)
+	ctx := context.Background()
+
+	wait, err := s.Blocked(ctx, "a@test.com")
+	require.NoError(t, err)
+	require.Zero(t, wait)
+
+	for i := int64(1); i <= 3; i++ {
+		n, err := s.Fail(ctx, "a@test.com", time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, i, n)
+	}
+
+	require.NoError(t, s.Block(ctx, "a@test.com", time.Minute))
+	wait, err = s.Blocked(ctx, "a@test.com")
+	require.NoError(t, err)
+	require.Greater(t, wait, time.Duration(0))
+	require.LessOrEqual(t, wait, time.Minute)
+
+	// другие аккаунты не задеты
+	wait, err = s.Blocked(ctx, "b@test.com")
+	require.NoError(t, err)
+	require.Zero(t, wait)
+
+	require.NoError(t, s.Reset(ctx, "a@test.com"))
+	wait, err = s.Blocked(ctx, "a@test.com")
+	require.NoError(t, err)
+	require.Zero(t, wait)
+	n, err := s.Fail(ctx, "a@test.com", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+}
+
+func TestOIDCStateRedisStore_SingleUse(t *testing.T) {
+	rdb := testhelpers.SetupTestRedis(t)
+	s := NewOIDCStateRedisStore(rdb)
+	ctx := context.Background()
+
+	want := OIDCState{Provider: "google", Nonce: "n", Verifier: "v"}
+	require.NoError(t, s.Save(ctx, "state-1", want, time.Minute))
+
+	got, err := s.Consume(ctx, "state-1")
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	_, err = s.Consume(ctx, "state-1")
+	require.ErrorIs(t, err, ErrInvalidOIDCState)
+	_, err = s.Consume(ctx, "unknown")
+	require.ErrorIs(t, err, ErrInvalidOIDCState)
+
+	require.NoError(t, s.SaveLogin(ctx, "code-hash", `{"access_token":"a"}`, time.Minute))
+	result, err := s.ConsumeLogin(ctx, "code-hash")
+	require.NoError(t, err)
+	require.Equal(t, `{"access_token":"a"}`, result)
+	_, err = s.ConsumeLogin(ctx, "code-hash")
+	require.ErrorIs(t, err, ErrInvalidOIDCLoginCode)
+}
+
+func TestWSTicketRedisStore_SingleUse(t *testing.T) {
+	rdb := testhelpers.SetupTestRedis(t)
+	s := NewWSTicketRedisStore(rdb)
+	ctx := context.Background()
+
+	require.NoError(t, s.Save(ctx, "hash-1", "access-jwt", time.Minute))
+
+	got, err := s.Consume(ctx, "hash-1")
+	require.NoError(t, err)
+	require.Equal(t, "access-jwt", got)
+
+	_, err = s.Consume(ctx, "hash-1")
+	require.ErrorIs(t, err, ErrInvalidWSTicket)
+	_, err = s.Consume(ctx, "unknown")
+	require.ErrorIs(t, err, ErrInvalidWSTicket)
+}
@@ -0,0 +1,40 @@
+package stores
+
+import (
+	"context"
+	"errors"
+	"go_blog/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrInvalidWSTicket = errors.New("invalid websocket ticket")
+
+// WSTicketStore: билет (по hash) -> access-токен, которым он выдан. Сокет открывается
+// по билету, а проверяется по токену — срок жизни и отзыв те же, что у токена.
+type WSTicketStore interface {
+	Save(ctx context.Context, hash, accessToken string, ttl time.Duration) error
+	// Consume одноразовый: повторное подключение с тем же билетом получит ErrInvalidWSTicket
+	Consume(ctx context.Context, hash string) (string, error)
+}
+
+type WSTicketRedisStore struct {
+	rdb *redis.Client
+}
+
+func NewWSTicketRedisStore(rdb *redis.Client) *WSTicketRedisStore {
+	return &WSTicketRedisStore{rdb: rdb}
+}
+
+func (s *WSTicketRedisStore) Save(ctx context.Context, hash, accessToken string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, utils.WSTicketKey(hash), accessToken, ttl).Err()
+}
+
+func (s *WSTicketRedisStore) Consume(ctx context.Context, hash string) (string, error) {
+	token, err := s.rdb.GetDel(ctx, utils.WSTicketKey(hash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrInvalidWSTicket
+	}
+	return token, err
+}
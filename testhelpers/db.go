@@ -33,11 +33,26 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	require.NoError(t, db.Migrator().DropTable(
+		&models.WebhookAttempt{},
+		&models.WebhookDelivery{},
+		&models.WebhookSubscription{},
+		&models.PersonalAccessToken{},
+		&models.MFARecoveryCode{},
+		&models.UserMFA{},
+		&models.Identity{},
 		&models.PostLike{},
 		&models.Comment{},
 		&models.Post{},
 		&models.User{},
-		&models.RefreshToken{},
+		&models.OutboxEvent{},
+		&models.ProcessedEvent{},
+		&models.AuditLog{},
+		&models.Follow{},
+		&models.NotificationActor{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.MailOutbox{},
+		&models.SchemaMigration{},
 	))
 
 	require.NoError(t, db.AutoMigrate(
@@ -45,7 +60,21 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 		&models.Post{},
 		&models.Comment{},
 		&models.PostLike{},
-		&models.RefreshToken{},
+		&models.OutboxEvent{},
+		&models.ProcessedEvent{},
+		&models.AuditLog{},
+		&models.Follow{},
+		&models.Notification{},
+		&models.NotificationActor{},
+		&models.NotificationPreference{},
+		&models.MailOutbox{},
+		&models.UserMFA{},
+		&models.MFARecoveryCode{},
+		&models.Identity{},
+		&models.PersonalAccessToken{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.WebhookAttempt{},
 	))
 
 	return db
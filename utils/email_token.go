@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidEmailToken = errors.New("invalid email token")
+
+const (
+	emailVerifyPurpose = "email_verify"
+	emailChangePurpose = "email_change"
+)
+
+// Ключ выводится из JWT_SECRET отдельно на каждое назначение:
+// ссылка подтверждения не пройдёт как смена адреса, а MFA-challenge — как ссылка из письма
+func purposeKey(purpose string) []byte {
+	m := hmac.New(sha256.New, jwtSecret())
+	m.Write([]byte(purpose))
+	return m.Sum(nil)
+}
+
+func EmailVerifyTTL() time.Duration {
+	if s := os.Getenv("EMAIL_VERIFY_TTL_H"); s != "" {
+		if d, err := time.ParseDuration(s + "h"); err == nil {
+			return d
+		}
+	}
+	return 48 * time.Hour
+}
+
+type EmailTokenClaims struct {
+	UserID   uint
+	Email    string
+	OldEmail string // только для смены адреса
+	ID       string // jti — для одноразовости
+	Exp      time.Time
+}
+
+// GenerateEmailVerifyJWT — подписанная ссылка подтверждения адреса email для пользователя
+func GenerateEmailVerifyJWT(userID uint, email string) (string, EmailTokenClaims, error) {
+	return generateEmailJWT(emailVerifyPurpose, EmailTokenClaims{UserID: userID, Email: email})
+}
+
+func ParseEmailVerifyJWT(tokenStr string) (EmailTokenClaims, error) {
+	return parseEmailJWT(emailVerifyPurpose, tokenStr)
+}
+
+// GenerateEmailChangeJWT — ссылка на новый адрес; действует, пока текущий адрес равен oldEmail
+func GenerateEmailChangeJWT(userID uint, oldEmail, newEmail string) (string, EmailTokenClaims, error) {
+	return generateEmailJWT(emailChangePurpose, EmailTokenClaims{UserID: userID, Email: newEmail, OldEmail: oldEmail})
+}
+
+func ParseEmailChangeJWT(tokenStr string) (EmailTokenClaims, error) {
+	c, err := parseEmailJWT(emailChangePurpose, tokenStr)
+	if err == nil && c.OldEmail == "" {
+		return EmailTokenClaims{}, ErrInvalidEmailToken
+	}
+	return c, err
+}
+
+func generateEmailJWT(purpose string, c EmailTokenClaims) (string, EmailTokenClaims, error) {
+	c.ID = uuid.NewString()
+	c.Exp = time.Now().Add(EmailVerifyTTL())
+
+	claims := jwt.MapClaims{
+		"sub":   c.UserID,
+		"email": c.Email,
+		"jti":   c.ID,
+		"pur":   purpose,
+		"exp":   c.Exp.Unix(),
+	}
+	if c.OldEmail != "" {
+		claims["old"] = c.OldEmail
+	}
+	t, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(purposeKey(purpose))
+	return t, c, err
+}
+
+func parseEmailJWT(purpose, tokenStr string) (EmailTokenClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		return purposeKey(purpose), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		return EmailTokenClaims{}, ErrInvalidEmailToken
+	}
+
+	sub, _ := claims["sub"].(float64)
+	email, _ := claims["email"].(string)
+	old, _ := claims["old"].(string)
+	jti, _ := claims["jti"].(string)
+	pur, _ := claims["pur"].(string)
+	if sub <= 0 || email == "" || jti == "" || pur != purpose {
+		return EmailTokenClaims{}, ErrInvalidEmailToken
+	}
+	exp, _ := claims.GetExpirationTime()
+
+	return EmailTokenClaims{UserID: uint(sub), Email: email, OldEmail: old, ID: jti, Exp: exp.Time}, nil
+}
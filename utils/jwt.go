@@ -1,41 +1,142 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"go_blog/internal/jwtkeys"
+	"log"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// jwtSecret — корень purposeKey: токены в письмах, MFA-challenge, OIDC-привязка и шифрование
+// MFA-секретов; access-токены подписываются ключами из AccessKeyring
 func jwtSecret() []byte {
 	sec := os.Getenv("JWT_SECRET")
 	return []byte(sec)
 }
 
-func accessTTL() time.Duration {
+// minJWTSecretLen — 256 бит, как у ключа HS256
+const minJWTSecretLen = 32
+
+// jwtDevInsecure — JWT_DEV_INSECURE=true: короткий JWT_SECRET и временный ключ без JWT_KEYS_DIR
+// разрешены (только локальная разработка)
+func jwtDevInsecure() bool {
+	v, _ := strconv.ParseBool(os.Getenv("JWT_DEV_INSECURE"))
+	return v
+}
+
+// CheckJWTConfig — на старте API: пустой секрет сделал бы все HMAC-токены подделываемыми,
+// а временный ключ разлогинивает всех на каждом рестарте и расходится между репликами
+func CheckJWTConfig() error {
+	if jwtDevInsecure() {
+		log.Println("jwt: JWT_DEV_INSECURE is set, secret and key checks are skipped")
+		return nil
+	}
+	if len(jwtSecret()) < minJWTSecretLen {
+		return fmt.Errorf("JWT_SECRET must be at least %d bytes", minJWTSecretLen)
+	}
+	if os.Getenv("JWT_KEYS_DIR") == "" {
+		return errors.New("JWT_KEYS_DIR is not set")
+	}
+	return nil
+}
+
+// AccessTTL — время жизни access-токена; столько же живут метки отзыва сессии и пользователя
+func AccessTTL() time.Duration {
 	if s := os.Getenv("JWT_ACCESS_TTL_MIN"); s != "" {
-		if d, err := time.ParseDuration(s + "m"); err != nil {
+		if d, err := time.ParseDuration(s + "m"); err == nil && d > 0 {
 			return d
 		}
 	}
 	return 60 * time.Minute
 }
 
+func JWTIssuer() string {
+	if v := os.Getenv("JWT_ISSUER"); v != "" {
+		return v
+	}
+	return "go_blog"
+}
+
+func JWTAudience() string {
+	if v := os.Getenv("JWT_AUDIENCE"); v != "" {
+		return v
+	}
+	return "go_blog"
+}
+
+func init() {
+	// iat access-токенов с точностью до мс: отзыв пользователя не должен щадить токены его секунды
+	jwt.TimePrecision = time.Millisecond
+}
+
+var (
+	keyringOnce sync.Once
+	keyring     *jwtkeys.Keyring
+	keyringErr  error
+)
+
+// AccessKeyring — ключи access-токенов из JWT_KEYS_DIR (<kid>.pem), подпись — JWT_SIGNING_KID.
+// Без JWT_KEYS_DIR — временный ключ процесса: API без JWT_DEV_INSECURE до этого не дойдёт
+// (CheckJWTConfig), остаются разработка и тесты.
+func AccessKeyring() (*jwtkeys.Keyring, error) {
+	keyringOnce.Do(func() {
+		dir := os.Getenv("JWT_KEYS_DIR")
+		if dir == "" {
+			log.Println("jwt: JWT_KEYS_DIR is not set, using an ephemeral signing key")
+			keyring, keyringErr = jwtkeys.Ephemeral()
+			return
+		}
+		keyring, keyringErr = jwtkeys.LoadDir(dir, os.Getenv("JWT_SIGNING_KID"))
+	})
+	return keyring, keyringErr
+}
+
 func GenerateAccessJWT(userID uint, role string) (string, error) {
+	return GenerateSessionAccessJWT(userID, role, "")
+}
+
+// GenerateSessionAccessJWT — access-токен с id refresh-сессии (claim sid), чтобы отличать текущее устройство
+func GenerateSessionAccessJWT(userID uint, role, sessionID string) (string, error) {
+	kr, err := AccessKeyring()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := jwt.MapClaims{
+		"iss":  JWTIssuer(),
+		"aud":  JWTAudience(),
+		"jti":  uuid.NewString(),
 		"sub":  userID,
 		"role": role,
-		"iat":  time.Now().Unix(),
-		"exp":  time.Now().Add(accessTTL()).Unix(),
+		"iat":  jwt.NewNumericDate(now), // с мс: метка отзыва пользователя тоже в мс
+		"exp":  now.Add(AccessTTL()).Unix(),
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
 	}
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return t.SignedString(jwtSecret())
+	return kr.Sign(claims)
 }
 
+// ParseAccessJWT проверяет подпись (алгоритм строго по kid), exp, iss и aud; отзыв — middleware.CheckRevoked
 func ParseAccessJWT(tokenStr string) (*jwt.Token, jwt.MapClaims, error) {
+	kr, err := AccessKeyring()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	claims := jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
-		return jwtSecret(), nil
-	})
+	token, err := kr.Parse(tokenStr, claims,
+		jwt.WithIssuer(JWTIssuer()),
+		jwt.WithAudience(JWTAudience()),
+		jwt.WithExpirationRequired(),
+	)
 	return token, claims, err
 }
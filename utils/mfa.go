@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidMFAToken  = errors.New("invalid mfa token")
+	ErrInvalidMFASecret = errors.New("invalid mfa secret")
+)
+
+const (
+	mfaChallengePurpose = "mfa_challenge"
+	mfaSecretPurpose    = "mfa_secret"
+)
+
+// MFAChallengeTTL — сколько есть на ввод кода после пароля
+func MFAChallengeTTL() time.Duration {
+	return 5 * time.Minute
+}
+
+type MFAChallengeClaims struct {
+	UserID uint
+	ID     string // jti — одноразовость и счётчик попыток
+	Exp    time.Time
+}
+
+// GenerateMFAChallengeJWT — выдаётся после верного пароля вместо пары токенов
+func GenerateMFAChallengeJWT(userID uint) (string, MFAChallengeClaims, error) {
+	c := MFAChallengeClaims{UserID: userID, ID: uuid.NewString(), Exp: time.Now().Add(MFAChallengeTTL())}
+	t, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": c.UserID,
+		"jti": c.ID,
+		"pur": mfaChallengePurpose,
+		"exp": c.Exp.Unix(),
+	}).SignedString(purposeKey(mfaChallengePurpose))
+	return t, c, err
+}
+
+func ParseMFAChallengeJWT(tokenStr string) (MFAChallengeClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		return purposeKey(mfaChallengePurpose), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		return MFAChallengeClaims{}, ErrInvalidMFAToken
+	}
+
+	sub, _ := claims["sub"].(float64)
+	jti, _ := claims["jti"].(string)
+	pur, _ := claims["pur"].(string)
+	if sub <= 0 || jti == "" || pur != mfaChallengePurpose {
+		return MFAChallengeClaims{}, ErrInvalidMFAToken
+	}
+	exp, _ := claims.GetExpirationTime()
+
+	return MFAChallengeClaims{UserID: uint(sub), ID: jti, Exp: exp.Time}, nil
+}
+
+// mfaSecretKey: MFA_ENCRYPTION_KEY, иначе ключ выводится из JWT_SECRET.
+// Смена ключа делает недействительными все подключённые TOTP.
+func mfaSecretKey() []byte {
+	if v := os.Getenv("MFA_ENCRYPTION_KEY"); v != "" {
+		sum := sha256.Sum256([]byte(v))
+		return sum[:]
+	}
+	return purposeKey(mfaSecretPurpose)
+}
+
+// EncryptMFASecret — TOTP-секрет хранится зашифрованным (AES-256-GCM), base64(nonce|ciphertext)
+func EncryptMFASecret(secret string) (string, error) {
+	gcm, err := mfaGCM()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func DecryptMFASecret(enc string) (string, error) {
+	gcm, err := mfaGCM()
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil || len(raw) < gcm.NonceSize() {
+		return "", ErrInvalidMFASecret
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrInvalidMFASecret
+	}
+	return string(plain), nil
+}
+
+func mfaGCM() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(mfaSecretKey())
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// NewRecoveryCode — одноразовый код восстановления вида xxxxx-xxxxx (50 бит)
+func NewRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	s := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+	return s[:5] + "-" + s[5:], nil
+}
+
+// HashRecoveryCode — в базе только хэш; регистр и дефисы при вводе не важны
+func HashRecoveryCode(code string) string {
+	norm := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return HashRefresh(norm)
+}
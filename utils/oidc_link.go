@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidLinkToken = errors.New("invalid identity link token")
+
+const oidcLinkPurpose = "oidc_link"
+
+// OIDCLinkTTL — сколько есть, чтобы войти в существующий аккаунт и подтвердить привязку
+func OIDCLinkTTL() time.Duration {
+	return 15 * time.Minute
+}
+
+// OIDCLinkClaims — вход через провайдера, адрес которого уже занят аккаунтом UserID
+type OIDCLinkClaims struct {
+	UserID   uint
+	Provider string
+	Subject  string
+	Email    string
+	ID       string
+	Exp      time.Time
+}
+
+func GenerateOIDCLinkJWT(userID uint, provider, subject, email string) (string, OIDCLinkClaims, error) {
+	c := OIDCLinkClaims{UserID: userID, Provider: provider, Subject: subject, Email: email,
+		ID: uuid.NewString(), Exp: time.Now().Add(OIDCLinkTTL())}
+	t, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   c.UserID,
+		"prv":   c.Provider,
+		"psub":  c.Subject,
+		"email": c.Email,
+		"jti":   c.ID,
+		"pur":   oidcLinkPurpose,
+		"exp":   c.Exp.Unix(),
+	}).SignedString(purposeKey(oidcLinkPurpose))
+	return t, c, err
+}
+
+func ParseOIDCLinkJWT(tokenStr string) (OIDCLinkClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		return purposeKey(oidcLinkPurpose), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		return OIDCLinkClaims{}, ErrInvalidLinkToken
+	}
+
+	sub, _ := claims["sub"].(float64)
+	provider, _ := claims["prv"].(string)
+	subject, _ := claims["psub"].(string)
+	email, _ := claims["email"].(string)
+	jti, _ := claims["jti"].(string)
+	pur, _ := claims["pur"].(string)
+	if sub <= 0 || provider == "" || subject == "" || jti == "" || pur != oidcLinkPurpose {
+		return OIDCLinkClaims{}, ErrInvalidLinkToken
+	}
+	exp, _ := claims.GetExpirationTime()
+
+	return OIDCLinkClaims{UserID: uint(sub), Provider: provider, Subject: subject, Email: email, ID: jti, Exp: exp.Time}, nil
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"time"
+)
+
+func PasswordResetTTL() time.Duration {
+	if s := os.Getenv("PASSWORD_RESET_TTL_MIN"); s != "" {
+		if d, err := time.ParseDuration(s + "m"); err == nil {
+			return d
+		}
+	}
+	return 30 * time.Minute
+}
+
+// NewPasswordResetToken — plain уходит в письмо, в Redis только hash (как у refresh)
+func NewPasswordResetToken() (plain, hashHex string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	plain = hex.EncodeToString(b)
+	hashHex = HashRefresh(plain)
+	return
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// PersonalTokenPrefix — по префиксу RequireAuth отличает токен скрипта от JWT,
+// а сканеры секретов находят токен в утёкшем коде
+const PersonalTokenPrefix = "gbp_"
+
+// NewPersonalToken: хранится только hash, как у refresh-токенов; plain показывается один раз
+func NewPersonalToken() (plain, hashHex string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	plain = PersonalTokenPrefix + hex.EncodeToString(b)
+	hashHex = HashRefresh(plain)
+	return
+}
+
+func IsPersonalToken(token string) bool {
+	return strings.HasPrefix(token, PersonalTokenPrefix)
+}
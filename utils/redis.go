@@ -7,14 +7,52 @@ import (
 	"strings"
 )
 
+// Ключи сессий пользователя несут hash tag {<id>}: в Redis Cluster они в одном слоте,
+// и Lua-скрипты RefreshRedisStore получают их все через KEYS.
+
+// RefreshUserKey — set id сессий пользователя
 func RefreshUserKey(userID uint) string {
-	return fmt.Sprintf("refresh:user:%d", userID)
+	return fmt.Sprintf("refresh:{%d}:sessions", userID)
+}
+
+// RefreshSessionKey — hash с метаданными сессии и хэшем её текущего refresh-токена
+func RefreshSessionKey(userID uint, sessionID string) string {
+	return fmt.Sprintf("refresh:{%d}:session:%s", userID, sessionID)
+}
+
+// RefreshUsedKey — метка ротированного токена: по ней ловим повторное предъявление
+func RefreshUsedKey(userID uint, hash string) string {
+	return fmt.Sprintf("refresh:{%d}:used:%s", userID, hash)
+}
+
+func PasswordResetTokenKey(hash string) string {
+	return "pwreset:token:" + hash
 }
 
+func PasswordResetUserKey(userID uint) string {
+	return fmt.Sprintf("pwreset:user:%d", userID)
+}
+
+// RefreshTokenKey — указатель hash -> "<userID>:<sessionID>"; по нему находим слот пользователя
 func RefreshTokenKey(hash string) string {
 	return "refresh:token:" + hash
 }
 
+// AccessDenyKey — отозванный access-токен по jti
+func AccessDenyKey(jti string) string {
+	return "access:deny:" + jti
+}
+
+// AccessSessionRevokedKey — все access-токены сессии отозваны
+func AccessSessionRevokedKey(sessionID string) string {
+	return "access:revoked:session:" + sessionID
+}
+
+// AccessUserWatermarkKey — unix-время: access-токены пользователя, выданные раньше, отозваны
+func AccessUserWatermarkKey(userID uint) string {
+	return fmt.Sprintf("access:revoked:user:%d", userID)
+}
+
 func PostsListVersionKey() string {
 	return "posts:list:ver"
 }
@@ -25,3 +63,36 @@ func PostsListsCacheKey(version int64, page, limit int, q string) string {
 	qh := hex.EncodeToString(sum[:8])
 	return fmt.Sprintf("posts:list:v%d:p%d:l%d:q%s", version, page, limit, qh)
 }
+
+func OneTimeKey(purpose, id string) string {
+	return "once:" + purpose + ":" + id
+}
+
+func CounterKey(name string) string {
+	return "counter:" + name
+}
+
+// LoginFailKey — неудачные входы по аккаунту (email в нижнем регистре)
+func LoginFailKey(account string) string {
+	return "login:fail:" + account
+}
+
+// LoginBlockKey — вход по аккаунту закрыт, пока ключ жив
+func LoginBlockKey(account string) string {
+	return "login:block:" + account
+}
+
+// OIDCStateKey — state незавершённого входа через внешнего провайдера
+func OIDCStateKey(state string) string {
+	return "oidc:state:" + state
+}
+
+// OIDCLoginKey — результат входа через провайдера до обмена одноразового кода (по hash)
+func OIDCLoginKey(hash string) string {
+	return "oidc:login:" + hash
+}
+
+// WSTicketKey — одноразовый билет на подключение к /ws (по hash)
+func WSTicketKey(hash string) string {
+	return "ws:ticket:" + hash
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// WSTicketTTL — билета хватает ровно на то, чтобы открыть сокет
+const WSTicketTTL = 30 * time.Second
+
+// NewWSTicket — plain уходит клиенту в ?ticket=, в Redis только hash
+func NewWSTicket() (plain, hashHex string, err error) {
+	return NewOneTimeCode()
+}
+
+// NewOneTimeCode — случайный одноразовый код: plain отдаётся клиенту, хранится только hash
+func NewOneTimeCode() (plain, hashHex string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	plain = hex.EncodeToString(b)
+	hashHex = HashRefresh(plain)
+	return
+}
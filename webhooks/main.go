@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"go_blog/config"
+	"go_blog/internal/repositories"
+	"go_blog/internal/webhooks"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	config.ConnectDB()
+
+	repo := repositories.NewWebhookRepository(config.DB)
+	dispatcher := webhooks.NewDispatcher(repo, webhooks.NewSender(nil), webhooks.DefaultConfig())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Println("webhook dispatcher started")
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			// пока есть работа — не ждём следующий тик
+			for {
+				n, err := dispatcher.RunOnce(ctx)
+				if err != nil {
+					log.Println("dispatch error:", err)
+					break
+				}
+				if n == 0 || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}